@@ -0,0 +1,83 @@
+package oss
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// AppendHandle appends the data to the object one segment after another, it tracks the append position and the
+// CRC64 of the whole object, so that each append is verified to continue the data appended before.
+// It's not safe for concurrent use.
+type AppendHandle struct {
+	bucket    Bucket
+	objectKey string
+	position  int64  // the next append position
+	crc       uint64 // the CRC64 of the object's data appended
+}
+
+//
+// AppendObjectVerified Appends the first segment to the new object and returns the handle for appending the others.
+//
+// The CRC64 of the whole object is tracked by the handle and passed to each append as InitCRC, the append fails
+// with CRCCheckError if the object's CRC64 returned by OSS is not the one of the data appended. The CRC needs to
+// be enabled by EnableCRC, which is the default.
+//
+// objectKey  the object to append to, it should not exist.
+// reader     the data of the first segment.
+// options    the options for the first append, such as CacheControl, ContentDisposition, ServerSideEncryption and ObjectACL.
+//
+// *AppendHandle  the handle for the following appends. It's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) AppendObjectVerified(objectKey string, reader io.Reader, options ...Option) (*AppendHandle, error) {
+	if !bucket.getConfig().IsEnableCRC {
+		return nil, errors.New("oss: the CRC is disabled, AppendObjectVerified needs it to verify the appends")
+	}
+
+	handle := &AppendHandle{bucket: bucket, objectKey: objectKey}
+	if err := handle.Append(reader, options...); err != nil {
+		return nil, err
+	}
+	return handle, nil
+}
+
+//
+// Append Appends the segment at the end of the data appended by the handle and verifies the object's CRC64.
+//
+// The position and the CRC64 of the handle are not changed when the append fails.
+//
+// reader   the data of the segment.
+// options  the options for the append, such as Progress and WithContext.
+//
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (handle *AppendHandle) Append(reader io.Reader, options ...Option) error {
+	if reader == nil {
+		// the CRC64 is computed from the body, it's the empty one
+		reader = bytes.NewReader(nil)
+	}
+	request := &AppendObjectRequest{
+		ObjectKey: handle.objectKey,
+		Reader:    reader,
+		Position:  handle.position,
+	}
+	result, resp, err := handle.bucket.doAppendObject(request, append(options, InitCRC(handle.crc)))
+	if err != nil {
+		return err
+	}
+
+	handle.position = result.NextPosition
+	handle.crc = resp.ClientCRC
+	return nil
+}
+
+// Position returns the next append position, which is the length of the object
+func (handle *AppendHandle) Position() int64 {
+	return handle.position
+}
+
+// CRC64 returns the CRC64 of the object's data appended
+func (handle *AppendHandle) CRC64() uint64 {
+	return handle.crc
+}
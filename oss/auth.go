@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
 	"hash"
 	"io"
@@ -18,16 +19,27 @@ type headerSorter struct {
 	Vals []string
 }
 
-// sign the header and set it as the authorization header.
+// sign the header by the credentials of the provider and set it as the authorization header.
 func (conn Conn) signHeader(req *http.Request, canonicalizedResource string) {
+	accessKeyID, accessKeySecret, securityToken := conn.config.CredentialsProvider.GetCredentials()
+	conn.signHeaderWithCredentials(req, canonicalizedResource, accessKeyID, accessKeySecret, securityToken)
+}
+
+// sign the header by the credentials and set it as the authorization header.
+func (conn Conn) signHeaderWithCredentials(req *http.Request, canonicalizedResource, accessKeyID, accessKeySecret,
+	securityToken string) {
+	if securityToken != "" {
+		req.Header.Set(HTTPHeaderOssSecurityToken, securityToken)
+	}
+
 	// Get the final Authorization' string
-	authorizationStr := "OSS " + conn.config.AccessKeyID + ":" + conn.getSignedStr(req, canonicalizedResource)
+	authorizationStr := conn.signPrefix() + accessKeyID + ":" + conn.getSignedStr(req, canonicalizedResource, accessKeySecret)
 
 	// Give the parameter "Authorization" value
 	req.Header.Set(HTTPHeaderAuthorization, authorizationStr)
 }
 
-func (conn Conn) getSignedStr(req *http.Request, canonicalizedResource string) string {
+func (conn Conn) getSignedStr(req *http.Request, canonicalizedResource, accessKeySecret string) string {
 	// Find out the "x-oss-"'s address in this request'header
 	temp := make(map[string]string)
 
@@ -54,13 +66,39 @@ func (conn Conn) getSignedStr(req *http.Request, canonicalizedResource string) s
 	contentMd5 := req.Header.Get(HTTPHeaderContentMD5)
 
 	signStr := req.Method + "\n" + contentMd5 + "\n" + contentType + "\n" + date + "\n" + canonicalizedOSSHeaders + canonicalizedResource
-	h := hmac.New(func() hash.Hash { return sha1.New() }, []byte(conn.config.AccessKeySecret))
+	if conn.config.isLogging() {
+		conn.config.Logger.Debugf("oss: the string to sign %q", redactStringToSign(signStr))
+	}
+	h := conn.newSignHash(accessKeySecret)
 	io.WriteString(h, signStr)
 	signedStr := base64.StdEncoding.EncodeToString(h.Sum(nil))
 
 	return signedStr
 }
 
+// sign the base64 encoded policy of the browser upload form
+func (conn Conn) signPolicy(policy, accessKeySecret string) string {
+	h := conn.newSignHash(accessKeySecret)
+	io.WriteString(h, policy)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// the HMAC of the signature by the SignHash in the config
+func (conn Conn) newSignHash(accessKeySecret string) hash.Hash {
+	if conn.config.SignHash == SignHashSHA256 {
+		return hmac.New(func() hash.Hash { return sha256.New() }, []byte(accessKeySecret))
+	}
+	return hmac.New(func() hash.Hash { return sha1.New() }, []byte(accessKeySecret))
+}
+
+// the prefix of the Authorization header by the SignHash in the config
+func (conn Conn) signPrefix() string {
+	if conn.config.SignHash == SignHashSHA256 {
+		return "OSS-HMAC-SHA256 "
+	}
+	return "OSS "
+}
+
 // Additional function for function SignHeader.
 func newHeaderSorter(m map[string]string) *headerSorter {
 	hs := &headerSorter{
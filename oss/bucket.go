@@ -1,10 +1,14 @@
 package oss
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"hash"
 	"hash/crc64"
@@ -14,6 +18,8 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,19 +29,54 @@ type Bucket struct {
 	BucketName string
 }
 
+// bucketInfoCache caches the information of the buckets which rarely changes by the bucket names, it's shared by the
+// buckets of the client
+type bucketInfoCache struct {
+	mu             sync.Mutex
+	storageClasses map[string]StorageClassType
+	encryptions    map[string]ServerEncryptionRule // the default encryptions, it's empty for the bucket without it
+}
+
+func newBucketInfoCache() *bucketInfoCache {
+	return &bucketInfoCache{
+		storageClasses: map[string]StorageClassType{},
+		encryptions:    map[string]ServerEncryptionRule{},
+	}
+}
+
+// setEncryption caches the bucket's default encryption got by GetBucketEncryption, the nil rule removes it
+func (cache *bucketInfoCache) setEncryption(bucketName string, rule *ServerEncryptionRule) {
+	if cache == nil {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if rule == nil {
+		delete(cache.encryptions, bucketName)
+	} else {
+		cache.encryptions[bucketName] = *rule
+	}
+}
+
 //
 // PutObject Creates a new object and it will overwrite the original one if it exists already.
 //
 // objectKey  The object key in UTF-8 encoding. The length must be between 1 to 1023 and cannot start with "/" or "\".
 // reader     io.Reader instance for reading the data for uploading
 // options    The options for uploading the object. The valid options here are CacheControl, ContentDisposition, ContentEncoding
-// Expires,ServerSideEncryption, ObjectACL and Meta. Please checks out the following link for the detail.
+// Expires,ServerSideEncryption, ObjectACL, ObjectStorageClass, SetObjectTagging and Meta. Please checks out the following link for the detail.
 // https://help.aliyun.com/document_detail/oss/api-reference/object/PutObject.html
+// VerifyETag could be used to compare the returned ETag with the content MD5, the reader must be seekable then.
+// IfNoneMatch("*") or ForbidOverwrite(true) creates the object only if it does not exist, it fails with
+// ErrObjectAlreadyExists otherwise. It's atomic in OSS, so it could be used as a lock.
 //
 // error  it will be nil if the operation succeeds, non-null if errors occurred.
 //
 func (bucket Bucket) PutObject(objectKey string, reader io.Reader, options ...Option) error {
-	opts := addContentType(options, objectKey)
+	opts, err := addContentTypeFromReader(options, reader, objectKey)
+	if err != nil {
+		return err
+	}
 
 	request := &PutObjectRequest{
 		ObjectKey: objectKey,
@@ -50,6 +91,57 @@ func (bucket Bucket) PutObject(objectKey string, reader io.Reader, options ...Op
 	return err
 }
 
+//
+// PutObjectWithContext Creates a new object bound to the context, checks out PutObject for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) PutObjectWithContext(ctx context.Context, objectKey string, reader io.Reader, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.PutObject(objectKey, reader, options...)
+}
+
+//
+// PutObjectWithResult Creates a new object and returns the result of the upload.
+//
+// It's same as PutObject, but the returned PutObjectResult carries the server side encryption
+// actually applied by OSS, so the caller could confirm that the bucket's default encryption or
+// the ServerSideEncryption option took effect. It carries the request id, the ETag and the CRC64 as well.
+// With the logging enabled, the ServerSideEncryption different from the bucket's default encryption is warned in the log
+// if the default encryption is got by GetBucketEncryption of the client before.
+//
+// objectKey  The object key.
+// reader     io.Reader instance for reading the data for uploading
+// options    The options for uploading the object. Checks out the details in parameter options in PutObject.
+//
+// PutObjectResult  The result object, it's valid when error is nil.
+// error  it will be nil if the operation succeeds, non-null if errors occurred.
+//
+func (bucket Bucket) PutObjectWithResult(objectKey string, reader io.Reader, options ...Option) (PutObjectResult, error) {
+	var out PutObjectResult
+	opts, err := addContentTypeFromReader(options, reader, objectKey)
+	if err != nil {
+		return out, err
+	}
+
+	request := &PutObjectRequest{
+		ObjectKey: objectKey,
+		Reader:    reader,
+	}
+	resp, err := bucket.DoPutObject(request, opts)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	out.ServerSideEncryption = resp.Headers.Get(HTTPHeaderOssServerSideEncryption)
+	out.ServerSideEncryptionKeyID = resp.Headers.Get(HTTPHeaderOssServerSideEncryptionKeyID)
+	out.RequestID = resp.Headers.Get(HTTPHeaderOssRequestID)
+	out.ETag = resp.Headers.Get(HTTPHeaderEtag)
+	out.CRC64 = resp.ServerCRC
+	return out, nil
+}
+
 //
 // PutObjectFromFile Creates a new object from the local file.
 //
@@ -66,7 +158,10 @@ func (bucket Bucket) PutObjectFromFile(objectKey, filePath string, options ...Op
 	}
 	defer fd.Close()
 
-	opts := addContentType(options, filePath, objectKey)
+	opts, err := addContentTypeFromReader(options, fd, filePath, objectKey)
+	if err != nil {
+		return err
+	}
 
 	request := &PutObjectRequest{
 		ObjectKey: objectKey,
@@ -81,6 +176,16 @@ func (bucket Bucket) PutObjectFromFile(objectKey, filePath string, options ...Op
 	return err
 }
 
+//
+// PutObjectFromFileWithContext Creates a new object from the local file bound to the context, checks out PutObjectFromFile for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) PutObjectFromFileWithContext(ctx context.Context, objectKey, filePath string, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.PutObjectFromFile(objectKey, filePath, options...)
+}
+
 //
 // DoPutObject Does the actual upload work
 //
@@ -97,25 +202,50 @@ func (bucket Bucket) DoPutObject(request *PutObjectRequest, options []Option) (*
 	}
 
 	listener := getProgressListener(options)
+	bucket.warnUnusualOptions(request.ObjectKey, options)
+
+	isVerify, err := findOption(options, verifyETag, false)
+	if err != nil {
+		return nil, err
+	}
+	contentMD5 := ""
+	if isVerify.(bool) {
+		if contentMD5, err = calcSeekableMD5(request.Reader); err != nil {
+			return nil, err
+		}
+	}
 
 	params := map[string]interface{}{}
 	resp, err := bucket.do("PUT", request.ObjectKey, params, options, request.Reader, listener)
 	if err != nil {
-		return nil, err
+		return nil, checkObjectAlreadyExists(err, options)
 	}
 
 	if bucket.getConfig().IsEnableCRC {
-		err = checkCRC(resp, "DoPutObject")
+		err = bucket.checkCRC(resp, "DoPutObject")
 		if err != nil {
 			return resp, err
 		}
 	}
 
 	err = checkRespCode(resp.StatusCode, []int{http.StatusOK})
+	if err == nil && isVerify.(bool) {
+		err = checkETag(resp, contentMD5, "DoPutObject")
+	}
 
 	return resp, err
 }
 
+//
+// DoPutObjectWithContext Does the actual upload work bound to the context, checks out DoPutObject for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) DoPutObjectWithContext(ctx context.Context, request *PutObjectRequest, options []Option) (*Response, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DoPutObject(request, options)
+}
+
 //
 // GetObject Download the object.
 //
@@ -123,6 +253,10 @@ func (bucket Bucket) DoPutObject(request *PutObjectRequest, options []Option) (*
 // options   The options for downloading the object. The valid values are: Range, IfModifiedSince, IfUnmodifiedSince, IfMatch,
 // IfNoneMatch,AcceptEncoding. For more details, please check out:
 // https://help.aliyun.com/document_detail/oss/api-reference/object/GetObject.html
+// VersionId gets the specified version of the object.
+// ReadAhead could be used to buffer the response body when the data is consumed in small pieces.
+// VerifyCRC(true) checks the CRC64 of the whole object when the body is read to the end, the mismatch is returned as a
+// CRCCheckError from the last Read and Close.
 //
 // io.ReadCloser  reader instance for reading data from response. It must be called close() after the usage and only valid when error is nil.
 // error  It's nil when no error occurred. Otherwise it's the error object.
@@ -135,6 +269,97 @@ func (bucket Bucket) GetObject(objectKey string, options ...Option) (io.ReadClos
 	return result.Response.Body, nil
 }
 
+//
+// GetObjectWithContext Downloads the object bound to the context, checks out GetObject for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) GetObjectWithContext(ctx context.Context, objectKey string, options ...Option) (io.ReadCloser, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.GetObject(objectKey, options...)
+}
+
+//
+// GetObjects Downloads the objects into memory concurrently, such as loading many small config objects.
+//
+// keys      the keys of the objects, the duplicated keys are downloaded once.
+// routines  the count of the objects downloaded at the same time, it's 1 at least.
+// options   the options for downloading each object, checks out GetObject for the valid values.
+// MaxObjectSize fails the larger object without reading it all into memory.
+//
+// map[string][]byte  the contents of the objects downloaded, keyed by the object key.
+// map[string]error  the errors of the objects failed, keyed by the object key. Such as IsNoSuchKey is true for the missing object.
+//
+func (bucket Bucket) GetObjects(keys []string, routines int, options ...Option) (map[string][]byte, map[string]error) {
+	contents := map[string][]byte{}
+	errs := map[string]error{}
+
+	maxSize, err := findOption(options, maxObjectSize, int64(0))
+	if err != nil {
+		for _, key := range keys {
+			errs[key] = err
+		}
+		return contents, errs
+	}
+
+	if routines < 1 {
+		routines = 1
+	}
+	jobs := make(chan string, len(keys))
+	queued := map[string]bool{}
+	for _, key := range keys {
+		if !queued[key] {
+			queued[key] = true
+			jobs <- key
+		}
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < routines; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				data, err := bucket.getObjectBytes(key, maxSize.(int64), options)
+				mu.Lock()
+				if err != nil {
+					errs[key] = err
+				} else {
+					contents[key] = data
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return contents, errs
+}
+
+// getObjectBytes reads the object into memory, the object larger than maxSize fails when maxSize is positive
+func (bucket Bucket) getObjectBytes(objectKey string, maxSize int64, options []Option) ([]byte, error) {
+	body, err := bucket.GetObject(objectKey, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if maxSize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("oss: the object %s is larger than the max size %d", objectKey, maxSize)
+	}
+	return data, nil
+}
+
 //
 // GetObjectToFile Download the data to a local file
 //
@@ -160,25 +385,54 @@ func (bucket Bucket) GetObjectToFile(objectKey, filePath string, options ...Opti
 		return err
 	}
 
+	// the partial file is removed on any failure, it's kept only when it's renamed to filePath
+	isRenamed := false
+	defer func() {
+		if !isRenamed {
+			os.Remove(tempFilePath)
+		}
+	}()
+
 	// copy the data to the local file path.
-	_, err = io.Copy(fd, result.Response.Body)
+	written, err := copyBuffer(fd, result.Response.Body, bucket.getConfig().IOBufferSize)
 	fd.Close()
 	if err != nil {
 		return err
 	}
 
-	// compares the CRC value
+	// checks the length against the Content-Length, so that the truncated download does not end up in the file
 	hasRange, _, _ := isOptionSet(options, HTTPHeaderRange)
+	if !hasRange {
+		err = checkDownloadLength(result.Response, written, "GetObjectToFile")
+		if err != nil {
+			return err
+		}
+	}
+
+	// compares the CRC value
 	if bucket.getConfig().IsEnableCRC && !hasRange {
 		result.Response.ClientCRC = result.ClientCRC.Sum64()
-		err = checkCRC(result.Response, "GetObjectToFile")
+		err = bucket.checkCRC(result.Response, "GetObjectToFile")
 		if err != nil {
-			os.Remove(tempFilePath)
 			return err
 		}
 	}
 
-	return os.Rename(tempFilePath, filePath)
+	if err = os.Rename(tempFilePath, filePath); err != nil {
+		return err
+	}
+	isRenamed = true
+	return nil
+}
+
+//
+// GetObjectToFileWithContext Downloads the object into a local file bound to the context, checks out GetObjectToFile for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) GetObjectToFileWithContext(ctx context.Context, objectKey, filePath string, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.GetObjectToFile(objectKey, filePath, options...)
 }
 
 //
@@ -191,7 +445,10 @@ func (bucket Bucket) GetObjectToFile(objectKey, filePath string, options ...Opti
 // error  It's nil if no error; otherwise it's the error object
 //
 func (bucket Bucket) DoGetObject(request *GetObjectRequest, options []Option) (*GetObjectResult, error) {
-	params := map[string]interface{}{}
+	params, err := getRawParams(options)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := bucket.do("GET", request.ObjectKey, params, options, nil, nil)
 	if err != nil {
 		return nil, err
@@ -214,11 +471,75 @@ func (bucket Bucket) DoGetObject(request *GetObjectRequest, options []Option) (*
 	listener := getProgressListener(options)
 
 	contentLen, _ := strconv.ParseInt(resp.Headers.Get(HTTPHeaderContentLength), 10, 64)
-	resp.Body = ioutil.NopCloser(TeeReader(resp.Body, crcCalc, contentLen, listener, nil))
+	reader := TeeReader(resp.Body, crcCalc, contentLen, listener, nil)
+
+	// read ahead, the buffer is on top of the crc calculation so that every byte is counted once
+	bufSize, err := findOption(options, readAhead, 0)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if bufSize.(int) > 0 {
+		reader = bufio.NewReaderSize(reader, bufSize.(int))
+	}
+
+	isVerify, err := findOption(options, verifyCRC, false)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if isVerify.(bool) && crcCalc != nil {
+		resp.Body = &crcCheckReader{reader: reader, body: resp.Body, resp: resp, crc: crcCalc, bucket: &bucket}
+	} else {
+		resp.Body = ioutil.NopCloser(reader)
+	}
 
 	return result, nil
 }
 
+// crcCheckReader compares the CRC64 of the response body with the server's when the body is read to the end
+type crcCheckReader struct {
+	reader io.Reader
+	body   io.ReadCloser
+	resp   *Response
+	crc    hash.Hash64
+	bucket *Bucket
+	err    error // the CRC mismatch
+}
+
+func (r *crcCheckReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.reader.Read(p)
+	if err == io.EOF {
+		r.resp.ClientCRC = r.crc.Sum64()
+		if r.err = r.bucket.checkCRC(r.resp, "GetObject"); r.err != nil {
+			return n, r.err
+		}
+	}
+	return n, err
+}
+
+// Close closes the response body, it returns the CRC mismatch if any
+func (r *crcCheckReader) Close() error {
+	err := r.body.Close()
+	if r.err != nil {
+		return r.err
+	}
+	return err
+}
+
+//
+// DoGetObjectWithContext Does the actual download work bound to the context, checks out DoGetObject for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) DoGetObjectWithContext(ctx context.Context, request *GetObjectRequest, options []Option) (*GetObjectResult, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DoGetObject(request, options)
+}
+
 //
 // CopyObject Copy the object inside the bucket.
 //
@@ -229,21 +550,38 @@ func (bucket Bucket) DoGetObject(request *GetObjectRequest, options []Option) (*
 // Also you can specify the target object's attributes, such as CacheControl,ContentDisposition,ContentEncoding,Expires,
 // ServerSideEncryption, ObjectACL, Meta. For more details, check out this link:
 // https://help.aliyun.com/document_detail/oss/api-reference/object/CopyObject.html
+// VersionId copies from the specified version of the source object.
+//
+// The source object larger than MaxCopyObjectSize is copied by multipart copy as CopyFile does with Routines and Progress,
+// the size is only checked when the copy request fails. The target object's options such as ObjectACL apply to it too,
+// the ETag of the result is the multipart one and LastModified is not set then. The source object with VersionId is not copied by multipart copy, use CopyFile instead.
+//
+// The result has the target object's VersionId and CRC64 from the response headers, such as to verify the object
+// rewritten in place. Copying the object to itself with MetaReplace but without any metadata fails with
+// ErrMetaReplaceWithoutMeta before the request, because it would clear the metadata.
 //
 // error It's nil if no error; otherwise it's the error object.
 //
 func (bucket Bucket) CopyObject(srcObjectKey, destObjectKey string, options ...Option) (CopyObjectResult, error) {
 	var out CopyObjectResult
-	options = append(options, CopySource(bucket.BucketName, url.QueryEscape(srcObjectKey)))
-	params := map[string]interface{}{}
-	resp, err := bucket.do("PUT", destObjectKey, params, options, nil, nil)
+	if srcObjectKey == destObjectKey {
+		if err := checkMetaReplace(options); err != nil {
+			return out, err
+		}
+	}
+	source, err := copySourceWithVersion(bucket.BucketName, srcObjectKey, options)
 	if err != nil {
 		return out, err
 	}
+	copyOptions := append(options, source)
+	params := map[string]interface{}{}
+	resp, err := bucket.do("PUT", destObjectKey, params, copyOptions, nil, nil)
+	if err != nil {
+		return bucket.copyLargeObject(bucket.BucketName, srcObjectKey, bucket.BucketName, destObjectKey, options, err)
+	}
 	defer resp.Body.Close()
 
-	err = xmlUnmarshal(resp.Body, &out)
-	return out, err
+	return getCopyObjectResult(resp)
 }
 
 //
@@ -283,21 +621,85 @@ func (bucket Bucket) CopyObjectFrom(srcBucketName, srcObjectKey, destObjectKey s
 
 func (bucket Bucket) copy(srcObjectKey, destBucketName, destObjectKey string, options ...Option) (CopyObjectResult, error) {
 	var out CopyObjectResult
-	options = append(options, CopySource(bucket.BucketName, url.QueryEscape(srcObjectKey)))
-	headers := make(map[string]string)
-	err := handleOptions(headers, options)
+	if bucket.BucketName == destBucketName && srcObjectKey == destObjectKey {
+		if err := checkMetaReplace(options); err != nil {
+			return out, err
+		}
+	}
+	source, err := copySourceWithVersion(bucket.BucketName, srcObjectKey, options)
 	if err != nil {
 		return out, err
 	}
+	copyOptions := append(options, source)
+	headers := make(map[string]string)
+	if err = handleOptions(headers, copyOptions); err != nil {
+		return out, err
+	}
+	if err = setUserAgentSuffix(headers, copyOptions, bucket.getConfig().UserAgent); err != nil {
+		return out, err
+	}
 	params := map[string]interface{}{}
-	resp, err := bucket.Client.Conn.Do("PUT", destBucketName, destObjectKey, params, headers, nil, 0, nil)
+	resp, err := bucket.Client.Conn.DoWithContext(getContext(copyOptions), "PUT", destBucketName, destObjectKey,
+		params, headers, nil, 0, nil)
+	captureResponseHeaders(copyOptions, resp)
 	if err != nil {
-		return out, err
+		return bucket.copyLargeObject(bucket.BucketName, srcObjectKey, destBucketName, destObjectKey, options, err)
 	}
 	defer resp.Body.Close()
 
-	err = xmlUnmarshal(resp.Body, &out)
-	return out, err
+	return getCopyObjectResult(resp)
+}
+
+// getCopyObjectResult gets the result of the copy response, with the target object's version id and CRC64 in the headers
+func getCopyObjectResult(resp *Response) (CopyObjectResult, error) {
+	var out CopyObjectResult
+	err := xmlUnmarshal(resp.Body, &out)
+	if err != nil {
+		return out, err
+	}
+	out.VersionID = resp.Headers.Get(HTTPHeaderOssVersionID)
+	out.CRC64 = resp.ServerCRC
+	return out, nil
+}
+
+// checkMetaReplace checks MetaReplace of copying the object to itself, there must be the metadata to replace with
+func checkMetaReplace(options []Option) error {
+	directive, err := findOption(options, HTTPHeaderOssMetadataDirective, nil)
+	if err != nil {
+		return err
+	}
+	if directive == nil || directive.(string) != string(MetaReplace) {
+		return nil
+	}
+	headers := make(map[string]string)
+	if err := handleOptions(headers, options); err != nil {
+		return err
+	}
+	for key := range headers {
+		if isObjectMetaHeader(key) {
+			return nil
+		}
+	}
+	return ErrMetaReplaceWithoutMeta
+}
+
+// copySourceWithVersion is the CopySource option of the object, the version is set by the VersionId option in the copy options
+func copySourceWithVersion(srcBucketName, srcObjectKey string, options []Option) (Option, error) {
+	source := escapeCopySourceKey(srcObjectKey)
+	versionID, err := findOption(options, HTTPParamVersionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if versionID != nil {
+		source += "?" + HTTPParamVersionID + "=" + url.QueryEscape(versionID.(string))
+	}
+	return CopySource(srcBucketName, source), nil
+}
+
+// escapeCopySourceKey encodes the source object key once for the X-Oss-Copy-Source header, the space is encoded
+// as %20 like the key in the request path, because OSS does not decode "+" as the space in the header
+func escapeCopySourceKey(srcObjectKey string) string {
+	return strings.Replace(url.QueryEscape(srcObjectKey), "+", "%20", -1)
 }
 
 //
@@ -342,27 +744,40 @@ func (bucket Bucket) AppendObject(objectKey string, reader io.Reader, appendPosi
 // error  It's nil if no errors; otherwise it's the error object.
 //
 func (bucket Bucket) DoAppendObject(request *AppendObjectRequest, options []Option) (*AppendObjectResult, error) {
+	result, _, err := bucket.doAppendObject(request, options)
+	return result, err
+}
+
+// doAppendObject appends the object and returns the response as well, whose body is closed
+func (bucket Bucket) doAppendObject(request *AppendObjectRequest, options []Option) (*AppendObjectResult, *Response, error) {
 	params := map[string]interface{}{}
 	params["append"] = nil
 	params["position"] = strconv.FormatInt(request.Position, 10)
 	headers := make(map[string]string)
 
 	opts := addContentType(options, request.ObjectKey)
-	handleOptions(headers, opts)
+	if err := handleOptions(headers, opts); err != nil {
+		return nil, nil, err
+	}
+	if err := setUserAgentSuffix(headers, options, bucket.getConfig().UserAgent); err != nil {
+		return nil, nil, err
+	}
 
 	var initCRC uint64
-	isCRCSet, initCRCOpt, _ := isOptionSet(options, initCRC64)
+	isCRCSet, initCRCOpt, err := isOptionSet(options, initCRC64)
+	if err != nil {
+		return nil, nil, err
+	}
 	if isCRCSet {
 		initCRC = initCRCOpt.(uint64)
 	}
 
 	listener := getProgressListener(options)
-
-	handleOptions(headers, opts)
-	resp, err := bucket.Client.Conn.Do("POST", bucket.BucketName, request.ObjectKey, params, headers,
-		request.Reader, initCRC, listener)
+	resp, err := bucket.Client.Conn.DoWithContext(getContext(options), "POST", bucket.BucketName, request.ObjectKey,
+		params, headers, request.Reader, initCRC, listener)
+	captureResponseHeaders(options, resp)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
@@ -373,50 +788,128 @@ func (bucket Bucket) DoAppendObject(request *AppendObjectRequest, options []Opti
 	}
 
 	if bucket.getConfig().IsEnableCRC && isCRCSet {
-		err = checkCRC(resp, "AppendObject")
+		err = bucket.checkCRC(resp, "AppendObject")
 		if err != nil {
-			return result, err
+			return result, resp, err
 		}
 	}
 
-	return result, nil
+	return result, resp, nil
 }
 
 //
 // DeleteObject Deletes the object.
 //
 // objectKey The object key to delete.
+// options   The options for deleting the object. VersionId deletes the specified version of the object.
 //
 // error it's nil if no error; otherwise it's the error object
 //
-func (bucket Bucket) DeleteObject(objectKey string) error {
-	params := map[string]interface{}{}
-	resp, err := bucket.do("DELETE", objectKey, params, nil, nil, nil)
+func (bucket Bucket) DeleteObject(objectKey string, options ...Option) error {
+	_, err := bucket.DeleteObjectWithResult(objectKey, options...)
+	return err
+}
+
+//
+// DeleteObjectWithResult Deletes the object and returns the version information of the deletion.
+//
+// In the versioning enabled bucket, the deletion without VersionId does not remove any data but inserts a delete marker,
+// whose version id is returned in DeleteObjectResult.
+//
+// objectKey The object key to delete.
+// options   The options for deleting the object. VersionId deletes the specified version of the object.
+//
+// DeleteObjectResult  The result object, it's valid when error is nil.
+// error it's nil if no error; otherwise it's the error object
+//
+func (bucket Bucket) DeleteObjectWithResult(objectKey string, options ...Option) (DeleteObjectResult, error) {
+	var out DeleteObjectResult
+	params, err := getRawParams(options)
 	if err != nil {
-		return err
+		return out, err
+	}
+
+	resp, err := bucket.do("DELETE", objectKey, params, options, nil, nil)
+	if err != nil {
+		return out, err
 	}
 	defer resp.Body.Close()
-	return checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
+
+	out.VersionID = resp.Headers.Get(HTTPHeaderOssVersionID)
+	out.DeleteMarker = resp.Headers.Get(HTTPHeaderOssDeleteMarker) == "true"
+	out.RequestID = resp.Headers.Get(HTTPHeaderOssRequestID)
+	return out, checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
 }
 
 //
 // DeleteObjects Delete multiple objects.
 //
+// The keys are deleted by the batches of MaxDeleteObjectsKeys one after another, and the results of the batches
+// are merged. It stops at the first batch failed with the error.
+//
 // objectKeys The object keys to delete.
 // options The options for deleting objects.
 //         Supported option is DeleteObjectsQuiet which means it will not return error even deletion failed (not recommended). By default it's not used.
+//         FailOnPartialError makes it return a DeleteObjectsError listing the failed keys when some objects failed to delete.
+//         ConfirmDeleted makes it return the deleted keys in DeletedObjects as the confirmation, and the failed keys in
+//         FailedObjects without the error. The response lists every key then, which is about 1000 keys per batch for
+//         the large batches, while the quiet mode sends no key back, so it costs more traffic and time to parse.
 //
-// DeleteObjectsResult The result object.
+// DeleteObjectsResult The result object. With the error it has the results of the batches before the failed one.
 // error it's nil if no error; otherwise it's the error object
 //
 func (bucket Bucket) DeleteObjects(objectKeys []string, options ...Option) (DeleteObjectsResult, error) {
+	out := DeleteObjectsResult{}
+	isFailOnError, err := findOption(options, failOnPartialError, false)
+	if err != nil {
+		return out, err
+	}
+	isConfirm, err := findOption(options, confirmDeleted, false)
+	if err != nil {
+		return out, err
+	}
+	for {
+		n := len(objectKeys)
+		if n > MaxDeleteObjectsKeys {
+			n = MaxDeleteObjectsKeys
+		}
+		batch, err := bucket.deleteObjects(objectKeys[:n], options)
+		if err != nil {
+			return out, err
+		}
+		out.DeletedObjects = append(out.DeletedObjects, batch.DeletedObjects...)
+		out.FailedObjects = append(out.FailedObjects, batch.FailedObjects...)
+		out.RequestID = batch.RequestID
+		out.RequestIDs = append(out.RequestIDs, batch.RequestID)
+
+		objectKeys = objectKeys[n:]
+		if len(objectKeys) == 0 {
+			break
+		}
+	}
+
+	if isFailOnError.(bool) && !isConfirm.(bool) && out.HasErrors() {
+		return out, DeleteObjectsError{out.FailedObjects}
+	}
+	return out, nil
+}
+
+// deleteObjects deletes the objects by one request
+func (bucket Bucket) deleteObjects(objectKeys []string, options []Option) (DeleteObjectsResult, error) {
 	out := DeleteObjectsResult{}
 	dxml := deleteXML{}
 	for _, key := range objectKeys {
 		dxml.Objects = append(dxml.Objects, DeleteObject{Key: key})
 	}
-	isQuiet, _ := findOption(options, deleteObjectsQuiet, false)
-	dxml.Quiet = isQuiet.(bool)
+	isQuiet, err := findOption(options, deleteObjectsQuiet, false)
+	if err != nil {
+		return out, err
+	}
+	isConfirm, err := findOption(options, confirmDeleted, false)
+	if err != nil {
+		return out, err
+	}
+	dxml.Quiet = isQuiet.(bool) && !isConfirm.(bool)
 
 	bs, err := xml.Marshal(dxml)
 	if err != nil {
@@ -426,10 +919,11 @@ func (bucket Bucket) DeleteObjects(objectKeys []string, options ...Option) (Dele
 	buffer.Write(bs)
 
 	contentType := http.DetectContentType(buffer.Bytes())
-	options = append(options, ContentType(contentType))
+	// Content-MD5 is required by OSS to check the body is not altered, it overrides the one in the options
 	sum := md5.Sum(bs)
 	b64 := base64.StdEncoding.EncodeToString(sum[:])
-	options = append(options, ContentMD5(b64))
+	// the options are appended for each batch, so the caller's slice is not changed
+	options = append(options[:len(options):len(options)], ContentType(contentType), ContentMD5(b64))
 
 	params := map[string]interface{}{}
 	params["delete"] = nil
@@ -441,6 +935,7 @@ func (bucket Bucket) DeleteObjects(objectKeys []string, options ...Option) (Dele
 	}
 	defer resp.Body.Close()
 
+	out.RequestID = resp.Headers.Get(HTTPHeaderOssRequestID)
 	if !dxml.Quiet {
 		if err = xmlUnmarshal(resp.Body, &out); err == nil {
 			err = decodeDeleteObjectsResult(&out)
@@ -452,26 +947,56 @@ func (bucket Bucket) DeleteObjects(objectKeys []string, options ...Option) (Dele
 //
 // IsObjectExist Checks if the object exists.
 //
+// The symlink is checked itself, it exists even if its target is missing. So when GetObjectMeta, which follows the
+// symlink, fails with SymlinkTargetNotExist, GetSymlink is called to check the symlink. NoSuchKey, or 404 without the
+// error code such as from a proxy, means the object doesn't exist without another request.
+//
 // bool  flag of object's existence (true:exists;false:non-exist) when error is nil.
 //
 // error it's nil if no error; otherwise it's the error object
 //
 func (bucket Bucket) IsObjectExist(objectKey string) (bool, error) {
-	_, err := bucket.GetObjectMeta(objectKey)
+	_, found, err := bucket.GetObjectMetaIfExists(objectKey)
+	return found, err
+}
+
+//
+// GetObjectMetaIfExists Gets the object metadata by GetObjectMeta if the object exists, checks out IsObjectExist for
+// the existence of the symlink.
+//
+// objectKey object key
+//
+// http.Header the object's metadata, valid when the bool is true. For the symlink whose target is missing, it's
+//             the headers of GetSymlink.
+// bool  true if the object exists, false if it's not found.
+// error it's nil if no error; otherwise it's the error object, the object not found is not an error.
+//
+func (bucket Bucket) GetObjectMetaIfExists(objectKey string) (http.Header, bool, error) {
+	meta, err := bucket.GetObjectMeta(objectKey)
 	if err == nil {
-		return true, nil
+		return meta, true, nil
 	}
 
-	switch err.(type) {
-	case ServiceError:
-		if err.(ServiceError).StatusCode == 404 && err.(ServiceError).Code == "NoSuchKey" {
-			return false, nil
-		}
+	srvErr, _ := asServiceError(err)
+	switch {
+	case srvErr.Code == "NoSuchKey", srvErr.Code == "" && srvErr.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case srvErr.Code == "SymlinkTargetNotExist":
+	default:
+		return nil, false, err
 	}
 
-	return false, err
-}
-
+	// GetObjectMeta follows the symlink, the symlink whose target is missing exists itself
+	meta, err = bucket.GetSymlink(objectKey)
+	if err == nil {
+		return meta, true, nil
+	}
+	if IsNoSuchKey(err) {
+		return nil, false, nil
+	}
+	return nil, false, err
+}
+
 //
 // ListObjects Lists the objects under the current bucket.
 //
@@ -489,7 +1014,10 @@ func (bucket Bucket) IsObjectExist(objectKey string) (bool, error) {
 // For example, if the bucket has three objects fun/test.jpg, fun/movie/001.avi, fun/movie/007.avi. And if the prefix is "fun/", then it returns all three objects.
 // But if the delimiter is '/', then only "fun/test.jpg" is returned as files and fun/movie/ is returned as common prefix.
 //
-// For common usage scenario, checks out sample/list_object.go.
+// For common usage scenario, checks out sample/list_object.go. ListObjectsIterator and ForEachObject fetch the pages by the marker.
+//
+// With WithTags(true) the tags of each listed object are fetched by GetObjectTagging in Routines concurrent requests after the listing.
+// It costs one more request per object, so use it for small result sets only, such as with a small MaxKeys.
 //
 // ListObjectsResponse  The return value after operation succeeds (only valid when error is nil).
 //
@@ -502,7 +1030,7 @@ func (bucket Bucket) ListObjects(options ...Option) (ListObjectsResult, error) {
 		return out, err
 	}
 
-	resp, err := bucket.do("GET", "", params, nil, nil, nil)
+	resp, err := bucket.do("GET", "", params, options, nil, nil)
 	if err != nil {
 		return out, err
 	}
@@ -514,15 +1042,110 @@ func (bucket Bucket) ListObjects(options ...Option) (ListObjectsResult, error) {
 	}
 
 	err = decodeListObjectsResult(&out)
+	if err != nil {
+		return out, err
+	}
+
+	isWithTags, err := findOption(options, withTags, false)
+	if err != nil {
+		return out, err
+	}
+	if isWithTags.(bool) {
+		err = bucket.fillObjectTags(out.Objects, options)
+	}
 	return out, err
 }
 
+// fillObjectTags gets the tags of the objects concurrently, it returns the first error if any request fails
+func (bucket Bucket) fillObjectTags(objects []ObjectProperties, options []Option) error {
+	tagOptions, err := filterOptions(options, func(key string) bool {
+		return isRequestArg(key)
+	})
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(objects))
+	jobs := make(chan int, len(objects))
+	for i := range objects {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < getRoutines(options); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res, err := bucket.GetObjectTagging(objects[i].Key, tagOptions...)
+				objects[i].Tags, errs[i] = res.Tags, err
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// ListObjectsSince Lists the objects whose last modified time is not earlier than the specified time.
+//
+// OSS has no filter on the modified time, so it still scans all the keys matching the options page by page
+// and filters the objects in the client side. Use Prefix to narrow the scan when possible.
+//
+// since    The objects modified at or after this time are returned.
+// options  The filters for listing object, checks out the details in ListObjects. The MaxKeys is used as the page size,
+//          and the Marker is the start point of the scan. MaxPages bounds the pages scanned.
+//
+// []ObjectProperties  The matching objects, it's valid when error is nil.
+// error  It's nil if no error, otherwise it's an error object.
+//
+func (bucket Bucket) ListObjectsSince(since time.Time, options ...Option) ([]ObjectProperties, error) {
+	out := []ObjectProperties{}
+
+	marker, err := findOption(options, "marker", "")
+	if err != nil {
+		return out, err
+	}
+
+	max := getMaxPages(options)
+	for pages := 0; ; pages++ {
+		if max > 0 && pages >= max {
+			return out, MaxPagesError{BucketName: bucket.BucketName, Pages: pages, NextMarker: marker.(string)}
+		}
+		lor, err := bucket.ListObjects(append(options, Marker(marker.(string)))...)
+		if err != nil {
+			return out, err
+		}
+
+		for _, object := range lor.Objects {
+			if !object.LastModified.Before(since) {
+				out = append(out, object)
+			}
+		}
+
+		if !lor.IsTruncated {
+			break
+		}
+		marker = lor.NextMarker
+	}
+
+	return out, nil
+}
+
 //
 // SetObjectMeta Sets the metadata of the Object.
 //
 // objectKey object
 // options Options for setting the metadata. The valid options are CacheControl, ContentDisposition, ContentEncoding, Expires,
-// ServerSideEncryption, and custom metadata.
+// ServerSideEncryption, and custom metadata. The metadata not in the options is cleared, so it fails with
+// ErrMetaReplaceWithoutMeta if there's no metadata in the options.
 //
 // error It's nil if no errors;otherwise it's the error object.
 //
@@ -538,12 +1161,16 @@ func (bucket Bucket) SetObjectMeta(objectKey string, options ...Option) error {
 // objectKey object key.
 // objectPropertyConstraints The contraints of the object. Only when the object meet the requirements this method will return the metadata. Otherwise returns error. Valid options are IfModifiedSince, IfUnmodifiedSince,
 // IfMatch, IfNoneMatch. For more details check out https://help.aliyun.com/document_detail/oss/api-reference/object/HeadObject.html
+// VersionId gets the metadata of the specified version.
 //
 // http.Header  object meta when error is nil.
 // error  It's nil if no errors; otherwise it's the error object.
 //
 func (bucket Bucket) GetObjectDetailedMeta(objectKey string, options ...Option) (http.Header, error) {
-	params := map[string]interface{}{}
+	params, err := getRawParams(options)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := bucket.do("HEAD", objectKey, params, options, nil, nil)
 	if err != nil {
 		return nil, err
@@ -553,11 +1180,38 @@ func (bucket Bucket) GetObjectDetailedMeta(objectKey string, options ...Option)
 	return resp.Headers, nil
 }
 
+//
+// StatObject Gets the object's metadata parsed from the headers of GetObjectDetailedMeta.
+//
+// objectKey object key.
+// options The constraints of the object, checks out GetObjectDetailedMeta for the valid options.
+//
+// ObjectStat the object's metadata when error is nil, such as the size and the count of the tags.
+// error  It's nil if no errors; otherwise it's the error object.
+//
+func (bucket Bucket) StatObject(objectKey string, options ...Option) (ObjectStat, error) {
+	var out ObjectStat
+	meta, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return out, err
+	}
+
+	out.Size, _ = strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
+	out.ETag = meta.Get(HTTPHeaderEtag)
+	out.LastModified, _ = http.ParseTime(meta.Get(HTTPHeaderLastModified))
+	out.ObjectType = meta.Get(HTTPHeaderOssObjectType)
+	out.StorageClass = meta.Get(HTTPHeaderOssStorageClass)
+	out.TaggingCount, _ = strconv.Atoi(meta.Get(HTTPHeaderOssTaggingCount))
+	out.Headers = meta
+	return out, nil
+}
+
 //
 // GetObjectMeta Gets object metadata.
 //
 // GetObjectMeta is more lightweight than GetObjectDetailedMeta as it only returns basic metadata including ETag
 // size, LastModified. The size information is in the HTTP header Content-Length.
+// For the symlink it's the metadata of the target, StatSymlink gets the symlink's own metadata.
 //
 // objectKey object key
 //
@@ -577,6 +1231,98 @@ func (bucket Bucket) GetObjectMeta(objectKey string) (http.Header, error) {
 	return resp.Headers, nil
 }
 
+//
+// OptionObject Sends the CORS preflight request of the object, which is sent by the browser before the cross-origin
+// request, to check the CORS rules of the bucket set by SetBucketCORS.
+//
+// objectKey   the object key.
+// origin      the origin of the cross-origin request, such as "http://www.example.com".
+// method      the method of the cross-origin request, such as "PUT".
+// reqHeaders  the headers of the cross-origin request, nil if there's none.
+//
+// http.Header the CORS headers of the response such as Access-Control-Allow-Origin, valid when error is nil.
+// error it's nil if no error; otherwise it's the error object, the ServiceError with the status 403 if the request
+//       is not allowed by the CORS rules.
+//
+func (bucket Bucket) OptionObject(objectKey, origin, method string, reqHeaders []string) (http.Header, error) {
+	options := []Option{
+		Origin(origin),
+		setHeader(HTTPHeaderAccessControlRequestMethod, strings.ToUpper(method)),
+	}
+	if len(reqHeaders) > 0 {
+		options = append(options, setHeader(HTTPHeaderAccessControlRequestHeaders, strings.Join(reqHeaders, ",")))
+	}
+
+	params := map[string]interface{}{}
+	resp, err := bucket.do("OPTIONS", objectKey, params, options, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkRespCode(resp.StatusCode, []int{http.StatusOK}); err != nil {
+		return nil, err
+	}
+	headers := http.Header{}
+	for k, v := range resp.Headers {
+		if strings.HasPrefix(k, "Access-Control-") {
+			headers[k] = v
+		}
+	}
+	return headers, nil
+}
+
+//
+// SpotCheckObject Checks the integrity of the object by downloading the sample ranges only.
+//
+// OSS does not return the CRC of a range, so the CRC64 of each downloaded range is compared with the expected one
+// provided by the caller. The size and the ETag are got by GetObjectDetailedMeta first, the ranges must be in the object
+// and they are downloaded with IfMatch the ETag, so that the object changed during the check fails the check.
+//
+// objectKey     the object key.
+// sampleRanges  the ranges to check with their expected CRC64.
+// options       the options for GetObjectDetailedMeta and GetObject, such as VersionId.
+//
+// error  it's nil if all the ranges match; a CRCCheckError if a range mismatches; otherwise it's the error object.
+//
+func (bucket Bucket) SpotCheckObject(objectKey string, sampleRanges []SpotCheckRange, options ...Option) error {
+	meta, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return err
+	}
+	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	for _, sample := range sampleRanges {
+		if sample.Start < 0 || sample.End < sample.Start || sample.End >= objectSize {
+			return fmt.Errorf("oss: spot check range [%d, %d] is out of the object size %d", sample.Start, sample.End, objectSize)
+		}
+
+		opts := append(options, Range(sample.Start, sample.End), IfMatch(meta.Get(HTTPHeaderEtag)))
+		result, err := bucket.DoGetObject(&GetObjectRequest{objectKey}, opts)
+		if err != nil {
+			return err
+		}
+		crc := crc64.New(crcTable())
+		written, err := io.Copy(crc, result.Response.Body)
+		result.Response.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		operation := fmt.Sprintf("SpotCheckObject bytes=%d-%d", sample.Start, sample.End)
+		if written != sample.End-sample.Start+1 {
+			return fmt.Errorf("oss: %s got %d bytes", operation, written)
+		}
+		if crc.Sum64() != sample.CRC64 {
+			return CRCCheckError{crc.Sum64(), sample.CRC64, operation, result.Response.Headers.Get(HTTPHeaderOssRequestID)}
+		}
+	}
+	return nil
+}
+
 //
 // SetObjectACL updates the object's ACL.
 //
@@ -591,11 +1337,12 @@ func (bucket Bucket) GetObjectMeta(objectKey string) (http.Header, error) {
 //
 // objectKey the target object key (to set the ACL on)
 // objectAcl object ACL. Valid options are PrivateACL , PublicReadACL, PublicReadWriteACL.
+// options   The options for the request, such as CaptureResponseHeaders to get the request id.
 //
 // error it's nil if no error; otherwise it's the error object.
 //
-func (bucket Bucket) SetObjectACL(objectKey string, objectACL ACLType) error {
-	options := []Option{ObjectACL(objectACL)}
+func (bucket Bucket) SetObjectACL(objectKey string, objectACL ACLType, options ...Option) error {
+	options = append(options, ObjectACL(objectACL))
 	params := map[string]interface{}{}
 	params["acl"] = nil
 	resp, err := bucket.do("PUT", objectKey, params, options, nil, nil)
@@ -610,15 +1357,140 @@ func (bucket Bucket) SetObjectACL(objectKey string, objectACL ACLType) error {
 // GetObjectACL Gets object's ACL
 //
 // objectKey the object to get ACL from.
+// options   The options for the request, such as CaptureResponseHeaders.
 //
 // GetObjectACLResult The result object when error is nil.GetObjectACLResult.Acl is the object acl.
 // error it's nil if no error; otherwise it's the error object
 //
-func (bucket Bucket) GetObjectACL(objectKey string) (GetObjectACLResult, error) {
+func (bucket Bucket) GetObjectACL(objectKey string, options ...Option) (GetObjectACLResult, error) {
 	var out GetObjectACLResult
 	params := map[string]interface{}{}
 	params["acl"] = nil
-	resp, err := bucket.do("GET", objectKey, params, nil, nil, nil)
+	resp, err := bucket.do("GET", objectKey, params, options, nil, nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	return out, err
+}
+
+//
+// PutObjectTagging sets the tags of the object, the existing tags are replaced.
+//
+// objectKey  the object key.
+// tagging    the tags of the object. Each object could have up to 10 tags.
+//
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) PutObjectTagging(objectKey string, tagging Tagging) error {
+	bs, err := xml.Marshal(tagging)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	params := map[string]interface{}{}
+	params["tagging"] = nil
+	resp, err := bucket.do("PUT", objectKey, params, nil, buffer, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetObjectTagging gets the tags of the object.
+//
+// objectKey  the object key.
+// options    the options such as WithContext.
+//
+// GetObjectTaggingResult  the result object when error is nil, its Tags is empty if the object has no tag.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) GetObjectTagging(objectKey string, options ...Option) (GetObjectTaggingResult, error) {
+	var out GetObjectTaggingResult
+	params := map[string]interface{}{}
+	params["tagging"] = nil
+	resp, err := bucket.do("GET", objectKey, params, options, nil, nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	return out, err
+}
+
+//
+// DeleteObjectTagging deletes all the tags of the object.
+//
+// objectKey  the object key.
+//
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) DeleteObjectTagging(objectKey string) error {
+	params := map[string]interface{}{}
+	params["tagging"] = nil
+	resp, err := bucket.do("DELETE", objectKey, params, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
+}
+
+//
+// PutObjectLegalHold sets the legal hold of the object, which is independent of the retention. The object under the
+// legal hold can not be deleted or overwritten until the legal hold is off.
+//
+// objectKey  the object key.
+// status     LegalHoldOn or LegalHoldOff.
+// options    the options such as VersionId to set the legal hold of the version.
+//
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) PutObjectLegalHold(objectKey string, status LegalHoldStatus, options ...Option) error {
+	bs, err := xml.Marshal(LegalHold{Status: status})
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	params, err := getRawParams(options)
+	if err != nil {
+		return err
+	}
+	params["legal-hold"] = nil
+	resp, err := bucket.do("PUT", objectKey, params, options, buffer, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetObjectLegalHold gets the legal hold of the object.
+//
+// objectKey  the object key.
+// options    the options such as VersionId to get the legal hold of the version.
+//
+// GetObjectLegalHoldResult  the result object when error is nil, its IsDeleteBlocked tells if deleting the object fails.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) GetObjectLegalHold(objectKey string, options ...Option) (GetObjectLegalHoldResult, error) {
+	var out GetObjectLegalHoldResult
+	params, err := getRawParams(options)
+	if err != nil {
+		return out, err
+	}
+	params["legal-hold"] = nil
+	resp, err := bucket.do("GET", objectKey, params, options, nil, nil)
 	if err != nil {
 		return out, err
 	}
@@ -659,14 +1531,18 @@ func (bucket Bucket) PutSymlink(symObjectKey string, targetObjectKey string, opt
 // If the symlink object does not exist, returns 404.
 //
 // objectKey The symlink object's key.
+// options The options, such as VersionId.
 //
 // error it's nil if no error; otherwise it's the error object.
 // When error is nil, the target file key is in the X-Oss-Symlink-Target header of the returned object.
 //
-func (bucket Bucket) GetSymlink(objectKey string) (http.Header, error) {
-	params := map[string]interface{}{}
+func (bucket Bucket) GetSymlink(objectKey string, options ...Option) (http.Header, error) {
+	params, err := getRawParams(options)
+	if err != nil {
+		return nil, err
+	}
 	params["symlink"] = nil
-	resp, err := bucket.do("GET", objectKey, params, nil, nil, nil)
+	resp, err := bucket.do("GET", objectKey, params, options, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -681,12 +1557,48 @@ func (bucket Bucket) GetSymlink(objectKey string) (http.Header, error) {
 	return resp.Headers, err
 }
 
+//
+// StatSymlink Gets the symlink object's own metadata and its target without following the symlink.
+//
+// objectKey The symlink object's key.
+// options The options, such as VersionId.
+//
+// SymlinkMeta the target key and the metadata of the symlink. It's valid when error is nil.
+// error it's nil if no error; otherwise it's the error object. It's an error if the object is not a symlink.
+//
+func (bucket Bucket) StatSymlink(objectKey string, options ...Option) (SymlinkMeta, error) {
+	var out SymlinkMeta
+	meta, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return out, err
+	}
+
+	objectType := meta.Get(HTTPHeaderOssObjectType)
+	if objectType != "Symlink" {
+		return out, fmt.Errorf("oss: the object %s is not a symlink, its type is %s", objectKey, objectType)
+	}
+
+	symlink, err := bucket.GetSymlink(objectKey, options...)
+	if err != nil {
+		return out, err
+	}
+
+	out.TargetKey = symlink.Get(HTTPHeaderOssSymlinkTarget)
+	out.ObjectType = objectType
+	out.Size, _ = strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
+	out.ETag = meta.Get(HTTPHeaderEtag)
+	out.LastModified, _ = http.ParseTime(meta.Get(HTTPHeaderLastModified))
+	out.Headers = meta
+	return out, nil
+}
+
 //
 // RestoreObject Restore the object from the archive storage.
 //
 // An archive object is in cold status by default and it cannot be accessed.
 // When restore is called on the cold object, it will become available for access after some time.
-// If multiple restores are called on the same file when the object is being restored, server side does nothing for additional calls but returns success.
+// If multiple restores are called on the same file when the object is being restored, the additional calls fail with 409 RestoreAlreadyInProgress,
+// which could be checked by IsRestoreInProgress.
 // By default, the restored object is available for access for one day. After that it will be unavailable again.
 // But if another restored are called after the file is restored， then it will extend one day's access time of that object, up to 7 days.
 //
@@ -695,9 +1607,111 @@ func (bucket Bucket) GetSymlink(objectKey string) (http.Header, error) {
 // error it's nil if no error; otherwise it's the error object
 //
 func (bucket Bucket) RestoreObject(objectKey string) error {
+	return bucket.restoreObject(objectKey, nil)
+}
+
+//
+// RestoreObjectDetail Restores the Archive or ColdArchive object with the configuration.
+//
+// The JobParameters is supported by the ColdArchive object only, so the storage class of the object is checked
+// by GetObjectDetailedMeta before the restore when it's set.
+//
+// objectKey  object key to restore.
+// config     the restore configuration, the Days could be 0 to use the default.
+//
+// error it's nil if no error; otherwise it's the error object. IsRestoreInProgress(error) is true if the object is being
+// restored, then the caller could poll the X-Oss-Restore header by GetObjectDetailedMeta until it's ongoing-request="false".
+//
+func (bucket Bucket) RestoreObjectDetail(objectKey string, config RestoreConfiguration) error {
+	if config.JobParameters != nil {
+		tier := config.JobParameters.Tier
+		switch tier {
+		case RestoreExpedited, RestoreStandard, RestoreBulk:
+		default:
+			return fmt.Errorf("oss: invalid restore tier %s, it must be Expedited, Standard or Bulk", tier)
+		}
+
+		meta, err := bucket.GetObjectDetailedMeta(objectKey)
+		if err != nil {
+			return err
+		}
+		if class := StorageClassType(meta.Get(HTTPHeaderOssStorageClass)); class != StorageColdArchive {
+			return fmt.Errorf("oss: the restore tier %s is supported by the ColdArchive object only, but %s is %s", tier, objectKey, class)
+		}
+	}
+
+	var body []byte
+	if config.Days > 0 || config.JobParameters != nil {
+		bs, err := xml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		body = bs
+	}
+	return bucket.restoreObject(objectKey, body)
+}
+
+//
+// RestoreObjects Restores the archive objects concurrently.
+//
+// The object being restored already (409 RestoreAlreadyInProgress) is treated as success and marked as in progress.
+//
+// objectKeys  the objects to restore.
+// config      the restore configuration, checks out RestoreObjectDetail for the details.
+// routines    the count of concurrent restore requests, it's in the range [1, 100].
+//
+// []RestoreResult  the result of each object in the order of objectKeys.
+// error  it's nil if all the restores succeed; otherwise it's the first error in the results.
+//
+func (bucket Bucket) RestoreObjects(objectKeys []string, config RestoreConfiguration, routines int) ([]RestoreResult, error) {
+	if routines < 1 {
+		routines = 1
+	} else if routines > 100 {
+		routines = 100
+	}
+
+	results := make([]RestoreResult, len(objectKeys))
+	jobs := make(chan int, len(objectKeys))
+	for i := range objectKeys {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < routines; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = RestoreResult{Key: objectKeys[i]}
+				err := bucket.RestoreObjectDetail(objectKeys[i], config)
+				if IsRestoreInProgress(err) {
+					results[i].InProgress = true
+					err = nil
+				}
+				results[i].Error = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Error != nil {
+			return results, result.Error
+		}
+	}
+	return results, nil
+}
+
+func (bucket Bucket) restoreObject(objectKey string, body []byte) error {
+	var data io.Reader
+	if body != nil {
+		data = bytes.NewReader(body)
+	}
+
 	params := map[string]interface{}{}
 	params["restore"] = nil
-	resp, err := bucket.do("POST", objectKey, params, nil, nil, nil)
+	resp, err := bucket.do("POST", objectKey, params, nil, data, nil)
 	if err != nil {
 		return err
 	}
@@ -705,9 +1719,108 @@ func (bucket Bucket) RestoreObject(objectKey string) error {
 	return checkRespCode(resp.StatusCode, []int{http.StatusOK, http.StatusAccepted})
 }
 
+//
+// GetStorageClass Gets the bucket's default storage class, which is inherited by the objects uploaded without ObjectStorageClass.
+//
+// The storage class is got by GetBucketInfo on the first call and then cached by the client. Once it's cached, the
+// ObjectStorageClass warmer than it is warned in the log when the logging is enabled.
+//
+// StorageClassType  The bucket's storage class, it's valid when error is nil.
+// error it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) GetStorageClass() (StorageClassType, error) {
+	cache := bucket.getBucketInfoCache()
+	if cache != nil {
+		cache.mu.Lock()
+		storageClass, ok := cache.storageClasses[bucket.BucketName]
+		cache.mu.Unlock()
+		if ok {
+			return storageClass, nil
+		}
+	}
+
+	res, err := bucket.Client.GetBucketInfo(bucket.BucketName)
+	if err != nil {
+		return "", err
+	}
+
+	storageClass := StorageClassType(res.BucketInfo.StorageClass)
+	if cache != nil {
+		cache.mu.Lock()
+		cache.storageClasses[bucket.BucketName] = storageClass
+		cache.mu.Unlock()
+	}
+	return storageClass, nil
+}
+
+// getBucketInfoCache gets the cache of the client, it's nil for the client not created by New
+func (bucket Bucket) getBucketInfoCache() *bucketInfoCache {
+	if bucket.Client.Conn == nil {
+		return nil
+	}
+	return bucket.Client.Conn.buckets
+}
+
+// getCachedBucketInfo gets the bucket's storage class and default encryption cached by the client, ok is false if
+// they have not been got yet. The encryption rule is empty if there's none.
+func (bucket Bucket) getCachedBucketInfo() (storageClass StorageClassType, classOK bool, rule ServerEncryptionRule, ruleOK bool) {
+	cache := bucket.getBucketInfoCache()
+	if cache == nil {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	storageClass, classOK = cache.storageClasses[bucket.BucketName]
+	rule, ruleOK = cache.encryptions[bucket.BucketName]
+	return
+}
+
+// the storage classes from the warmest to the coldest
+var storageClassTiers = map[StorageClassType]int{
+	StorageStandard:    0,
+	StorageIA:          1,
+	StorageArchive:     2,
+	StorageColdArchive: 3,
+}
+
+// warnUnusualOptions logs the warnings of the options which are valid but unusual for the bucket when the logging is
+// enabled. No request is sent for it, the bucket information is only what the caller has got by GetStorageClass and
+// GetBucketEncryption of the client, which is cached by the client.
+func (bucket Bucket) warnUnusualOptions(objectKey string, options []Option) {
+	config := bucket.getConfig()
+	if !config.isLogging() {
+		return
+	}
+	bucketClass, classOK, rule, ruleOK := bucket.getCachedBucketInfo()
+
+	// the object warmer than the bucket's default costs more than the other objects of the bucket
+	objectClass, _ := findOption(options, HTTPHeaderOssStorageClass, nil)
+	if objectClass != nil && classOK {
+		if tier, ok := storageClassTiers[StorageClassType(objectClass.(string))]; ok &&
+			tier < storageClassTiers[bucketClass] {
+			config.Logger.Infof("oss: warning, the storage class %s of the object %s is warmer than the default %s of the bucket %s",
+				objectClass, objectKey, bucketClass, bucket.BucketName)
+		}
+	}
+
+	// the encryption different from the bucket's default, which the bucket's objects are expected to have
+	sse, _ := findOption(options, HTTPHeaderOssServerSideEncryption, nil)
+	if sse != nil && ruleOK {
+		keyID, _ := findOption(options, HTTPHeaderOssServerSideEncryptionKeyID, "")
+		if rule.SSEAlgorithm != "" && (!strings.EqualFold(sse.(string), rule.SSEAlgorithm) ||
+			(keyID.(string) != "" && rule.KMSMasterKeyID != "" && keyID.(string) != rule.KMSMasterKeyID)) {
+			config.Logger.Infof("oss: warning, the server side encryption %s of the object %s conflicts with the default %s of the bucket %s",
+				sse, objectKey, rule.SSEAlgorithm, bucket.BucketName)
+		}
+	}
+}
+
 //
 // SignURL Sign the url. Users could access the object directly with this url without getting the AK.
 //
+// The headers of the options such as ContentType and Meta are signed, so the request with the url must send them.
+// SignURLWithHeaders returns them as well.
+//
 // objectKey the target object to sign.
 // signURLConfig The config for the signed url
 //
@@ -715,23 +1828,194 @@ func (bucket Bucket) RestoreObject(objectKey string) error {
 // error it's nil if no error; otherwise it's the error object
 //
 func (bucket Bucket) SignURL(objectKey string, method HTTPMethod, expiredInSec int64, options ...Option) (string, error) {
+	signedURL, _, err := bucket.SignURLWithHeaders(objectKey, method, expiredInSec, options...)
+	return signedURL, err
+}
+
+//
+// SignURLWithHeaders Signs the url and returns the headers the request with the url must send, checks out SignURL for the details.
+//
+// string the signed url, when error is nil.
+// http.Header the signed headers, which are Content-Type, Content-MD5 and the x-oss- headers of the options.
+// error it's nil if no error; otherwise it's the error object
+//
+func (bucket Bucket) SignURLWithHeaders(objectKey string, method HTTPMethod, expiredInSec int64, options ...Option) (string, http.Header, error) {
 	if expiredInSec < 0 {
-		return "", fmt.Errorf("invalid expires: %d, expires must bigger than 0", expiredInSec)
+		return "", nil, fmt.Errorf("invalid expires: %d, expires must bigger than 0", expiredInSec)
 	}
 	expiration := time.Now().Unix() + expiredInSec
 
 	params, err := getRawParams(options)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	headers := make(map[string]string)
 	err = handleOptions(headers, options)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	// the traffic limit of the signed url is the parameter
+	if limit, ok := headers[HTTPHeaderOssTrafficLimit]; ok {
+		params[strings.ToLower(HTTPHeaderOssTrafficLimit)] = limit
+		delete(headers, HTTPHeaderOssTrafficLimit)
 	}
 
-	return bucket.Client.Conn.signURL(method, bucket.BucketName, objectKey, expiration, params, headers), nil
+	signedHeaders := http.Header{}
+	for k, v := range headers {
+		if isSignedHeader(k) {
+			signedHeaders.Set(k, v)
+		}
+	}
+	signedURL, err := bucket.Client.Conn.signURL(method, bucket.BucketName, objectKey, expiration, params, headers)
+	if err != nil {
+		return "", nil, err
+	}
+	return signedURL, signedHeaders, nil
+}
+
+//
+// ValidateSignedURL Checks that the signed url of the bucket would be accepted by OSS for the request.
+//
+// The url is checked for its host and path of the bucket, the expiration and the AccessKeyId, then its signature
+// is computed again from the method, the headers and the object key. The request without the headers signed by
+// SignURL, such as Content-Type, fails the check as it would fail with SignatureDoesNotMatch.
+//
+// signedURL the url returned by SignURL.
+// method the method of the request.
+// headers the headers the request sends, nil if it sends no header.
+//
+// error it's nil if the url is valid; otherwise it tells the problem.
+//
+func (bucket Bucket) ValidateSignedURL(signedURL string, method HTTPMethod, headers http.Header) error {
+	uri, err := url.Parse(signedURL)
+	if err != nil {
+		return err
+	}
+	conn := bucket.Client.Conn
+	host, pathPrefix := conn.url.buildURL(bucket.BucketName, "")
+	if uri.Scheme != conn.url.Scheme || uri.Host != host || !strings.HasPrefix(uri.Path, pathPrefix) {
+		return fmt.Errorf("oss: the signed url %s is not of the bucket %s", signedURL, bucket.BucketName)
+	}
+	objectKey := strings.TrimPrefix(uri.Path, pathPrefix)
+
+	query := uri.Query()
+	expiration, err := strconv.ParseInt(query.Get(HTTPParamExpires), 10, 64)
+	if err != nil {
+		return fmt.Errorf("oss: the signed url has the invalid %s %q", HTTPParamExpires, query.Get(HTTPParamExpires))
+	}
+	if expiration < time.Now().Unix() {
+		return fmt.Errorf("oss: the signed url expired at %s", time.Unix(expiration, 0).UTC().Format(http.TimeFormat))
+	}
+	accessKeyID, _, securityToken, err := bucket.getConfig().getCredentials()
+	if err != nil {
+		return err
+	}
+	if query.Get(HTTPParamAccessKeyID) != accessKeyID {
+		return fmt.Errorf("oss: the signed url has the %s %q of another account", HTTPParamAccessKeyID, query.Get(HTTPParamAccessKeyID))
+	}
+	if query.Get(HTTPParamSecurityToken) != securityToken {
+		return fmt.Errorf("oss: the %s of the signed url does not match the client's", HTTPParamSecurityToken)
+	}
+
+	params := map[string]interface{}{}
+	for k := range query {
+		switch k {
+		case HTTPParamExpires, HTTPParamAccessKeyID, HTTPParamSignature, HTTPParamSecurityToken:
+		default:
+			if v := query.Get(k); v != "" {
+				params[k] = v
+			} else {
+				params[k] = nil
+			}
+		}
+	}
+	signedHeaders := map[string]string{}
+	for k := range headers {
+		if isSignedHeader(k) {
+			signedHeaders[k] = headers.Get(k)
+		}
+	}
+
+	expectedURL, err := conn.signURL(method, bucket.BucketName, objectKey, expiration, params, signedHeaders)
+	if err != nil {
+		return err
+	}
+	expected, err := url.Parse(expectedURL)
+	if err != nil {
+		return err
+	}
+	if query.Get(HTTPParamSignature) != expected.Query().Get(HTTPParamSignature) {
+		return fmt.Errorf("oss: the signature of the url does not match the %s request with the headers %v, "+
+			"the headers signed by SignURL may be missing", method, headers)
+	}
+	return nil
+}
+
+// isSignedHeader checks if the header is in the string to sign, which has Content-Type, Content-MD5 and the x-oss- headers
+func isSignedHeader(key string) bool {
+	key = strings.ToLower(key)
+	return key == strings.ToLower(HTTPHeaderContentType) || key == strings.ToLower(HTTPHeaderContentMD5) ||
+		strings.HasPrefix(key, "x-oss-")
+}
+
+//
+// SignPostPolicy Signs the policy of the browser upload form, so the browser uploads the file to the bucket by
+// PostObject directly without getting the AK.
+//
+// The form posted to the bucket's URL has the fields key, policy, Signature and OSSAccessKeyId, plus
+// x-oss-security-token with STS and success_action_status if it's in the policy. The file field must be the last one.
+//
+// policy the expiration and the conditions of the upload.
+//
+// PostSignature the base64 encoded policy and its signature. It's valid when error is nil.
+// error it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) SignPostPolicy(policy PostPolicy) (PostSignature, error) {
+	var out PostSignature
+	if policy.Expiration.IsZero() {
+		return out, errors.New("oss: the expiration of the post policy is required")
+	}
+	if policy.MinContentLength < 0 || policy.MaxContentLength < policy.MinContentLength {
+		return out, fmt.Errorf("oss: invalid content length range [%d, %d]", policy.MinContentLength, policy.MaxContentLength)
+	}
+
+	conditions := []interface{}{map[string]string{"bucket": bucket.BucketName}}
+	if policy.KeyStartsWith != "" {
+		conditions = append(conditions, []interface{}{"starts-with", "$key", policy.KeyStartsWith})
+	}
+	if policy.KeyEquals != "" {
+		conditions = append(conditions, []interface{}{"eq", "$key", policy.KeyEquals})
+	}
+	if policy.MaxContentLength > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", policy.MinContentLength, policy.MaxContentLength})
+	}
+	switch policy.SuccessActionStatus {
+	case 0:
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		conditions = append(conditions, map[string]string{"success_action_status": strconv.Itoa(policy.SuccessActionStatus)})
+	default:
+		return out, fmt.Errorf("oss: invalid success action status %d, it should be 200, 201 or 204", policy.SuccessActionStatus)
+	}
+
+	doc, err := json.Marshal(map[string]interface{}{
+		"expiration": policy.Expiration.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return out, err
+	}
+
+	out.Policy = base64.StdEncoding.EncodeToString(doc)
+	accessKeyID, accessKeySecret, securityToken, err := bucket.getConfig().getCredentials()
+	if err != nil {
+		return out, err
+	}
+	out.Signature = bucket.Client.Conn.signPolicy(out.Policy, accessKeySecret)
+	out.AccessKeyID = accessKeyID
+	out.SecurityToken = securityToken
+	return out, nil
 }
 
 //
@@ -802,7 +2086,7 @@ func (bucket Bucket) DoPutObjectWithURL(signedURL string, reader io.Reader, opti
 	}
 
 	if bucket.getConfig().IsEnableCRC {
-		err = checkCRC(resp, "DoPutObjectWithURL")
+		err = bucket.checkCRC(resp, "DoPutObjectWithURL")
 		if err != nil {
 			return resp, err
 		}
@@ -813,6 +2097,16 @@ func (bucket Bucket) DoPutObjectWithURL(signedURL string, reader io.Reader, opti
 	return resp, err
 }
 
+//
+// DoPutObjectWithURLContext Does the upload with the signed url bound to the context, checks out DoPutObjectWithURL for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) DoPutObjectWithURLContext(ctx context.Context, signedURL string, reader io.Reader, options []Option) (*Response, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DoPutObjectWithURL(signedURL, reader, options)
+}
+
 //
 // GetObjectWithURL Downloading the object and return the reader instance,  with the signed url.
 //
@@ -857,25 +2151,44 @@ func (bucket Bucket) GetObjectToFileWithURL(signedURL, filePath string, options
 		return err
 	}
 
+	// the partial file is removed on any failure, it's kept only when it's renamed to filePath
+	isRenamed := false
+	defer func() {
+		if !isRenamed {
+			os.Remove(tempFilePath)
+		}
+	}()
+
 	// saves the data to the file.
-	_, err = io.Copy(fd, result.Response.Body)
+	written, err := copyBuffer(fd, result.Response.Body, bucket.getConfig().IOBufferSize)
 	fd.Close()
 	if err != nil {
 		return err
 	}
 
-	// compares the CRC value. If CRC values do not match, return error.
+	// checks the length against the Content-Length, so that the truncated download does not end up in the file
 	hasRange, _, _ := isOptionSet(options, HTTPHeaderRange)
+	if !hasRange {
+		err = checkDownloadLength(result.Response, written, "GetObjectToFileWithURL")
+		if err != nil {
+			return err
+		}
+	}
+
+	// compares the CRC value. If CRC values do not match, return error.
 	if bucket.getConfig().IsEnableCRC && !hasRange {
 		result.Response.ClientCRC = result.ClientCRC.Sum64()
-		err = checkCRC(result.Response, "GetObjectToFileWithURL")
+		err = bucket.checkCRC(result.Response, "GetObjectToFileWithURL")
 		if err != nil {
-			os.Remove(tempFilePath)
 			return err
 		}
 	}
 
-	return os.Rename(tempFilePath, filePath)
+	if err = os.Rename(tempFilePath, filePath); err != nil {
+		return err
+	}
+	isRenamed = true
+	return nil
 }
 
 //
@@ -916,6 +2229,17 @@ func (bucket Bucket) DoGetObjectWithURL(signedURL string, options []Option) (*Ge
 	return result, nil
 }
 
+//
+// DoGetObjectWithURLContext Does the download with the signed url bound to the context, checks out DoGetObjectWithURL for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+// When the context is cancelled after the response is returned, reading the body fails.
+//
+func (bucket Bucket) DoGetObjectWithURLContext(ctx context.Context, signedURL string, options []Option) (*GetObjectResult, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DoGetObjectWithURL(signedURL, options)
+}
+
 // Private
 func (bucket Bucket) do(method, objectName string, params map[string]interface{}, options []Option,
 	data io.Reader, listener ProgressListener) (*Response, error) {
@@ -924,8 +2248,13 @@ func (bucket Bucket) do(method, objectName string, params map[string]interface{}
 	if err != nil {
 		return nil, err
 	}
-	return bucket.Client.Conn.Do(method, bucket.BucketName, objectName,
+	if err = setUserAgentSuffix(headers, options, bucket.getConfig().UserAgent); err != nil {
+		return nil, err
+	}
+	resp, err := bucket.Client.Conn.DoWithContext(getContext(options), method, bucket.BucketName, objectName,
 		params, headers, data, 0, listener)
+	captureResponseHeaders(options, resp)
+	return resp, err
 }
 
 func (bucket Bucket) doURL(method HTTPMethod, signedURL string, params map[string]interface{}, options []Option,
@@ -935,13 +2264,55 @@ func (bucket Bucket) doURL(method HTTPMethod, signedURL string, params map[strin
 	if err != nil {
 		return nil, err
 	}
-	return bucket.Client.Conn.DoURL(method, signedURL, headers, data, 0, listener)
+	if err = setUserAgentSuffix(headers, options, bucket.getConfig().UserAgent); err != nil {
+		return nil, err
+	}
+	resp, err := bucket.Client.Conn.DoURLWithContext(getContext(options), method, signedURL, headers, data, 0, listener)
+	captureResponseHeaders(options, resp)
+	return resp, err
 }
 
 func (bucket Bucket) getConfig() *Config {
 	return bucket.Client.Config
 }
 
+// addContentTypeFromReader is same as addContentType, but when SniffContentType is set and no type is got from
+// the keys' extensions, the type is detected from the first 512 bytes of the seekable reader.
+func addContentTypeFromReader(options []Option, reader io.Reader, keys ...string) ([]Option, error) {
+	isSniff, err := findOption(options, sniffContentType, false)
+	if err != nil {
+		return nil, err
+	}
+	seeker, ok := reader.(io.ReadSeeker)
+	if !isSniff.(bool) || !ok {
+		return addContentType(options, keys...), nil
+	}
+
+	for _, key := range keys {
+		if TypeByExtension(key) != "" {
+			return addContentType(options, keys...), nil
+		}
+	}
+
+	pos, err := seeker.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(seeker, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if _, err = seeker.Seek(pos, os.SEEK_SET); err != nil {
+		return nil, err
+	}
+
+	opts := []Option{ContentType(http.DetectContentType(buf[:n]))}
+	opts = append(opts, options...)
+
+	return opts, nil
+}
+
 func addContentType(options []Option, keys ...string) []Option {
 	typ := TypeByExtension("")
 	for _, key := range keys {
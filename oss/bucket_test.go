@@ -4,17 +4,31 @@ package oss
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"hash/crc64"
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -197,6 +211,134 @@ func (s *OssBucketSuite) TestPutObject(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// TestPutObjectWithResult
+func (s *OssBucketSuite) TestPutObjectWithResult(c *C) {
+	objectName := objectNamePrefix + "tpowr"
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+
+	// without encryption
+	res, err := s.bucket.PutObjectWithResult(objectName, strings.NewReader(objectValue))
+	c.Assert(err, IsNil)
+	c.Assert(res.ServerSideEncryption, Equals, "")
+
+	// with encryption, the applied algorithm is returned
+	res, err = s.bucket.PutObjectWithResult(objectName, strings.NewReader(objectValue), ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(res.ServerSideEncryption, Equals, "AES256")
+
+	meta, err := s.bucket.GetObjectDetailedMeta(objectName)
+	c.Assert(err, IsNil)
+	c.Assert(meta.Get(HTTPHeaderOssServerSideEncryption), Equals, "AES256")
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+}
+
+// TestObjectStorageClass
+func (s *OssBucketSuite) TestObjectStorageClass(c *C) {
+	objectName := objectNamePrefix + "tosc"
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+
+	// the bucket's default storage class
+	storageClass, err := s.bucket.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(storageClass, Equals, StorageStandard)
+
+	storageClass, err = s.archiveBucket.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(storageClass, Equals, StorageArchive)
+
+	// the override is applied to the object
+	err = s.bucket.PutObject(objectName, strings.NewReader(objectValue), ObjectStorageClass(StorageIA))
+	c.Assert(err, IsNil)
+
+	meta, err := s.bucket.GetObjectDetailedMeta(objectName)
+	c.Assert(err, IsNil)
+	c.Assert(meta.Get(HTTPHeaderOssStorageClass), Equals, string(StorageIA))
+
+	// invalid storage class
+	err = s.bucket.PutObject(objectName, strings.NewReader(objectValue), ObjectStorageClass("Glacier"))
+	c.Assert(err, NotNil)
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+}
+
+// TestPutObjectVerifyETag
+func (s *OssBucketSuite) TestPutObjectVerifyETag(c *C) {
+	objectName := objectNamePrefix + "tpove"
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+
+	err := s.bucket.PutObjectFromFile(objectName, fileName, VerifyETag(true))
+	c.Assert(err, IsNil)
+
+	fd, err := os.Open(fileName)
+	c.Assert(err, IsNil)
+	defer fd.Close()
+	err = s.bucket.PutObject(objectName, fd, VerifyETag(true))
+	c.Assert(err, IsNil)
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+}
+
+// TestCaptureResponseHeaders
+func (s *OssBucketSuite) TestCaptureResponseHeaders(c *C) {
+	objectName := objectNamePrefix + "tcrh"
+	destObjectName := objectName + "-dest"
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+
+	err := s.bucket.PutObject(objectName, strings.NewReader(objectValue))
+	c.Assert(err, IsNil)
+
+	var respHeader http.Header
+	_, err = s.bucket.CopyObject(objectName, destObjectName, CaptureResponseHeaders(&respHeader))
+	c.Assert(err, IsNil)
+	c.Assert(respHeader.Get(HTTPHeaderOssRequestID) != "", Equals, true)
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+	err = s.bucket.DeleteObject(destObjectName)
+	c.Assert(err, IsNil)
+}
+
+// TestObjectTagging
+func (s *OssBucketSuite) TestObjectTagging(c *C) {
+	objectName := objectNamePrefix + "tot"
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+
+	// tags attached on upload
+	tagging := Tagging{Tags: []Tag{{Key: "k1", Value: "v1"}, {Key: "k 2", Value: "值&2"}}}
+	err := s.bucket.PutObject(objectName, strings.NewReader(objectValue), SetObjectTagging(tagging))
+	c.Assert(err, IsNil)
+
+	res, err := s.bucket.GetObjectTagging(objectName)
+	c.Assert(err, IsNil)
+	c.Assert(len(res.Tags), Equals, 2)
+
+	// replace the tags
+	tagging = Tagging{Tags: []Tag{{Key: "k3", Value: "v3"}}}
+	err = s.bucket.PutObjectTagging(objectName, tagging)
+	c.Assert(err, IsNil)
+
+	res, err = s.bucket.GetObjectTagging(objectName)
+	c.Assert(err, IsNil)
+	c.Assert(len(res.Tags), Equals, 1)
+	c.Assert(res.Tags[0].Key, Equals, "k3")
+	c.Assert(res.Tags[0].Value, Equals, "v3")
+
+	// no tag
+	err = s.bucket.DeleteObjectTagging(objectName)
+	c.Assert(err, IsNil)
+
+	res, err = s.bucket.GetObjectTagging(objectName)
+	c.Assert(err, IsNil)
+	c.Assert(len(res.Tags), Equals, 0)
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+}
+
 func (s *OssBucketSuite) TestSignURL(c *C) {
 	objectName := objectNamePrefix + randStr(5)
 	objectValue := randStr(20)
@@ -859,6 +1001,36 @@ func (s *OssBucketSuite) TestGetObject(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// TestGetObjectReadAhead
+func (s *OssBucketSuite) TestGetObjectReadAhead(c *C) {
+	objectName := objectNamePrefix + "tgora"
+	objectValue := strings.Repeat("长忆观潮，满郭人争江上望。", 1024)
+
+	err := s.bucket.PutObject(objectName, strings.NewReader(objectValue))
+	c.Assert(err, IsNil)
+
+	// many small reads are served from the read-ahead buffer
+	result, err := s.bucket.DoGetObject(&GetObjectRequest{objectName}, []Option{ReadAhead(64 * 1024)})
+	c.Assert(err, IsNil)
+
+	var buf bytes.Buffer
+	p := make([]byte, 7)
+	for {
+		n, err := result.Response.Body.Read(p)
+		buf.Write(p[:n])
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+	}
+	result.Response.Body.Close()
+	c.Assert(buf.String(), Equals, objectValue)
+	c.Assert(result.ClientCRC.Sum64(), Equals, result.ServerCRC)
+
+	err = s.bucket.DeleteObject(objectName)
+	c.Assert(err, IsNil)
+}
+
 // TestGetObjectNegative
 func (s *OssBucketSuite) TestGetObjectToWriterNegative(c *C) {
 	objectName := objectNamePrefix + "tgotwn"
@@ -1275,11 +1447,15 @@ func (s *OssBucketSuite) TestSetObjectMeta(c *C) {
 	c.Assert(acl.ACL, Equals, "default")
 
 	// invalid option
-	err = s.bucket.SetObjectMeta(objectName, AcceptEncoding("url"))
+	err = s.bucket.SetObjectMeta(objectName, AcceptEncoding("url"), Meta("myprop", "mypropval"))
 	c.Assert(err, IsNil)
 
+	// no metadata
+	err = s.bucket.SetObjectMeta(objectName, AcceptEncoding("url"))
+	c.Assert(err, Equals, ErrMetaReplaceWithoutMeta)
+
 	// invalid option value
-	err = s.bucket.SetObjectMeta(objectName, ServerSideEncryption("invalid"))
+	err = s.bucket.SetObjectMeta(objectName, ServerSideEncryption("invalid"), Meta("myprop", "mypropval"))
 	c.Assert(err, NotNil)
 
 	err = s.bucket.DeleteObject(objectName)
@@ -2244,3 +2420,2657 @@ func (s *OssBucketSuite) getObject(objects []ObjectProperties, object string) (b
 	}
 	return false, ObjectProperties{}
 }
+
+// OssBucketMockSuite tests the bucket operations against a stubbed OSS server
+type OssBucketMockSuite struct{}
+
+var _ = Suite(&OssBucketMockSuite{})
+
+// newMockBucket starts a stubbed OSS server with the handler and returns a bucket bound to it.
+// The server must be closed by the caller.
+func newMockBucket(handler http.HandlerFunc, c *C, options ...ClientOption) (*Bucket, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	client, err := New(server.URL, "ak", "sk", options...)
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	return bucket, server
+}
+
+// multipartMock is the mock of the multipart upload and copy requests served by newMultipartMockHandler. The hooks
+// observe the requests, the part hook and the complete hook returning false have written the response, such as an
+// error. The requests which are not of the multipart upload are served by other, they fail with 400 without it.
+type multipartMock struct {
+	initiate   func(w http.ResponseWriter, r *http.Request) string // returns the upload id, upload-id if it's empty
+	uploadPart func(w http.ResponseWriter, r *http.Request, body []byte) bool
+	complete   func(w http.ResponseWriter, r *http.Request, body []byte) bool
+	abort      func(r *http.Request)
+	other      func(w http.ResponseWriter, r *http.Request)
+}
+
+// newMultipartMockHandler gets the handler of the mock bucket serving the multipart upload with the hooks of the mock
+func newMultipartMockHandler(mock multipartMock) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		switch {
+		case r.Method == "POST" && r.URL.RawQuery == "uploads":
+			uploadID := "upload-id"
+			if mock.initiate != nil {
+				if id := mock.initiate(w, r); id != "" {
+					uploadID = id
+				}
+			}
+			io.WriteString(w, "<InitiateMultipartUploadResult><Bucket>mock-bucket</Bucket><Key>"+key+
+				"</Key><UploadId>"+uploadID+"</UploadId></InitiateMultipartUploadResult>")
+		case r.Method == "PUT" && query.Get("partNumber") != "":
+			body, _ := ioutil.ReadAll(r.Body)
+			if mock.uploadPart != nil && !mock.uploadPart(w, r, body) {
+				return
+			}
+			etag := "\"etag-" + query.Get("partNumber") + "\""
+			if r.Header.Get(HTTPHeaderOssCopySource) != "" {
+				io.WriteString(w, "<CopyPartResult><ETag>"+etag+"</ETag></CopyPartResult>")
+				return
+			}
+			w.Header().Set(HTTPHeaderEtag, etag)
+		case r.Method == "POST" && query.Get("uploadId") != "":
+			body, _ := ioutil.ReadAll(r.Body)
+			if mock.complete != nil && !mock.complete(w, r, body) {
+				return
+			}
+			io.WriteString(w, "<CompleteMultipartUploadResult><Bucket>mock-bucket</Bucket><Key>"+key+
+				"</Key><ETag>\"etag\"</ETag></CompleteMultipartUploadResult>")
+		case r.Method == "DELETE" && query.Get("uploadId") != "":
+			if mock.abort != nil {
+				mock.abort(r)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case mock.other != nil:
+			mock.other(w, r)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+}
+
+func (s *OssBucketMockSuite) TestWarnUnusualStorageClass(c *C) {
+	var infos []string
+	var classes []string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery == "bucketInfo" {
+			name := strings.Trim(r.URL.Path, "/")
+			infos = append(infos, name)
+			io.WriteString(w, "<BucketInfo><Bucket><Name>"+name+"</Name><StorageClass>IA</StorageClass></Bucket></BucketInfo>")
+			return
+		}
+		if r.Method == "POST" {
+			io.WriteString(w, "<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>")
+		}
+		classes = append(classes, r.Header.Get(HTTPHeaderOssStorageClass))
+	}, c)
+	defer server.Close()
+
+	// no request of the bucket information without the logger
+	err := bucket.PutObject("object", strings.NewReader("data"), ObjectStorageClass(StorageStandard))
+	c.Assert(err, IsNil)
+	c.Assert(infos, HasLen, 0)
+
+	// nor with the logger, the warning is only given after the caller got the storage class
+	logger := &recordingLogger{}
+	bucket.Client.Config.Logger = logger
+	err = bucket.PutObject("object", strings.NewReader("data"), ObjectStorageClass(StorageStandard))
+	c.Assert(err, IsNil)
+	c.Assert(infos, HasLen, 0)
+	for _, message := range logger.messages {
+		c.Assert(strings.Contains(message, "warning"), Equals, false)
+	}
+
+	logger.messages = nil
+	storageClass, err := bucket.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(storageClass, Equals, StorageIA)
+	err = bucket.PutObject("object", strings.NewReader("data"), ObjectStorageClass(StorageStandard))
+	c.Assert(err, IsNil)
+	_, err = bucket.InitiateMultipartUpload("object", ObjectStorageClass(StorageStandard))
+	c.Assert(err, IsNil)
+	c.Assert(classes, DeepEquals, []string{"Standard", "Standard", "Standard", "Standard"})
+	warning := "INFO oss: warning, the storage class Standard of the object object is warmer than the default IA of the bucket mock-bucket"
+	warnings := 0
+	for _, message := range logger.messages {
+		if message == warning {
+			warnings++
+		}
+	}
+	c.Assert(warnings, Equals, 2)
+
+	// the colder object and the default one are not warned
+	logger.messages = nil
+	err = bucket.PutObject("object", strings.NewReader("data"), ObjectStorageClass(StorageArchive))
+	c.Assert(err, IsNil)
+	err = bucket.PutObject("object", strings.NewReader("data"))
+	c.Assert(err, IsNil)
+	for _, message := range logger.messages {
+		c.Assert(strings.Contains(message, "warning"), Equals, false)
+	}
+
+	// the storage class is cached by the client, shared by the bucket built by the literal too
+	other := Bucket{bucket.Client, bucket.BucketName}
+	storageClass, err = other.GetStorageClass()
+	c.Assert(err, IsNil)
+	c.Assert(storageClass, Equals, StorageIA)
+	c.Assert(infos, DeepEquals, []string{"mock-bucket"})
+}
+
+func (s *OssBucketMockSuite) TestWarnEncryptionConflict(c *C) {
+	var rules []string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery == "encryption" && r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.RawQuery == "encryption" {
+			name := strings.Trim(r.URL.Path, "/")
+			rules = append(rules, name)
+			if name != "mock-bucket" {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<Error><Code>NoSuchServerSideEncryptionRule</Code></Error>")
+				return
+			}
+			io.WriteString(w, "<ServerSideEncryptionRule><ApplyServerSideEncryptionByDefault>"+
+				"<SSEAlgorithm>KMS</SSEAlgorithm><KMSMasterKeyID>key-1</KMSMasterKeyID>"+
+				"</ApplyServerSideEncryptionByDefault></ServerSideEncryptionRule>")
+			return
+		}
+		w.Header().Set(HTTPHeaderOssServerSideEncryption, r.Header.Get(HTTPHeaderOssServerSideEncryption))
+	}, c)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	bucket.Client.Config.Logger = logger
+	countWarnings := func() int {
+		warnings := 0
+		for _, message := range logger.messages {
+			if strings.HasPrefix(message, "INFO oss: warning") {
+				warnings++
+			}
+		}
+		return warnings
+	}
+
+	// the default encryption is not got for the warning
+	res, err := bucket.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(res.ServerSideEncryption, Equals, "AES256")
+	c.Assert(countWarnings(), Equals, 0)
+	c.Assert(rules, HasLen, 0)
+
+	// the algorithm conflicts with the bucket's default got by the caller
+	_, err = bucket.Client.GetBucketEncryption("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(countWarnings(), Equals, 1)
+	c.Assert(strings.Contains(strings.Join(logger.messages, "\n"), "INFO oss: warning, the server side encryption AES256 "+
+		"of the object object conflicts with the default KMS of the bucket mock-bucket"), Equals, true)
+
+	// so does the KMS key
+	_, err = bucket.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("KMS"),
+		setHeader(HTTPHeaderOssServerSideEncryptionKeyID, "key-2"))
+	c.Assert(err, IsNil)
+	c.Assert(countWarnings(), Equals, 2)
+
+	// the same encryption and the bucket without the default encryption are not warned
+	_, err = bucket.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("KMS"))
+	c.Assert(err, IsNil)
+	plain, err := bucket.Client.Bucket("plain-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.Client.GetBucketEncryption("plain-bucket")
+	code, _ := ServiceErrorCode(err)
+	c.Assert(code, Equals, "NoSuchServerSideEncryptionRule")
+	_, err = plain.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(countWarnings(), Equals, 2)
+
+	// the deleted default encryption is not warned any more
+	err = bucket.Client.DeleteBucketEncryption("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.PutObjectWithResult("object", strings.NewReader("data"), ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(countWarnings(), Equals, 2)
+	c.Assert(rules, DeepEquals, []string{"mock-bucket", "plain-bucket"})
+}
+
+func (s *OssBucketMockSuite) TestDeleteObjectsPartialError(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<DeleteResult>
+  <Deleted><Key>obj1</Key></Deleted>
+  <Error><Key>obj2</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>
+  <Error><Key>obj%203</Key><Code>InternalError</Code><Message>Internal Error</Message></Error>
+</DeleteResult>`)
+	}, c)
+	defer server.Close()
+
+	keys := []string{"obj1", "obj2", "obj 3"}
+
+	// by default the partial failure is only in the result
+	res, err := bucket.DeleteObjects(keys)
+	c.Assert(err, IsNil)
+	c.Assert(res.HasErrors(), Equals, true)
+	c.Assert(res.DeletedObjects, DeepEquals, []string{"obj1"})
+	c.Assert(len(res.FailedObjects), Equals, 2)
+	c.Assert(res.FailedObjects[1].Key, Equals, "obj 3")
+
+	// fail on partial error
+	res, err = bucket.DeleteObjects(keys, FailOnPartialError(true))
+	c.Assert(err, NotNil)
+	delErr, ok := err.(DeleteObjectsError)
+	c.Assert(ok, Equals, true)
+	c.Assert(len(delErr.FailedObjects), Equals, 2)
+	c.Assert(delErr.FailedObjects[0].Code, Equals, "AccessDenied")
+	c.Assert(strings.Contains(err.Error(), "obj2(AccessDenied)"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "obj 3(InternalError)"), Equals, true)
+	c.Assert(len(res.DeletedObjects), Equals, 1)
+}
+
+func (s *OssBucketMockSuite) TestDeleteObjectsRequestID(c *C) {
+	requests := 0
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		bs, _ := ioutil.ReadAll(r.Body)
+		sum := md5.Sum(bs)
+		c.Assert(r.Header.Get(HTTPHeaderContentMD5), Equals, base64.StdEncoding.EncodeToString(sum[:]))
+
+		var dxml deleteXML
+		c.Assert(xml.Unmarshal(bs, &dxml), IsNil)
+		w.Header().Set(HTTPHeaderOssRequestID, fmt.Sprintf("req-%d", requests))
+		if dxml.Quiet {
+			return
+		}
+		out := DeleteObjectsResult{}
+		for _, object := range dxml.Objects {
+			out.DeletedObjects = append(out.DeletedObjects, object.Key)
+		}
+		bs, _ = xml.Marshal(out)
+		w.Write(bs)
+	}, c)
+	defer server.Close()
+
+	res, err := bucket.DeleteObjects([]string{"a", "b"})
+	c.Assert(err, IsNil)
+	c.Assert(res.RequestID, Equals, "req-1")
+	c.Assert(res.RequestIDs, DeepEquals, []string{"req-1"})
+	c.Assert(res.DeletedObjects, DeepEquals, []string{"a", "b"})
+
+	// the quiet mode, the Content-MD5 of the options is overridden
+	res, err = bucket.DeleteObjects([]string{"a"}, DeleteObjectsQuiet(true), ContentMD5("bad"))
+	c.Assert(err, IsNil)
+	c.Assert(res.RequestID, Equals, "req-2")
+
+	// all the batches
+	keys := make([]string, MaxDeleteObjectsKeys+1)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	res, err = bucket.DeleteObjects(keys)
+	c.Assert(err, IsNil)
+	c.Assert(res.RequestID, Equals, "req-4")
+	c.Assert(res.RequestIDs, DeepEquals, []string{"req-3", "req-4"})
+}
+
+func (s *OssBucketMockSuite) TestDeleteObjectsBatches(c *C) {
+	var batches []int
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		var dxml deleteXML
+		bs, _ := ioutil.ReadAll(r.Body)
+		c.Assert(xml.Unmarshal(bs, &dxml), IsNil)
+		batches = append(batches, len(dxml.Objects))
+		if dxml.Quiet {
+			io.WriteString(w, "<DeleteResult></DeleteResult>")
+			return
+		}
+
+		out := DeleteObjectsResult{}
+		for _, object := range dxml.Objects {
+			if object.Key == "obj-2000" {
+				out.FailedObjects = append(out.FailedObjects, DeleteObjectError{Key: object.Key, Code: "AccessDenied"})
+			} else {
+				out.DeletedObjects = append(out.DeletedObjects, object.Key)
+			}
+		}
+		bs, _ = xml.Marshal(out)
+		w.Write(bs)
+	}, c)
+	defer server.Close()
+
+	keys := []string{}
+	for i := 0; i < 2500; i++ {
+		keys = append(keys, fmt.Sprintf("obj-%d", i))
+	}
+
+	res, err := bucket.DeleteObjects(keys)
+	c.Assert(err, IsNil)
+	c.Assert(batches, DeepEquals, []int{1000, 1000, 500})
+	c.Assert(len(res.DeletedObjects), Equals, 2499)
+	c.Assert(res.DeletedObjects[0], Equals, "obj-0")
+	c.Assert(res.DeletedObjects[2498], Equals, "obj-2499")
+	c.Assert(res.FailedObjects, HasLen, 1)
+	c.Assert(res.FailedObjects[0].Key, Equals, "obj-2000")
+
+	// the partial error is checked after all the batches
+	batches = nil
+	res, err = bucket.DeleteObjects(keys, FailOnPartialError(true))
+	c.Assert(err, FitsTypeOf, DeleteObjectsError{})
+	c.Assert(batches, DeepEquals, []int{1000, 1000, 500})
+	c.Assert(len(res.DeletedObjects), Equals, 2499)
+
+	// the confirmation overrides the quiet mode and the failed key is not fatal
+	res, err = bucket.DeleteObjects(keys[1995:2005], DeleteObjectsQuiet(true), FailOnPartialError(true), ConfirmDeleted(true))
+	c.Assert(err, IsNil)
+	c.Assert(res.DeletedObjects, DeepEquals, []string{"obj-1995", "obj-1996", "obj-1997", "obj-1998", "obj-1999",
+		"obj-2001", "obj-2002", "obj-2003", "obj-2004"})
+	c.Assert(res.FailedObjects, HasLen, 1)
+	c.Assert(res.FailedObjects[0].Key, Equals, "obj-2000")
+
+	// quiet in all the batches
+	batches = nil
+	res, err = bucket.DeleteObjects(keys, DeleteObjectsQuiet(true))
+	c.Assert(err, IsNil)
+	c.Assert(batches, DeepEquals, []int{1000, 1000, 500})
+	c.Assert(res.DeletedObjects, HasLen, 0)
+	c.Assert(res.FailedObjects, HasLen, 0)
+
+	// one request for 1000 keys
+	batches = nil
+	_, err = bucket.DeleteObjects(keys[:1000])
+	c.Assert(err, IsNil)
+	c.Assert(batches, DeepEquals, []int{1000})
+}
+
+func (s *OssBucketMockSuite) TestObjectLegalHold(c *C) {
+	holds := map[string][]byte{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.URL.Query()["legal-hold"]
+		c.Assert(ok, Equals, true)
+		key := r.URL.Path + "?" + r.URL.Query().Get("versionId")
+		switch r.Method {
+		case "PUT":
+			holds[key], _ = ioutil.ReadAll(r.Body)
+		case "GET":
+			if holds[key] == nil {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+				return
+			}
+			w.Write(holds[key])
+		}
+	}, c)
+	defer server.Close()
+
+	err := bucket.PutObjectLegalHold("object", LegalHoldOn)
+	c.Assert(err, IsNil)
+	c.Assert(string(holds["/mock-bucket/object?"]), Equals, "<LegalHold><Status>ON</Status></LegalHold>")
+	res, err := bucket.GetObjectLegalHold("object")
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, LegalHoldOn)
+	c.Assert(res.IsDeleteBlocked(), Equals, true)
+
+	err = bucket.PutObjectLegalHold("object", LegalHoldOff)
+	c.Assert(err, IsNil)
+	res, err = bucket.GetObjectLegalHold("object")
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, LegalHoldOff)
+	c.Assert(res.IsDeleteBlocked(), Equals, false)
+
+	// the legal hold of the version
+	err = bucket.PutObjectLegalHold("object", LegalHoldOn, VersionId("v1"))
+	c.Assert(err, IsNil)
+	res, err = bucket.GetObjectLegalHold("object", VersionId("v1"))
+	c.Assert(err, IsNil)
+	c.Assert(res.IsDeleteBlocked(), Equals, true)
+	res, err = bucket.GetObjectLegalHold("object")
+	c.Assert(err, IsNil)
+	c.Assert(res.IsDeleteBlocked(), Equals, false)
+
+	_, err = bucket.GetObjectLegalHold("none")
+	c.Assert(IsNoSuchKey(err), Equals, true)
+
+	// the status from the server is compared case-insensitively
+	c.Assert(GetObjectLegalHoldResult{Status: "On"}.IsDeleteBlocked(), Equals, true)
+}
+
+func (s *OssBucketMockSuite) TestWriteResultRequestID(c *C) {
+	objectValue := "request id"
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(HTTPHeaderOssRequestID, "req-"+r.Method)
+		switch r.Method {
+		case "PUT":
+			w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+			w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(data))
+		case "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, c)
+	defer server.Close()
+
+	res, err := bucket.PutObjectWithResult("object", strings.NewReader(objectValue))
+	c.Assert(err, IsNil)
+	c.Assert(res.RequestID, Equals, "req-PUT")
+	c.Assert(res.ETag, Equals, "\"etag\"")
+	crc, _ := strconv.ParseUint(mockCRC64([]byte(objectValue)), 10, 64)
+	c.Assert(res.CRC64, Equals, crc)
+
+	delRes, err := bucket.DeleteObjectWithResult("object")
+	c.Assert(err, IsNil)
+	c.Assert(delRes.RequestID, Equals, "req-DELETE")
+
+	var header http.Header
+	err = bucket.SetObjectACL("object", ACLPrivate, CaptureResponseHeaders(&header))
+	c.Assert(err, IsNil)
+	c.Assert(header.Get(HTTPHeaderOssRequestID), Equals, "req-PUT")
+}
+
+func (s *OssBucketMockSuite) TestPutObjectVerifyETag(c *C) {
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+	etag := fmt.Sprintf("\"%X\"", md5.Sum([]byte(objectValue)))
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Header().Set(HTTPHeaderEtag, etag)
+		w.WriteHeader(http.StatusOK)
+	}, c)
+	defer server.Close()
+
+	// matched etag
+	err := bucket.PutObject("obj", strings.NewReader(objectValue), VerifyETag(true))
+	c.Assert(err, IsNil)
+
+	// the content is uploaded from the current position
+	reader := strings.NewReader("prefix" + objectValue)
+	reader.Seek(int64(len("prefix")), os.SEEK_SET)
+	err = bucket.PutObject("obj", reader, VerifyETag(true))
+	c.Assert(err, IsNil)
+
+	// mismatched etag
+	err = bucket.PutObject("obj", strings.NewReader(objectValue+"!"), VerifyETag(true))
+	c.Assert(err, NotNil)
+	_, ok := err.(ETagCheckError)
+	c.Assert(ok, Equals, true)
+
+	// without the option the etag is not checked
+	err = bucket.PutObject("obj", strings.NewReader(objectValue+"!"))
+	c.Assert(err, IsNil)
+
+	// the reader must be seekable
+	err = bucket.PutObject("obj", ioutil.NopCloser(strings.NewReader(objectValue)), VerifyETag(true))
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestListObjectsSince(c *C) {
+	pages := map[string]string{
+		"": `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <IsTruncated>true</IsTruncated>
+  <NextMarker>obj2</NextMarker>
+  <Contents><Key>obj1</Key><LastModified>2017-01-01T00:00:00.000Z</LastModified></Contents>
+  <Contents><Key>obj2</Key><LastModified>2017-06-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`,
+		"obj2": `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>obj3</Key><LastModified>2017-03-01T00:00:00.000Z</LastModified></Contents>
+  <Contents><Key>obj4</Key><LastModified>2017-09-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`,
+	}
+	markers := []string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		marker := r.URL.Query().Get("marker")
+		markers = append(markers, marker)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, pages[marker])
+	}, c)
+	defer server.Close()
+
+	since := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	objects, err := bucket.ListObjectsSince(since)
+	c.Assert(err, IsNil)
+	c.Assert(markers, DeepEquals, []string{"", "obj2"})
+	c.Assert(len(objects), Equals, 3)
+	c.Assert(objects[0].Key, Equals, "obj2")
+	c.Assert(objects[1].Key, Equals, "obj3")
+	c.Assert(objects[2].Key, Equals, "obj4")
+
+	// the scan starts from the marker
+	markers = []string{}
+	objects, err = bucket.ListObjectsSince(since, Marker("obj2"))
+	c.Assert(err, IsNil)
+	c.Assert(markers, DeepEquals, []string{"obj2"})
+	c.Assert(len(objects), Equals, 2)
+}
+
+func (s *OssBucketMockSuite) TestCaptureResponseHeaders(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderOssRequestID, "request-id")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "PUT" {
+			io.WriteString(w, `<CopyObjectResult><ETag>"etag"</ETag><LastModified>2017-01-01T00:00:00.000Z</LastModified></CopyObjectResult>`)
+		} else {
+			io.WriteString(w, `<ListBucketResult><IsTruncated>false</IsTruncated></ListBucketResult>`)
+		}
+	}, c)
+	defer server.Close()
+
+	var respHeader http.Header
+	_, err := bucket.CopyObject("src", "dest", CaptureResponseHeaders(&respHeader))
+	c.Assert(err, IsNil)
+	c.Assert(respHeader.Get(HTTPHeaderOssRequestID), Equals, "request-id")
+
+	respHeader = nil
+	_, err = bucket.ListObjects(Prefix("p"), CaptureResponseHeaders(&respHeader))
+	c.Assert(err, IsNil)
+	c.Assert(respHeader.Get(HTTPHeaderOssRequestID), Equals, "request-id")
+}
+
+func (s *OssBucketMockSuite) TestPutObjectWithoutServerCRC(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}, c, EnableCRC(true))
+	defer server.Close()
+	c.Assert(bucket.getConfig().IsEnableCRC, Equals, true)
+
+	logger := &recordingLogger{}
+	bucket.Client.Config.Logger = logger
+	err := bucket.PutObject("obj", strings.NewReader("大江东去，浪淘尽，千古风流人物。"))
+	c.Assert(err, IsNil)
+
+	_, err = bucket.AppendObject("obj", strings.NewReader("大江东去"), 0, InitCRC(0))
+	c.Assert(err, IsNil)
+
+	// the skipped checks are logged
+	var skipped []string
+	for _, message := range logger.messages {
+		if strings.HasSuffix(message, "the CRC check is skipped") {
+			skipped = append(skipped, message)
+		}
+	}
+	c.Assert(skipped, HasLen, 2)
+	c.Assert(strings.HasPrefix(skipped[0], "DEBUG oss: the server CRC64 header is absent in the response of DoPutObject"), Equals, true)
+	c.Assert(strings.HasPrefix(skipped[1], "DEBUG oss: the server CRC64 header is absent in the response of AppendObject"), Equals, true)
+}
+
+func (s *OssBucketMockSuite) TestRestoreObjects(c *C) {
+	var mu sync.Mutex
+	bodies := map[string]string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		mu.Lock()
+		bodies[key] = string(body)
+		mu.Unlock()
+
+		switch key {
+		case "obj2":
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `<Error><Code>RestoreAlreadyInProgress</Code><Message>The restore operation is in progress.</Message></Error>`)
+		case "obj4":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}, c)
+	defer server.Close()
+
+	keys := []string{"obj1", "obj2", "obj3"}
+	results, err := bucket.RestoreObjects(keys, RestoreConfiguration{Days: 3}, 2)
+	c.Assert(err, IsNil)
+	c.Assert(len(results), Equals, 3)
+	for i, result := range results {
+		c.Assert(result.Key, Equals, keys[i])
+		c.Assert(result.Error, IsNil)
+		c.Assert(result.InProgress, Equals, keys[i] == "obj2")
+		c.Assert(bodies[keys[i]], Equals, "<RestoreRequest><Days>3</Days></RestoreRequest>")
+	}
+
+	// the failed object is reported
+	results, err = bucket.RestoreObjects([]string{"obj1", "obj4"}, RestoreConfiguration{}, 1)
+	c.Assert(err, NotNil)
+	c.Assert(results[0].Error, IsNil)
+	c.Assert(results[1].Error, NotNil)
+	c.Assert(results[1].Error.(ServiceError).Code, Equals, "NoSuchKey")
+	c.Assert(bodies["obj1"], Equals, "")
+}
+
+func (s *OssBucketMockSuite) TestRestoreColdArchiveObject(c *C) {
+	var body string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		if r.Method == "HEAD" {
+			if key == "cold" {
+				w.Header().Set(HTTPHeaderOssStorageClass, string(StorageColdArchive))
+			} else {
+				w.Header().Set(HTTPHeaderOssStorageClass, string(StorageArchive))
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		bs, _ := ioutil.ReadAll(r.Body)
+		body = string(bs)
+		if key == "restoring" {
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `<Error><Code>RestoreAlreadyInProgress</Code><Message>The restore operation is in progress.</Message></Error>`)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}, c)
+	defer server.Close()
+
+	err := bucket.RestoreObjectDetail("cold", RestoreConfiguration{Days: 2, JobParameters: &RestoreJobParameters{Tier: RestoreBulk}})
+	c.Assert(err, IsNil)
+	c.Assert(body, Equals, "<RestoreRequest><Days>2</Days><JobParameters><Tier>Bulk</Tier></JobParameters></RestoreRequest>")
+
+	// invalid tier
+	body = ""
+	err = bucket.RestoreObjectDetail("cold", RestoreConfiguration{JobParameters: &RestoreJobParameters{Tier: "Fast"}})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "oss: invalid restore tier Fast, it must be Expedited, Standard or Bulk")
+	c.Assert(body, Equals, "")
+
+	// the Archive object does not support the tiers
+	err = bucket.RestoreObjectDetail("archive", RestoreConfiguration{JobParameters: &RestoreJobParameters{Tier: RestoreExpedited}})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "oss: the restore tier Expedited is supported by the ColdArchive object only, but archive is Archive")
+	c.Assert(body, Equals, "")
+
+	err = bucket.RestoreObjectDetail("archive", RestoreConfiguration{Days: 1})
+	c.Assert(err, IsNil)
+	c.Assert(body, Equals, "<RestoreRequest><Days>1</Days></RestoreRequest>")
+
+	// the object being restored
+	err = bucket.RestoreObjectDetail("restoring", RestoreConfiguration{Days: 1})
+	c.Assert(IsRestoreInProgress(err), Equals, true)
+}
+
+// mockProgressListener records the types of the progress events
+type mockProgressListener struct {
+	mu     sync.Mutex
+	events []ProgressEventType
+}
+
+func (listener *mockProgressListener) ProgressChanged(event *ProgressEvent) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	listener.events = append(listener.events, event.EventType)
+}
+
+func (s *OssBucketMockSuite) TestPutObjectWithContext(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Oss-Meta-Slow") == "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// hold the response until the client gives up
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}, c)
+	defer server.Close()
+
+	objectValue := "大江东去，浪淘尽，千古风流人物。"
+
+	// not cancelled
+	err := bucket.PutObjectWithContext(context.Background(), "obj", strings.NewReader(objectValue))
+	c.Assert(err, IsNil)
+
+	// cancelled in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	listener := &mockProgressListener{}
+	start := time.Now()
+	err = bucket.PutObjectWithContext(ctx, "obj", strings.NewReader(objectValue), Meta("slow", "1"), Progress(listener))
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+	c.Assert(listener.events[0], Equals, TransferStartedEvent)
+	c.Assert(listener.events[len(listener.events)-1], Equals, TransferFailedEvent)
+
+	// deadline exceeded
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = bucket.GetObjectWithContext(ctx, "obj", Meta("slow", "1"))
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	// the context is passed by the option as well
+	_, err = bucket.GetObjectDetailedMeta("obj", WithContext(ctx))
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	// the spare capacity of the caller's options is not written
+	options := make([]Option, 1, 2)
+	options[0] = Meta("key", "value")
+	err = bucket.PutObjectWithContext(context.Background(), "obj", strings.NewReader(objectValue), options...)
+	c.Assert(err, IsNil)
+	c.Assert(options[:2][1], IsNil)
+}
+
+func (s *OssBucketMockSuite) TestObjectWithURLContext(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		if r.Method == "PUT" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// sends the first half and holds the rest until the client gives up
+		w.Header().Set(HTTPHeaderContentLength, "10")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "01234")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		io.WriteString(w, "56789")
+	}, c)
+	defer server.Close()
+
+	signedURL, err := bucket.SignURL("obj", HTTPGet, 60)
+	c.Assert(err, IsNil)
+
+	// cancelled in the middle of the download
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := bucket.DoGetObjectWithURLContext(ctx, signedURL, nil)
+	c.Assert(err, IsNil)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(result.Response.Body, buf)
+	c.Assert(err, IsNil)
+	c.Assert(string(buf), Equals, "01234")
+
+	start := time.Now()
+	cancel()
+	_, err = ioutil.ReadAll(result.Response.Body)
+	result.Response.Body.Close()
+	c.Assert(err, NotNil)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+
+	// the deadline exceeds in the upload
+	signedURL, err = bucket.SignURL("obj", HTTPPut, 60)
+	c.Assert(err, IsNil)
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = bucket.DoPutObjectWithURLContext(ctx, signedURL, strings.NewReader("hello"), nil)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+}
+
+func (s *OssBucketMockSuite) TestSniffContentType(c *C) {
+	var contentType string
+	var body []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get(HTTPHeaderContentType)
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}, c)
+	defer server.Close()
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 600)
+
+	// sniffed from the content, the whole content is uploaded
+	err := bucket.PutObject("image", strings.NewReader(png), SniffContentType(true))
+	c.Assert(err, IsNil)
+	c.Assert(contentType, Equals, "image/png")
+	c.Assert(string(body), Equals, png)
+
+	// not sniffed by default
+	err = bucket.PutObject("image", strings.NewReader(png))
+	c.Assert(err, IsNil)
+	c.Assert(contentType, Equals, "application/octet-stream")
+
+	// the extension goes first
+	err = bucket.PutObject("image.txt", strings.NewReader(png), SniffContentType(true))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(contentType, "text/plain"), Equals, true)
+
+	// the explicit content type goes first
+	err = bucket.PutObject("image", strings.NewReader(png), SniffContentType(true), ContentType("image/x-icon"))
+	c.Assert(err, IsNil)
+	c.Assert(contentType, Equals, "image/x-icon")
+
+	// short content
+	err = bucket.PutObject("text", strings.NewReader("hello"), SniffContentType(true))
+	c.Assert(err, IsNil)
+	c.Assert(contentType, Equals, "text/plain; charset=utf-8")
+}
+
+func (s *OssBucketMockSuite) TestBucketVersioning(c *C) {
+	status := ""
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, "/mock-bucket/")
+		c.Assert(r.URL.RawQuery, Equals, "versioning")
+		switch r.Method {
+		case "PUT":
+			var conf VersioningConfig
+			body, _ := ioutil.ReadAll(r.Body)
+			c.Assert(xml.Unmarshal(body, &conf), IsNil)
+			status = conf.Status
+			w.WriteHeader(http.StatusOK)
+		case "GET":
+			w.WriteHeader(http.StatusOK)
+			bs, _ := xml.Marshal(VersioningConfig{Status: status})
+			w.Write(bs)
+		}
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+
+	res, err := client.GetBucketVersioning(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, "")
+
+	err = client.SetBucketVersioning(bucket.BucketName, VersionEnabled)
+	c.Assert(err, IsNil)
+	res, err = client.GetBucketVersioning(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, string(VersionEnabled))
+
+	err = client.SetBucketVersioning(bucket.BucketName, VersionSuspended)
+	c.Assert(err, IsNil)
+	res, err = client.GetBucketVersioning(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, string(VersionSuspended))
+
+	err = client.SetBucketVersioning(bucket.BucketName, VersioningStatus("Disabled"))
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestBucketEncryption(c *C) {
+	var stored []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, "/mock-bucket/")
+		c.Assert(r.URL.RawQuery, Equals, "encryption")
+		switch r.Method {
+		case "PUT":
+			stored, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		case "GET":
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<Error><Code>NoSuchServerSideEncryptionRule</Code></Error>")
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(stored)
+		case "DELETE":
+			stored = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+
+	// AES256
+	err := client.SetBucketEncryption(bucket.BucketName, ServerEncryptionRule{SSEAlgorithm: SSEAES256})
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<ServerSideEncryptionRule><ApplyServerSideEncryptionByDefault>"+
+		"<SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></ServerSideEncryptionRule>")
+	res, err := client.GetBucketEncryption(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, GetBucketEncryptionResult{SSEAlgorithm: SSEAES256})
+
+	// KMS with the master key
+	rule := ServerEncryptionRule{SSEAlgorithm: SSEKMS, KMSMasterKeyID: "key-id"}
+	err = client.SetBucketEncryption(bucket.BucketName, rule)
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<ServerSideEncryptionRule><ApplyServerSideEncryptionByDefault>"+
+		"<SSEAlgorithm>KMS</SSEAlgorithm><KMSMasterKeyID>key-id</KMSMasterKeyID>"+
+		"</ApplyServerSideEncryptionByDefault></ServerSideEncryptionRule>")
+	res, err = client.GetBucketEncryption(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(ServerEncryptionRule(res), Equals, rule)
+
+	// deleted
+	err = client.DeleteBucketEncryption(bucket.BucketName)
+	c.Assert(err, IsNil)
+	_, err = client.GetBucketEncryption(bucket.BucketName)
+	c.Assert(err, NotNil)
+	c.Assert(err.(ServiceError).Code, Equals, "NoSuchServerSideEncryptionRule")
+
+	// invalid rules are not sent
+	err = client.SetBucketEncryption(bucket.BucketName, ServerEncryptionRule{SSEAlgorithm: "SM4"})
+	c.Assert(err, NotNil)
+	err = client.SetBucketEncryption(bucket.BucketName, ServerEncryptionRule{SSEAlgorithm: SSEAES256, KMSMasterKeyID: "key-id"})
+	c.Assert(err, NotNil)
+	c.Assert(stored, IsNil)
+}
+
+func (s *OssBucketMockSuite) TestBucketPolicy(c *C) {
+	var stored []byte
+	emptyBody := false
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, "/mock-bucket/")
+		c.Assert(r.URL.RawQuery, Equals, "policy")
+		switch r.Method {
+		case "PUT":
+			c.Assert(r.Header.Get(HTTPHeaderContentType), Equals, "application/json")
+			stored, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		case "GET":
+			if emptyBody {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<Error><Code>NoSuchBucketPolicy</Code></Error>")
+				return
+			}
+			w.Header().Set(HTTPHeaderContentType, "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(stored)
+		case "DELETE":
+			stored = nil
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+	policy := `{"Version":"1","Statement":[{"Action":["oss:GetObject"],"Effect":"Allow",` +
+		`"Principal":["1234567890"],"Resource":["acs:oss:*:*:mock-bucket/*"]}]}`
+
+	err := client.SetBucketPolicy(bucket.BucketName, policy)
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, policy)
+	res, err := client.GetBucketPolicy(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, policy)
+
+	err = client.DeleteBucketPolicy(bucket.BucketName)
+	c.Assert(err, IsNil)
+	_, err = client.GetBucketPolicy(bucket.BucketName)
+	c.Assert(IsNoSuchBucketPolicy(err), Equals, true)
+
+	// the empty policy is the NoSuchBucketPolicy error too
+	emptyBody = true
+	_, err = client.GetBucketPolicy(bucket.BucketName)
+	c.Assert(IsNoSuchBucketPolicy(err), Equals, true)
+
+	// not JSON
+	err = client.SetBucketPolicy(bucket.BucketName, "<Policy/>")
+	c.Assert(err, NotNil)
+	c.Assert(stored, IsNil)
+}
+
+func (s *OssBucketMockSuite) TestBucketRequestPayment(c *C) {
+	payer := "BucketOwner"
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery == "requestPayment" {
+			switch r.Method {
+			case "PUT":
+				var conf RequestPaymentConfiguration
+				body, _ := ioutil.ReadAll(r.Body)
+				c.Assert(xml.Unmarshal(body, &conf), IsNil)
+				payer = conf.Payer
+			case "GET":
+				bs, _ := xml.Marshal(RequestPaymentConfiguration{Payer: payer})
+				w.Write(bs)
+			}
+			return
+		}
+
+		// the object requests of the others are rejected without the payer
+		ioutil.ReadAll(r.Body)
+		if payer == "Requester" && r.Header.Get(HTTPHeaderOssRequester) != "requester" {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+			return
+		}
+		if r.Method == "POST" {
+			io.WriteString(w, "<DeleteResult><Deleted><Key>object</Key></Deleted></DeleteResult>")
+			return
+		}
+		io.WriteString(w, "data")
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+	res, err := client.GetBucketRequestPayment(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.Payer, Equals, string(BucketOwner))
+
+	err = client.SetBucketRequestPayment(bucket.BucketName, RequestPaymentConfiguration{Payer: string(Requester)})
+	c.Assert(err, IsNil)
+	res, err = client.GetBucketRequestPayment(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.Payer, Equals, string(Requester))
+
+	// without the payer
+	err = bucket.PutObject("object", strings.NewReader("data"))
+	c.Assert(IsAccessDenied(err), Equals, true)
+
+	// with the payer
+	err = bucket.PutObject("object", strings.NewReader("data"), RequestPayer("requester"))
+	c.Assert(err, IsNil)
+	body, err := bucket.GetObject("object", RequestPayer(string(Requester)))
+	c.Assert(err, IsNil)
+	data, _ := ioutil.ReadAll(body)
+	body.Close()
+	c.Assert(string(data), Equals, "data")
+	del, err := bucket.DeleteObjects([]string{"object"}, RequestPayer("requester"))
+	c.Assert(err, IsNil)
+	c.Assert(del.DeletedObjects, DeepEquals, []string{"object"})
+
+	// invalid payer
+	err = client.SetBucketRequestPayment(bucket.BucketName, RequestPaymentConfiguration{Payer: "Nobody"})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestBucketStat(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "GET")
+		c.Assert(r.URL.Path, Equals, "/mock-bucket/")
+		c.Assert(r.URL.RawQuery, Equals, "stat")
+		io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<BucketStat>
+  <Storage>1600</Storage>
+  <ObjectCount>230</ObjectCount>
+  <MultipartUploadCount>40</MultipartUploadCount>
+</BucketStat>`)
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+	stat, err := client.GetBucketStat(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(stat.Storage, Equals, int64(1600))
+	c.Assert(stat.ObjectCount, Equals, int64(230))
+	c.Assert(stat.MultipartUploadCount, Equals, int64(40))
+
+	size, err := client.GetBucketStorageSize(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(size, Equals, int64(1600))
+
+	count, err := client.GetBucketObjectCount(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, int64(230))
+}
+
+func (s *OssBucketMockSuite) TestIsBucketExistPrefixNeighbors(c *C) {
+	// the buckets whose names are the prefix of each other
+	buckets := map[string]int{"log": http.StatusOK, "logs-archive": http.StatusOK, "logs-other": http.StatusForbidden}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "GET")
+		c.Assert(r.URL.RawQuery, Equals, "bucketInfo")
+		name := strings.Trim(r.URL.Path, "/")
+		switch buckets[name] {
+		case http.StatusOK:
+			io.WriteString(w, "<BucketInfo><Bucket><Name>"+name+"</Name></Bucket></BucketInfo>")
+		case http.StatusForbidden:
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+		case 0:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchBucket</Code></Error>")
+		default:
+			w.WriteHeader(buckets[name])
+			io.WriteString(w, "<Error><Code>InternalError</Code></Error>")
+		}
+	}, c)
+	defer server.Close()
+	client := bucket.Client
+
+	for name, expected := range map[string]bool{"log": true, "logs": false, "logs-archive": true, "lo": false,
+		"logs-archive-2": false, "logs-other": true} {
+		exist, err := client.IsBucketExist(name)
+		c.Assert(err, IsNil)
+		c.Assert(exist, Equals, expected, Commentf("bucket %s", name))
+	}
+
+	// the other error
+	buckets["logs-broken"] = http.StatusInternalServerError
+	_, err := client.IsBucketExist("logs-broken")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestBucketWebsiteDetail(c *C) {
+	var stored []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.RawQuery, Equals, "website")
+		switch r.Method {
+		case "PUT":
+			stored, _ = ioutil.ReadAll(r.Body)
+		case "GET":
+			w.Write(stored)
+		}
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+
+	// redirects the 404s under docs/ to /404.html
+	wxml := WebsiteXML{}
+	wxml.IndexDocument.Suffix = "index.html"
+	wxml.RoutingRules = []RoutingRule{{
+		Condition: RoutingCondition{KeyPrefixEquals: "docs/", HTTPErrorCodeReturnedEquals: http.StatusNotFound},
+		Redirect:  RoutingRedirect{RedirectType: "External", Protocol: "https", HostName: "www.example.com", ReplaceKeyWith: "404.html"},
+	}}
+	err := client.SetBucketWebsiteDetail(bucket.BucketName, wxml)
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument>"+
+		"<RoutingRules><RoutingRule><RuleNumber>1</RuleNumber>"+
+		"<Condition><KeyPrefixEquals>docs/</KeyPrefixEquals><HttpErrorCodeReturnedEquals>404</HttpErrorCodeReturnedEquals></Condition>"+
+		"<Redirect><RedirectType>External</RedirectType><Protocol>https</Protocol><HostName>www.example.com</HostName>"+
+		"<ReplaceKeyWith>404.html</ReplaceKeyWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>")
+
+	res, err := client.GetBucketWebsite(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.IndexDocument.Suffix, Equals, "index.html")
+	c.Assert(res.ErrorDocument.Key, Equals, "")
+	c.Assert(len(res.RoutingRules), Equals, 1)
+	c.Assert(res.RoutingRules[0].RuleNumber, Equals, 1)
+	c.Assert(res.RoutingRules[0].Condition.HTTPErrorCodeReturnedEquals, Equals, http.StatusNotFound)
+	c.Assert(res.RoutingRules[0].Redirect.ReplaceKeyWith, Equals, "404.html")
+	c.Assert(res.RedirectAllRequestsTo, IsNil)
+
+	// redirects all the requests
+	wxml = WebsiteXML{RedirectAllRequestsTo: &RedirectAllRequestsTo{HostName: "www.example.com", Protocol: "https"}}
+	err = client.SetBucketWebsiteDetail(bucket.BucketName, wxml)
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<WebsiteConfiguration><RedirectAllRequestsTo><HostName>www.example.com</HostName>"+
+		"<Protocol>https</Protocol></RedirectAllRequestsTo></WebsiteConfiguration>")
+	res, err = client.GetBucketWebsite(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.RedirectAllRequestsTo.HostName, Equals, "www.example.com")
+	c.Assert(res.RedirectAllRequestsTo.Protocol, Equals, "https")
+
+	// the simple variant is not changed
+	err = client.SetBucketWebsite(bucket.BucketName, "index.html", "error.html")
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument>"+
+		"<ErrorDocument><Key>error.html</Key></ErrorDocument></WebsiteConfiguration>")
+}
+
+func (s *OssBucketMockSuite) TestBucketRefererFlags(c *C) {
+	var stored []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.RawQuery, Equals, "referer")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "PUT" {
+			stored, _ = ioutil.ReadAll(r.Body)
+		} else {
+			w.Write(stored)
+		}
+	}, c)
+	defer server.Close()
+
+	client := bucket.Client
+	referers := []string{"https://www.aliyun.com"}
+	err := client.SetBucketReferer(bucket.BucketName, referers, false, RefererAllowHTTPSOnly(true), RefererTruncatePath(true))
+	c.Assert(err, IsNil)
+	c.Assert(string(stored), Equals, "<RefererConfiguration><AllowEmptyReferer>false</AllowEmptyReferer>"+
+		"<TruncatePath>true</TruncatePath><AllowHttpsOnly>true</AllowHttpsOnly>"+
+		"<RefererList><Referer>https://www.aliyun.com</Referer></RefererList></RefererConfiguration>")
+
+	res, err := client.GetBucketReferer(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.AllowEmptyReferer, Equals, false)
+	c.Assert(res.AllowHTTPSOnly, Equals, true)
+	c.Assert(res.TruncatePath, Equals, true)
+	c.Assert(res.RefererList, DeepEquals, referers)
+
+	// the flags are not sent by default
+	err = client.SetBucketReferer(bucket.BucketName, referers, true)
+	c.Assert(err, IsNil)
+	res, err = client.GetBucketReferer(bucket.BucketName)
+	c.Assert(err, IsNil)
+	c.Assert(res.AllowEmptyReferer, Equals, true)
+	c.Assert(res.AllowHTTPSOnly, Equals, false)
+	c.Assert(res.TruncatePath, Equals, false)
+	c.Assert(strings.Contains(string(stored), "AllowHttpsOnly"), Equals, false)
+}
+
+func (s *OssBucketMockSuite) TestSpotCheckObject(c *C) {
+	data := []byte(strings.Repeat("0123456789", 100))
+	etag := `"etag"`
+	ranges := 0
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HTTPHeaderRange) != "" {
+			ranges++
+			c.Assert(r.Header.Get(HTTPHeaderIfMatch), Equals, `"etag"`)
+		}
+		w.Header().Set(HTTPHeaderEtag, etag)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}, c)
+	defer server.Close()
+
+	rangeCRC := func(start, end int64) uint64 {
+		crc := crc64.New(crcTable())
+		crc.Write(data[start : end+1])
+		return crc.Sum64()
+	}
+	samples := []SpotCheckRange{
+		{Start: 0, End: 99, CRC64: rangeCRC(0, 99)},
+		{Start: 500, End: 500, CRC64: rangeCRC(500, 500)},
+		{Start: 900, End: 999, CRC64: rangeCRC(900, 999)},
+	}
+	err := bucket.SpotCheckObject("object", samples)
+	c.Assert(err, IsNil)
+	c.Assert(ranges, Equals, 3)
+
+	// the mismatched range
+	samples[1].CRC64++
+	err = bucket.SpotCheckObject("object", samples)
+	c.Assert(err, FitsTypeOf, CRCCheckError{})
+	c.Assert(strings.Contains(err.Error(), "SpotCheckObject bytes=500-500"), Equals, true)
+
+	// the range out of the object
+	err = bucket.SpotCheckObject("object", []SpotCheckRange{{Start: 900, End: 1000}})
+	c.Assert(err, NotNil)
+	err = bucket.SpotCheckObject("object", []SpotCheckRange{{Start: 10, End: 9}})
+	c.Assert(err, NotNil)
+}
+
+// countingTransport counts the requests sent by the custom HTTP client
+type countingTransport struct {
+	mu       sync.Mutex
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests++
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *OssBucketMockSuite) TestCustomHTTPClient(c *C) {
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "hello")
+	}, c, Timeout(1, 1), HTTPClient(httpClient))
+	defer server.Close()
+
+	c.Assert(bucket.Client.Config.HTTPClient, Equals, httpClient)
+	c.Assert(bucket.Client.Conn.client, Equals, httpClient)
+
+	body, err := bucket.GetObject("object")
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
+	c.Assert(transport.requests, Equals, 1)
+
+	str, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	body, err = bucket.GetObjectWithURL(str)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(transport.requests, Equals, 2)
+}
+
+// truncatedTransport responds with a body shorter than its Content-Length and no read error,
+// like a connection closed early by a misbehaving proxy.
+type truncatedTransport struct {
+	body          string
+	contentLength int
+}
+
+func (t truncatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	header.Set(HTTPHeaderContentLength, strconv.Itoa(t.contentLength))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(t.body)),
+		Request:    req,
+	}, nil
+}
+
+func (s *OssBucketMockSuite) TestGetObjectToFileTruncated(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {}, c, EnableCRC(false))
+	defer server.Close()
+	bucket.Client.Conn.client.Transport = truncatedTransport{body: "0123456789", contentLength: 100}
+
+	filePath := "mock-truncated.txt"
+	err := bucket.GetObjectToFile("object", filePath)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "got 10 bytes"), Equals, true)
+	_, err = os.Stat(filePath)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(filePath + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// the range request is not checked
+	err = bucket.GetObjectToFile("object", filePath, Range(0, 9))
+	c.Assert(err, IsNil)
+	os.Remove(filePath)
+
+	// complete
+	bucket.Client.Conn.client.Transport = truncatedTransport{body: "0123456789", contentLength: 10}
+	err = bucket.GetObjectToFile("object", filePath)
+	c.Assert(err, IsNil)
+	os.Remove(filePath)
+}
+
+func (s *OssBucketMockSuite) TestPutObjectIfAbsent(c *C) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		if _, ok := objects[key]; ok {
+			switch {
+			case r.Header.Get(HTTPHeaderIfNoneMatch) == "*":
+				w.WriteHeader(http.StatusPreconditionFailed)
+				io.WriteString(w, "<Error><Code>PreconditionFailed</Code></Error>")
+				return
+			case r.Header.Get(HTTPHeaderOssForbidOverwrite) == "true":
+				w.WriteHeader(http.StatusConflict)
+				io.WriteString(w, "<Error><Code>FileAlreadyExists</Code></Error>")
+				return
+			}
+		}
+		objects[key], _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}, c, EnableCRC(false))
+	defer server.Close()
+
+	// created once
+	err := bucket.PutObject("lock", strings.NewReader("owner-1"), IfNoneMatch("*"))
+	c.Assert(err, IsNil)
+	err = bucket.PutObject("lock", strings.NewReader("owner-2"), IfNoneMatch("*"))
+	c.Assert(err, Equals, ErrObjectAlreadyExists)
+	c.Assert(string(objects["lock"]), Equals, "owner-1")
+
+	err = bucket.PutObject("lock2", strings.NewReader("owner-1"), ForbidOverwrite(true))
+	c.Assert(err, IsNil)
+	_, err = bucket.PutObjectWithResult("lock2", strings.NewReader("owner-2"), ForbidOverwrite(true))
+	c.Assert(err, Equals, ErrObjectAlreadyExists)
+	c.Assert(string(objects["lock2"]), Equals, "owner-1")
+
+	// the other precondition failure is not mapped
+	c.Assert(checkObjectAlreadyExists(ServiceError{StatusCode: http.StatusPreconditionFailed}, []Option{IfMatch("x")}),
+		Not(Equals), ErrObjectAlreadyExists)
+
+	// overwritten without the option
+	err = bucket.PutObject("lock", strings.NewReader("owner-2"))
+	c.Assert(err, IsNil)
+	c.Assert(string(objects["lock"]), Equals, "owner-2")
+}
+
+func (s *OssBucketMockSuite) TestGetObjectVerifyCRC(c *C) {
+	data := []byte("the object data streamed by GetObject")
+	serverCRC := mockCRC64(data)
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderOssCRC64, serverCRC)
+		if r.Header.Get(HTTPHeaderRange) != "" {
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(data[:4])
+			return
+		}
+		w.Write(data)
+	}, c)
+	defer server.Close()
+
+	// matched
+	body, err := bucket.GetObject("object", VerifyCRC(true), ReadAhead(8))
+	c.Assert(err, IsNil)
+	got, err := ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, data)
+	c.Assert(body.Close(), IsNil)
+
+	// mismatched, the error is returned instead of io.EOF and by Close
+	serverCRC = "1"
+	body, err = bucket.GetObject("object", VerifyCRC(true))
+	c.Assert(err, IsNil)
+	got, err = ioutil.ReadAll(body)
+	c.Assert(got, DeepEquals, data)
+	_, ok := err.(CRCCheckError)
+	c.Assert(ok, Equals, true)
+	_, ok = body.Close().(CRCCheckError)
+	c.Assert(ok, Equals, true)
+
+	// not checked without the option, or for the range
+	body, err = bucket.GetObject("object")
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	c.Assert(body.Close(), IsNil)
+
+	body, err = bucket.GetObject("object", VerifyCRC(true), Range(0, 3))
+	c.Assert(err, IsNil)
+	got, err = ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	c.Assert(string(got), Equals, "the ")
+	c.Assert(body.Close(), IsNil)
+
+	// closed before the end is not checked
+	body, err = bucket.GetObject("object", VerifyCRC(true))
+	c.Assert(err, IsNil)
+	_, err = body.Read(make([]byte, 4))
+	c.Assert(err, IsNil)
+	c.Assert(body.Close(), IsNil)
+}
+
+// failingBodyTransport responds with a body failing after some bytes, like a connection reset in the download
+type failingBodyTransport struct {
+	err error
+}
+
+func (t failingBodyTransport) Read(p []byte) (int, error) {
+	return 0, t.err
+}
+
+func (t failingBodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	header.Set(HTTPHeaderContentLength, "100")
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(io.MultiReader(strings.NewReader("0123456789"), t)),
+		Request:    req,
+	}, nil
+}
+
+func (s *OssBucketMockSuite) TestGetObjectToFileRemovesTempFile(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {}, c)
+	defer server.Close()
+	readErr := errors.New("read: connection reset by peer")
+	bucket.Client.Conn.client.Transport = failingBodyTransport{err: readErr}
+
+	filePath := "mock-failing.txt"
+	err := bucket.GetObjectToFile("object", filePath)
+	c.Assert(err, Equals, readErr)
+	_, err = os.Stat(filePath + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	err = bucket.GetObjectToFileWithURL(signedURL, filePath)
+	c.Assert(err, Equals, readErr)
+	_, err = os.Stat(filePath + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// the rename fails as the file path is a directory
+	bucket.Client.Config.IsEnableCRC = false
+	bucket.Client.Conn.client.Transport = truncatedTransport{body: "0123456789", contentLength: 10}
+	dir, err := ioutil.TempDir("", "oss-rename-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(os.Mkdir(filepath.Join(dir, "target"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "target", "file"), nil, 0644), IsNil)
+	err = bucket.GetObjectToFile("object", filepath.Join(dir, "target"))
+	c.Assert(err, NotNil)
+	_, err = os.Stat(filepath.Join(dir, "target") + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *OssBucketMockSuite) TestListObjectsWithTags(c *C) {
+	var mu sync.Mutex
+	tagRequests := 0
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/mock-bucket/" {
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Name>mock-bucket</Name>
+  <EncodingType>url</EncodingType>
+  <Contents><Key>obj1</Key></Contents>
+  <Contents><Key>obj2</Key></Contents>
+  <Contents><Key>obj%203</Key></Contents>
+</ListBucketResult>`)
+			return
+		}
+
+		c.Assert(r.URL.RawQuery, Equals, "tagging")
+		mu.Lock()
+		tagRequests++
+		mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		bs, _ := xml.Marshal(Tagging{Tags: []Tag{{Key: "name", Value: key}}})
+		w.WriteHeader(http.StatusOK)
+		w.Write(bs)
+	}, c)
+	defer server.Close()
+
+	// no tags by default
+	lor, err := bucket.ListObjects()
+	c.Assert(err, IsNil)
+	c.Assert(len(lor.Objects), Equals, 3)
+	c.Assert(lor.Objects[0].Tags, IsNil)
+	c.Assert(tagRequests, Equals, 0)
+
+	lor, err = bucket.ListObjects(WithTags(true), Routines(2))
+	c.Assert(err, IsNil)
+	c.Assert(tagRequests, Equals, 3)
+	for _, object := range lor.Objects {
+		c.Assert(len(object.Tags), Equals, 1)
+		c.Assert(object.Tags[0].Key, Equals, "name")
+		c.Assert(object.Tags[0].Value, Equals, object.Key)
+	}
+	c.Assert(lor.Objects[2].Key, Equals, "obj 3")
+}
+
+func (s *OssBucketMockSuite) TestObjectVersionId(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		versionID := r.URL.Query().Get("versionId")
+		switch r.Method {
+		case "GET", "HEAD":
+			c.Assert(versionID, Equals, "v1")
+			w.Header().Set(HTTPHeaderOssVersionID, versionID)
+			w.WriteHeader(http.StatusOK)
+			if r.Method == "GET" {
+				io.WriteString(w, "version 1")
+			}
+		case "PUT":
+			c.Assert(versionID, Equals, "")
+			c.Assert(r.Header.Get(HTTPHeaderOssCopySource), Equals, "/mock-bucket/src%2Fobject?versionId=v1")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, "<CopyObjectResult><ETag>\"etag\"</ETag></CopyObjectResult>")
+		case "DELETE":
+			if versionID == "" {
+				// deleting without the version inserts a delete marker
+				versionID = "marker"
+			}
+			w.Header().Set(HTTPHeaderOssVersionID, versionID)
+			w.Header().Set(HTTPHeaderOssDeleteMarker, "true")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}, c)
+	defer server.Close()
+
+	body, err := bucket.GetObject("object", VersionId("v1"))
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "version 1")
+
+	meta, err := bucket.GetObjectDetailedMeta("object", VersionId("v1"))
+	c.Assert(err, IsNil)
+	c.Assert(meta.Get(HTTPHeaderOssVersionID), Equals, "v1")
+
+	_, err = bucket.CopyObject("src/object", "dest", VersionId("v1"))
+	c.Assert(err, IsNil)
+
+	res, err := bucket.DeleteObjectWithResult("object", VersionId("v1"))
+	c.Assert(err, IsNil)
+	c.Assert(res.VersionID, Equals, "v1")
+	c.Assert(res.DeleteMarker, Equals, true)
+
+	res, err = bucket.DeleteObjectWithResult("object")
+	c.Assert(err, IsNil)
+	c.Assert(res.VersionID, Equals, "marker")
+	c.Assert(res.DeleteMarker, Equals, true)
+
+	err = bucket.DeleteObject("object", VersionId("v1"))
+	c.Assert(err, IsNil)
+}
+
+func (s *OssBucketMockSuite) TestRetryIdempotentRequests(c *C) {
+	var mu sync.Mutex
+	failures := 0
+	bodies := []string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(body))
+		w.WriteHeader(http.StatusOK)
+	}, c, MaxRetries(2), RetryBackoff(time.Millisecond, 2*time.Millisecond), EnableMD5(true))
+	defer server.Close()
+
+	c.Assert(bucket.Client.Config.MaxRetries, Equals, 2)
+	c.Assert(bucket.Client.Conn.getRetryBackoff(0), Equals, time.Millisecond)
+	c.Assert(bucket.Client.Conn.getRetryBackoff(5), Equals, 2*time.Millisecond)
+
+	// the body is sent from the start again, the CRC is not accumulated across the attempts
+	failures = 2
+	listener := &mockProgressListener{}
+	err := bucket.PutObject("object", strings.NewReader("hello"), Progress(listener))
+	c.Assert(err, IsNil)
+	c.Assert(bodies, DeepEquals, []string{"hello", "hello", "hello"})
+	c.Assert(listener.events[0], Equals, TransferStartedEvent)
+	c.Assert(listener.events[len(listener.events)-1], Equals, TransferCompletedEvent)
+	for _, event := range listener.events {
+		c.Assert(event, Not(Equals), TransferFailedEvent)
+	}
+
+	// out of the retries
+	failures, bodies = 3, nil
+	err = bucket.PutObject("object", strings.NewReader("hello"))
+	c.Assert(err, NotNil)
+	c.Assert(len(bodies), Equals, 3)
+	failures = 0
+
+	// the reader which is not seekable is not retried
+	failures, bodies = 1, nil
+	err = bucket.PutObject("object", io.MultiReader(strings.NewReader("hello")))
+	c.Assert(err, NotNil)
+	c.Assert(len(bodies), Equals, 1)
+
+	// the POST request is not retried
+	failures, bodies = 1, nil
+	_, err = bucket.AppendObject("object", strings.NewReader("hello"), 0)
+	c.Assert(err, NotNil)
+	c.Assert(len(bodies), Equals, 1)
+
+	// the conditional PUT is not retried, the first attempt may have created the object
+	failures, bodies = 1, nil
+	err = bucket.PutObject("object", strings.NewReader("hello"), ForbidOverwrite(true))
+	c.Assert(err, NotNil)
+	c.Assert(len(bodies), Equals, 1)
+	failures, bodies = 1, nil
+	err = bucket.PutObject("object", strings.NewReader("hello"), IfNoneMatch("*"))
+	c.Assert(err, NotNil)
+	c.Assert(len(bodies), Equals, 1)
+	failures, bodies = 1, nil
+	err = bucket.PutObject("object", strings.NewReader("hello"), ForbidOverwrite(false))
+	c.Assert(err, IsNil)
+	c.Assert(len(bodies), Equals, 2)
+
+	// GET
+	failures, bodies = 1, nil
+	body, err := bucket.GetObject("object")
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(len(bodies), Equals, 2)
+}
+
+func (s *OssBucketMockSuite) TestRetryThrottled(c *C) {
+	var mu sync.Mutex
+	var throttles int
+	var retryAfter string
+	var times []time.Time
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+		if throttles > 0 {
+			throttles--
+			if retryAfter != "" {
+				w.Header().Set(HTTPHeaderRetryAfter, retryAfter)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, "<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}, c, MaxRetries(1), RetryBackoff(time.Millisecond, 100*time.Millisecond))
+	defer server.Close()
+
+	// the delay of Retry-After is honored instead of the 1ms backoff, it's bounded by the max backoff
+	throttles, retryAfter = 1, "1"
+	_, err := bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(len(times), Equals, 2)
+	delay := times[1].Sub(times[0])
+	c.Assert(delay >= 100*time.Millisecond && delay < time.Second, Equals, true)
+
+	// out of the retries
+	throttles, retryAfter, times = 2, "0", nil
+	err = bucket.PutObject("object", strings.NewReader("hello"))
+	c.Assert(err, NotNil)
+	c.Assert(len(times), Equals, 2)
+	throttleErr, ok := err.(ThrottleError)
+	c.Assert(ok, Equals, true)
+	c.Assert(throttleErr.Retries, Equals, 1)
+	c.Assert(throttleErr.RetryAfter, Equals, time.Duration(0))
+	c.Assert(throttleErr.StatusCode, Equals, http.StatusServiceUnavailable)
+	code, ok := ServiceErrorCode(err)
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, "SlowDown")
+
+	// HEAD has no error body, it's throttled by Retry-After
+	throttles, retryAfter, times = 2, "2", nil
+	_, err = bucket.GetObjectDetailedMeta("object")
+	throttleErr, ok = err.(ThrottleError)
+	c.Assert(ok, Equals, true)
+	c.Assert(throttleErr.RetryAfter, Equals, 2*time.Second)
+	c.Assert(throttleErr.StatusCode, Equals, http.StatusServiceUnavailable)
+	c.Assert(times[1].Sub(times[0]) < time.Second, Equals, true)
+
+	// SlowDown without the retry is the plain ServiceError
+	bucket.Client.Config.MaxRetries = 0
+	throttles, retryAfter, times = 1, "", nil
+	_, err = bucket.GetObject("object")
+	_, ok = err.(ThrottleError)
+	c.Assert(ok, Equals, false)
+	srvErr, ok := err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(srvErr.Code, Equals, "SlowDown")
+	c.Assert(len(times), Equals, 1)
+
+	// the HTTP date
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set(HTTPHeaderRetryAfter, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	delay, ok = getRetryAfter(resp)
+	c.Assert(ok, Equals, true)
+	c.Assert(delay > 59*time.Minute && delay <= time.Hour, Equals, true)
+	resp.Header.Set(HTTPHeaderRetryAfter, "soon")
+	_, ok = getRetryAfter(resp)
+	c.Assert(ok, Equals, false)
+}
+
+// resetTransport fails the first requests with the connection reset
+type resetTransport struct {
+	resets   int
+	requests int
+}
+
+func (t *resetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	if t.resets > 0 {
+		t.resets--
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *OssBucketMockSuite) TestRetryConnectionReset(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, c, MaxRetries(1), RetryBackoff(time.Millisecond, time.Millisecond))
+	defer server.Close()
+	transport := &resetTransport{resets: 1}
+	bucket.Client.Conn.client.Transport = transport
+
+	listener := &mockProgressListener{}
+	_, err := bucket.GetObjectDetailedMeta("object", Progress(listener))
+	c.Assert(err, IsNil)
+	c.Assert(transport.requests, Equals, 2)
+
+	// the failed event is only published after the last attempt
+	transport.resets, transport.requests = 2, 0
+	listener = &mockProgressListener{}
+	err = bucket.PutObject("object", strings.NewReader("hello"), Progress(listener))
+	c.Assert(err, NotNil)
+	c.Assert(transport.requests, Equals, 2)
+	failed := 0
+	for _, event := range listener.events {
+		if event == TransferFailedEvent {
+			failed++
+		}
+	}
+	c.Assert(failed, Equals, 1)
+
+	// the context is cancelled in the backoff
+	bucket.Client.Config.RetryBackoff = time.Hour
+	bucket.Client.Config.MaxRetryBackoff = time.Hour
+	transport.resets, transport.requests = 1, 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = bucket.GetObjectDetailedMeta("object", WithContext(ctx))
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(transport.requests, Equals, 1)
+
+	c.Assert(isConnectionReset(errors.New("read tcp: connection reset by peer")), Equals, true)
+	c.Assert(isConnectionReset(io.EOF), Equals, false)
+}
+
+func (s *OssBucketMockSuite) TestStatSymlink(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		_, isSymlink := r.URL.Query()["symlink"]
+		switch {
+		case r.Method == "HEAD" && key == "link":
+			w.Header().Set(HTTPHeaderOssObjectType, "Symlink")
+			w.Header().Set(HTTPHeaderContentLength, "25")
+			w.Header().Set(HTTPHeaderEtag, "\"link-etag\"")
+			w.Header().Set(HTTPHeaderLastModified, "Fri, 24 Feb 2017 03:15:40 GMT")
+			w.Header().Set("X-Oss-Meta-Owner", "tom")
+		case r.Method == "GET" && key == "link" && isSymlink:
+			w.Header().Set(HTTPHeaderOssSymlinkTarget, url.QueryEscape("dir/target object"))
+		case r.Method == "HEAD" && key == "normal":
+			w.Header().Set(HTTPHeaderOssObjectType, "Normal")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, c)
+	defer server.Close()
+
+	meta, err := bucket.StatSymlink("link")
+	c.Assert(err, IsNil)
+	c.Assert(meta.TargetKey, Equals, "dir/target object")
+	c.Assert(meta.ObjectType, Equals, "Symlink")
+	c.Assert(meta.Size, Equals, int64(25))
+	c.Assert(meta.ETag, Equals, "\"link-etag\"")
+	c.Assert(meta.LastModified.Equal(time.Date(2017, 2, 24, 3, 15, 40, 0, time.UTC)), Equals, true)
+	c.Assert(meta.Headers.Get("X-Oss-Meta-Owner"), Equals, "tom")
+
+	_, err = bucket.StatSymlink("normal")
+	c.Assert(err, NotNil)
+
+	_, err = bucket.StatSymlink("none")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestIsObjectExistSymlink(c *C) {
+	var requests []string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		requests = append(requests, r.URL.RawQuery+" "+key)
+		_, isSymlink := r.URL.Query()["symlink"]
+		switch {
+		case key == "normal" && !isSymlink:
+		case key == "link" && isSymlink:
+			// the symlink to the nonexistent target
+			w.Header().Set(HTTPHeaderOssSymlinkTarget, "missing")
+		case key == "link":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>SymlinkTargetNotExist</Code></Error>")
+		case key == "denied":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+		case key == "proxied" && !isSymlink:
+			// 404 without the error body
+			w.WriteHeader(http.StatusNotFound)
+		case key == "headed" && isSymlink:
+			w.Header().Set(HTTPHeaderOssSymlinkTarget, "missing")
+		case key == "headed":
+			// the error body in the header
+			w.Header().Set(HTTPHeaderOssErr, base64.StdEncoding.EncodeToString(
+				[]byte("<Error><Code>SymlinkTargetNotExist</Code></Error>")))
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+		}
+	}, c)
+	defer server.Close()
+
+	exist, err := bucket.IsObjectExist("link")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, true)
+	c.Assert(requests, DeepEquals, []string{"objectMeta link", "symlink link"})
+
+	requests = nil
+	exist, err = bucket.IsObjectExist("normal")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, true)
+	c.Assert(requests, DeepEquals, []string{"objectMeta normal"})
+
+	// NoSuchKey doesn't check the symlink
+	requests = nil
+	exist, err = bucket.IsObjectExist("missing")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, false)
+	c.Assert(requests, DeepEquals, []string{"objectMeta missing"})
+
+	// 404 without the error code doesn't check the symlink either
+	requests = nil
+	exist, err = bucket.IsObjectExist("proxied")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, false)
+	c.Assert(requests, DeepEquals, []string{"objectMeta proxied"})
+
+	// the error code in the X-Oss-Err header
+	requests = nil
+	exist, err = bucket.IsObjectExist("headed")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, true)
+	c.Assert(requests, DeepEquals, []string{"objectMeta headed", "symlink headed"})
+
+	_, err = bucket.IsObjectExist("denied")
+	c.Assert(IsAccessDenied(err), Equals, true)
+}
+
+func (s *OssBucketMockSuite) TestGetObjectMetaIfExists(c *C) {
+	var requests int
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch strings.TrimPrefix(r.URL.Path, "/mock-bucket/") {
+		case "object":
+			w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+			w.Header().Set(HTTPHeaderContentLength, "0")
+		case "denied":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+		}
+	}, c)
+	defer server.Close()
+
+	meta, found, err := bucket.GetObjectMetaIfExists("object")
+	c.Assert(err, IsNil)
+	c.Assert(found, Equals, true)
+	c.Assert(meta.Get(HTTPHeaderEtag), Equals, "\"etag\"")
+	c.Assert(requests, Equals, 1)
+
+	meta, found, err = bucket.GetObjectMetaIfExists("missing")
+	c.Assert(err, IsNil)
+	c.Assert(found, Equals, false)
+	c.Assert(meta, IsNil)
+
+	meta, found, err = bucket.GetObjectMetaIfExists("denied")
+	c.Assert(IsAccessDenied(err), Equals, true)
+	c.Assert(found, Equals, false)
+}
+
+func (s *OssBucketMockSuite) TestSignPostPolicy(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {}, c, SecurityToken("sts-token"))
+	defer server.Close()
+
+	expiration := time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC)
+	res, err := bucket.SignPostPolicy(PostPolicy{
+		Expiration:          expiration,
+		KeyStartsWith:       "user/",
+		MaxContentLength:    1024 * 1024,
+		SuccessActionStatus: http.StatusCreated,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(res.AccessKeyID, Equals, "ak")
+	c.Assert(res.SecurityToken, Equals, "sts-token")
+
+	doc, err := base64.StdEncoding.DecodeString(res.Policy)
+	c.Assert(err, IsNil)
+	c.Assert(string(doc), Equals, `{"conditions":[{"bucket":"mock-bucket"},["starts-with","$key","user/"],`+
+		`["content-length-range",0,1048576],{"success_action_status":"201"}],"expiration":"2017-12-01T12:00:00.000Z"}`)
+
+	h := hmac.New(sha1.New, []byte("sk"))
+	io.WriteString(h, res.Policy)
+	c.Assert(res.Signature, Equals, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+
+	// the key equals
+	res, err = bucket.SignPostPolicy(PostPolicy{Expiration: expiration, KeyEquals: "user/a.jpg"})
+	c.Assert(err, IsNil)
+	doc, _ = base64.StdEncoding.DecodeString(res.Policy)
+	c.Assert(strings.Contains(string(doc), `["eq","$key","user/a.jpg"]`), Equals, true)
+	c.Assert(strings.Contains(string(doc), "content-length-range"), Equals, false)
+
+	// invalid policies
+	_, err = bucket.SignPostPolicy(PostPolicy{})
+	c.Assert(err, NotNil)
+	_, err = bucket.SignPostPolicy(PostPolicy{Expiration: expiration, MinContentLength: 10, MaxContentLength: 1})
+	c.Assert(err, NotNil)
+	_, err = bucket.SignPostPolicy(PostPolicy{Expiration: expiration, SuccessActionStatus: http.StatusAccepted})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestGetObjects(c *C) {
+	objects := map[string]string{"conf/a.json": `{"a":1}`, "conf/b.json": `{"b":2}`, "conf/large.json": strings.Repeat("x", 100)}
+	var requests int32
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		data, ok := objects[strings.TrimPrefix(r.URL.Path, "/mock-bucket/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		io.WriteString(w, data)
+	}, c)
+	defer server.Close()
+
+	keys := []string{"conf/a.json", "conf/b.json", "conf/missing.json", "conf/large.json", "conf/a.json"}
+	contents, errs := bucket.GetObjects(keys, 3)
+	c.Assert(len(contents), Equals, 3)
+	c.Assert(string(contents["conf/a.json"]), Equals, `{"a":1}`)
+	c.Assert(string(contents["conf/b.json"]), Equals, `{"b":2}`)
+	c.Assert(len(contents["conf/large.json"]), Equals, 100)
+	c.Assert(len(errs), Equals, 1)
+	c.Assert(IsNoSuchKey(errs["conf/missing.json"]), Equals, true)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(4))
+
+	// the object larger than the max size fails
+	contents, errs = bucket.GetObjects(keys, 0, MaxObjectSize(10))
+	c.Assert(len(contents), Equals, 2)
+	c.Assert(len(errs), Equals, 2)
+	c.Assert(errs["conf/large.json"], NotNil)
+	c.Assert(IsNoSuchKey(errs["conf/missing.json"]), Equals, true)
+}
+
+func (s *OssBucketMockSuite) TestStatObject(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "HEAD")
+		if strings.HasSuffix(r.URL.Path, "/untagged") {
+			w.Header().Set(HTTPHeaderContentLength, "5")
+			return
+		}
+		w.Header().Set(HTTPHeaderContentLength, "1024")
+		w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+		w.Header().Set(HTTPHeaderLastModified, "Fri, 24 Feb 2017 03:15:40 GMT")
+		w.Header().Set(HTTPHeaderOssObjectType, "Normal")
+		w.Header().Set(HTTPHeaderOssStorageClass, "IA")
+		w.Header().Set(HTTPHeaderOssTaggingCount, "3")
+	}, c)
+	defer server.Close()
+
+	stat, err := bucket.StatObject("tagged")
+	c.Assert(err, IsNil)
+	c.Assert(stat.Size, Equals, int64(1024))
+	c.Assert(stat.ETag, Equals, "\"etag\"")
+	c.Assert(stat.LastModified.Equal(time.Date(2017, 2, 24, 3, 15, 40, 0, time.UTC)), Equals, true)
+	c.Assert(stat.ObjectType, Equals, "Normal")
+	c.Assert(stat.StorageClass, Equals, "IA")
+	c.Assert(stat.TaggingCount, Equals, 3)
+
+	// no tags
+	stat, err = bucket.StatObject("untagged")
+	c.Assert(err, IsNil)
+	c.Assert(stat.Size, Equals, int64(5))
+	c.Assert(stat.TaggingCount, Equals, 0)
+}
+
+func (s *OssBucketMockSuite) TestCopyObjectSpecialKeys(c *C) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	sources := []string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		switch r.Method {
+		case "PUT":
+			source := r.Header.Get(HTTPHeaderOssCopySource)
+			if source == "" {
+				objects[key], _ = ioutil.ReadAll(r.Body)
+				return
+			}
+			// the source is decoded once by OSS, "+" is not the space in the header
+			sources = append(sources, source)
+			srcKey, err := url.QueryUnescape(strings.Replace(strings.TrimPrefix(source, "/mock-bucket/"), "+", "%2B", -1))
+			c.Assert(err, IsNil)
+			data, ok := objects[srcKey]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+				return
+			}
+			if r.URL.Query().Get("partNumber") != "" {
+				io.WriteString(w, "<CopyPartResult><ETag>\"part\"</ETag></CopyPartResult>")
+				return
+			}
+			objects[key] = data
+			io.WriteString(w, "<CopyObjectResult><ETag>\"copy\"</ETag></CopyObjectResult>")
+		case "GET":
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}, c)
+	defer server.Close()
+
+	keys := []string{"a b/ünïcode.txt", "a+b.txt", "100%.txt", "a%20b.txt"}
+	for i, key := range keys {
+		content := "content of " + key
+		c.Assert(bucket.PutObject(key, strings.NewReader(content)), IsNil)
+
+		destKey := "dest/" + key
+		_, err := bucket.CopyObject(key, destKey)
+		c.Assert(err, IsNil)
+		body, err := bucket.GetObject(destKey)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, content)
+
+		imur := InitiateMultipartUploadResult{Bucket: "mock-bucket", Key: destKey, UploadID: "upload"}
+		part, err := bucket.UploadPartCopy(imur, "mock-bucket", key, 0, int64(len(content)), 1)
+		c.Assert(err, IsNil)
+		c.Assert(part.ETag, Equals, "\"part\"")
+
+		// both requests send the same source encoded once
+		c.Assert(len(sources), Equals, 2*(i+1))
+		c.Assert(sources[2*i], Equals, sources[2*i+1])
+		c.Assert(strings.Contains(sources[2*i], "+"), Equals, false)
+	}
+	c.Assert(sources[0], Equals, "/mock-bucket/a%20b%2F%C3%BCn%C3%AFcode.txt")
+	c.Assert(sources[2], Equals, "/mock-bucket/a%2Bb.txt")
+	c.Assert(sources[4], Equals, "/mock-bucket/100%25.txt")
+	c.Assert(sources[6], Equals, "/mock-bucket/a%2520b.txt")
+}
+
+func (s *OssBucketMockSuite) TestSetObjectMetaResult(c *C) {
+	requests := 0
+	var header http.Header
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		header = r.Header
+		w.Header().Set(HTTPHeaderOssVersionID, "version-2")
+		w.Header().Set(HTTPHeaderOssCRC64, "12345678901234567890")
+		io.WriteString(w, "<CopyObjectResult><LastModified>2017-11-01T05:20:01.000Z</LastModified><ETag>\"copy\"</ETag></CopyObjectResult>")
+	}, c)
+	defer server.Close()
+
+	// the metadata is rewritten in place with the version id and CRC64 of the result
+	err := bucket.SetObjectMeta("object", Meta("color", "blue"))
+	c.Assert(err, IsNil)
+	c.Assert(header.Get(HTTPHeaderOssMetadataDirective), Equals, string(MetaReplace))
+	c.Assert(header.Get(HTTPHeaderOssMetaPrefix+"Color"), Equals, "blue")
+
+	res, err := bucket.CopyObject("object", "object", MetadataDirective(MetaReplace), ContentType("text/plain"))
+	c.Assert(err, IsNil)
+	c.Assert(res.ETag, Equals, "\"copy\"")
+	c.Assert(res.VersionID, Equals, "version-2")
+	c.Assert(res.CRC64, Equals, uint64(12345678901234567890))
+
+	res, err = bucket.CopyObjectTo("mock-bucket", "object", "object", MetadataDirective(MetaReplace), Expires(futureDate))
+	c.Assert(err, IsNil)
+	c.Assert(res.VersionID, Equals, "version-2")
+	c.Assert(requests, Equals, 3)
+
+	// MetaReplace without the metadata fails before the request
+	err = bucket.SetObjectMeta("object")
+	c.Assert(err, Equals, ErrMetaReplaceWithoutMeta)
+	err = bucket.SetObjectMeta("object", AcceptEncoding("url"))
+	c.Assert(err, Equals, ErrMetaReplaceWithoutMeta)
+	_, err = bucket.CopyObject("object", "object", MetadataDirective(MetaReplace))
+	c.Assert(err, Equals, ErrMetaReplaceWithoutMeta)
+	_, err = bucket.CopyObjectFrom("mock-bucket", "object", "object", MetadataDirective(MetaReplace))
+	c.Assert(err, Equals, ErrMetaReplaceWithoutMeta)
+	c.Assert(requests, Equals, 3)
+
+	// it's not checked for copying to another object or with MetaCopy
+	_, err = bucket.CopyObject("object", "dest", MetadataDirective(MetaReplace))
+	c.Assert(err, IsNil)
+	_, err = bucket.CopyObject("object", "object", MetadataDirective(MetaCopy))
+	c.Assert(err, IsNil)
+	c.Assert(requests, Equals, 5)
+}
+
+func (s *OssBucketMockSuite) TestUserAgentSuffix(c *C) {
+	agents := []string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get(HTTPHeaderUserAgent))
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}, c, UserAgent("my-app/1.0"))
+	defer server.Close()
+
+	err := bucket.PutObject("object", strings.NewReader("data"), UserAgentSuffix("origin/billing"))
+	c.Assert(err, IsNil)
+	body, err := bucket.GetObject("object")
+	c.Assert(err, IsNil)
+	body.Close()
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	body, err = bucket.GetObjectWithURL(signedURL, UserAgentSuffix("origin/report"))
+	c.Assert(err, IsNil)
+	body.Close()
+
+	c.Assert(agents, DeepEquals, []string{"my-app/1.0 origin/billing", "my-app/1.0", "my-app/1.0 origin/report"})
+}
+
+func (s *OssBucketMockSuite) TestAppendObjectVerified(c *C) {
+	var object []byte
+	appends := 0
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "POST")
+		c.Assert(r.URL.Query().Get("position"), Equals, strconv.Itoa(len(object)))
+		body, _ := ioutil.ReadAll(r.Body)
+		object = append(object, body...)
+		appends++
+
+		// the data of the third append is corrupted in the server
+		crc := mockCRC64(object)
+		if appends == 3 {
+			crc = mockCRC64(append([]byte("x"), object...))
+		}
+		w.Header().Set(HTTPHeaderOssCRC64, crc)
+		w.Header().Set(HTTPHeaderOssNextAppendPosition, strconv.Itoa(len(object)))
+		w.WriteHeader(http.StatusOK)
+	}, c)
+	defer server.Close()
+
+	handle, err := bucket.AppendObjectVerified("object", strings.NewReader("first,"))
+	c.Assert(err, IsNil)
+	c.Assert(handle.Position(), Equals, int64(6))
+
+	err = handle.Append(strings.NewReader("second,"))
+	c.Assert(err, IsNil)
+	c.Assert(handle.Position(), Equals, int64(13))
+	crc, _ := strconv.ParseUint(mockCRC64([]byte("first,second,")), 10, 64)
+	c.Assert(handle.CRC64(), Equals, crc)
+
+	err = handle.Append(strings.NewReader("third"))
+	c.Assert(err, NotNil)
+	_, ok := err.(CRCCheckError)
+	c.Assert(ok, Equals, true)
+	c.Assert(handle.Position(), Equals, int64(13))
+	c.Assert(handle.CRC64(), Equals, crc)
+
+	// the CRC is needed
+	noCRC, server2 := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Fail()
+	}, c, EnableCRC(false))
+	defer server2.Close()
+	_, err = noCRC.AppendObjectVerified("object", strings.NewReader("data"))
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketMockSuite) TestRetryPredicate(c *C) {
+	var requests int
+	var status int
+	var bodies []string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+	}, c, MaxRetries(5), RetryBackoff(time.Millisecond, time.Millisecond))
+	defer server.Close()
+
+	// the predicate retries exactly twice, then gives up
+	var attempts []int
+	var statuses []int
+	bucket.Client.Config.RetryPredicate = func(req *http.Request, resp *Response, err error, attempt int) bool {
+		c.Assert(err, IsNil)
+		c.Assert(req.Method, Equals, "HEAD")
+		attempts = append(attempts, attempt)
+		statuses = append(statuses, resp.StatusCode)
+		return attempt < 2
+	}
+	status = http.StatusTooManyRequests
+	_, err := bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 3)
+	c.Assert(attempts, DeepEquals, []int{0, 1, 2})
+	c.Assert(statuses, DeepEquals, []int{429, 429, 429})
+
+	// the predicate replaces the status codes, 503 is not retried
+	RetryPredicate(func(req *http.Request, resp *Response, err error, attempt int) bool {
+		return req.Method != "POST" && resp != nil && resp.StatusCode == http.StatusTooManyRequests
+	})(&bucket.Client)
+	requests, status = 0, http.StatusServiceUnavailable
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 1)
+
+	// the retries are still bounded by MaxRetries
+	requests, status = 0, http.StatusTooManyRequests
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 6)
+
+	// the predicate decides for POST
+	requests = 0
+	_, err = bucket.AppendObject("object", strings.NewReader("hello"), 0)
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 1)
+
+	// POST is retried with the body rewound when the predicate accepts it
+	RetryPredicate(func(req *http.Request, resp *Response, err error, attempt int) bool {
+		return req.Method == "POST" && attempt < 1
+	})(&bucket.Client)
+	requests, bodies = 0, nil
+	_, err = bucket.AppendObject("object", strings.NewReader("hello"), 0)
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 2)
+	c.Assert(bodies, DeepEquals, []string{"hello", "hello"})
+
+	// the body which can't be rewound is not retried
+	requests = 0
+	_, err = bucket.AppendObject("object", io.MultiReader(strings.NewReader("hello")), 0)
+	c.Assert(err, NotNil)
+	c.Assert(requests, Equals, 1)
+}
+
+func (s *OssBucketMockSuite) TestServiceErrorRequestID(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderOssRequestID, "5C3D9175B6FC201293AD4890")
+		switch r.URL.Path {
+		case "/mock-bucket/xml":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code><Message>denied</Message>"+
+				"<RequestId>5C3D9175B6FC201293AD4890</RequestId><HostId>mock-bucket.oss.example.com</HostId></Error>")
+		case "/mock-bucket/html":
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, "<html>bad gateway</html>")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}, c)
+	defer server.Close()
+
+	// the HEAD 404 without the body
+	_, err := bucket.GetObjectDetailedMeta("none")
+	srvErr, ok := err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(srvErr.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(srvErr.RequestID, Equals, "5C3D9175B6FC201293AD4890")
+	c.Assert(srvErr.HostID, Equals, strings.TrimPrefix(server.URL, "http://"))
+	c.Assert(srvErr.Message, Equals, "404 Not Found")
+	c.Assert(srvErr.RawMessage, Equals, "")
+	c.Assert(strings.Contains(err.Error(), "StatusCode=404"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "RequestId=5C3D9175B6FC201293AD4890"), Equals, true)
+	requestID, ok := ServiceErrorRequestID(err)
+	c.Assert(ok, Equals, true)
+	c.Assert(requestID, Equals, "5C3D9175B6FC201293AD4890")
+
+	// the XML body
+	_, err = bucket.GetObject("xml")
+	srvErr, ok = err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(srvErr.Code, Equals, "AccessDenied")
+	c.Assert(srvErr.HostID, Equals, "mock-bucket.oss.example.com")
+	c.Assert(strings.Contains(srvErr.RawMessage, "<Code>AccessDenied</Code>"), Equals, true)
+	c.Assert(strings.Contains(err.Error(), "HostId=mock-bucket.oss.example.com"), Equals, true)
+
+	// the body not from OSS is kept in RawMessage
+	_, err = bucket.GetObject("html")
+	srvErr, ok = err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(srvErr.StatusCode, Equals, http.StatusBadGateway)
+	c.Assert(srvErr.RequestID, Equals, "5C3D9175B6FC201293AD4890")
+	c.Assert(srvErr.RawMessage, Equals, "<html>bad gateway</html>")
+
+	_, ok = ServiceErrorRequestID(errors.New("oss: not a service error"))
+	c.Assert(ok, Equals, false)
+}
+
+func (s *OssBucketMockSuite) TestOptionObject(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "OPTIONS")
+		c.Assert(r.URL.Path, Equals, "/mock-bucket/dir/object")
+		if r.Header.Get(HTTPHeaderOrigin) != "http://www.example.com" ||
+			r.Header.Get(HTTPHeaderAccessControlRequestMethod) != "PUT" {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessForbidden</Code><Message>CORSResponse: This CORS request is not allowed.</Message></Error>")
+			return
+		}
+		w.Header().Set(HTTPHeaderAccessControlAllowOrigin, r.Header.Get(HTTPHeaderOrigin))
+		w.Header().Set(HTTPHeaderAccessControlAllowMethods, "GET, PUT")
+		w.Header().Set(HTTPHeaderAccessControlAllowHeaders, r.Header.Get(HTTPHeaderAccessControlRequestHeaders))
+		w.Header().Set(HTTPHeaderAccessControlMaxAge, "100")
+		w.Header().Set(HTTPHeaderOssRequestID, "req-1")
+	}, c)
+	defer server.Close()
+
+	headers, err := bucket.OptionObject("dir/object", "http://www.example.com", "put", []string{"Content-Type", "X-Oss-Meta-A"})
+	c.Assert(err, IsNil)
+	c.Assert(headers, DeepEquals, http.Header{
+		HTTPHeaderAccessControlAllowOrigin:  []string{"http://www.example.com"},
+		HTTPHeaderAccessControlAllowMethods: []string{"GET, PUT"},
+		HTTPHeaderAccessControlAllowHeaders: []string{"Content-Type,X-Oss-Meta-A"},
+		HTTPHeaderAccessControlMaxAge:       []string{"100"},
+	})
+
+	// not allowed by the rules
+	_, err = bucket.OptionObject("dir/object", "http://www.other.com", "GET", nil)
+	c.Assert(err, NotNil)
+	serr, ok := err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(serr.StatusCode, Equals, http.StatusForbidden)
+	c.Assert(serr.Code, Equals, "AccessForbidden")
+}
+
+func (s *OssBucketMockSuite) TestTrafficLimit(c *C) {
+	limits := []string{}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		limits = append(limits, r.Header.Get(HTTPHeaderOssTrafficLimit))
+		if r.Method == "PUT" {
+			io.Copy(ioutil.Discard, r.Body)
+		}
+	}, c)
+	defer server.Close()
+
+	limit := TrafficLimit(MinTrafficLimit)
+	c.Assert(bucket.PutObject("object", strings.NewReader("data"), limit), IsNil)
+	body, err := bucket.GetObject("object", limit)
+	c.Assert(err, IsNil)
+	body.Close()
+	_, err = bucket.UploadPart(InitiateMultipartUploadResult{Bucket: "mock-bucket", Key: "object", UploadID: "id"},
+		strings.NewReader("data"), 4, 1, TrafficLimit(MaxTrafficLimit))
+	c.Assert(err, IsNil)
+	c.Assert(limits, DeepEquals, []string{"819200", "819200", "838860800"})
+
+	// the limit out of the range fails before sending
+	limits = nil
+	err = bucket.PutObject("object", strings.NewReader("data"), TrafficLimit(MinTrafficLimit-1))
+	c.Assert(err, NotNil)
+	_, err = bucket.GetObject("object", TrafficLimit(MaxTrafficLimit+1))
+	c.Assert(err, NotNil)
+	_, err = bucket.SignURL("object", HTTPGet, 60, TrafficLimit(0))
+	c.Assert(err, NotNil)
+	c.Assert(len(limits), Equals, 0)
+
+	// the signed url carries the limit as the parameter
+	signedURL, headers, err := bucket.SignURLWithHeaders("object", HTTPGet, 60, TrafficLimit(MinTrafficLimit))
+	c.Assert(err, IsNil)
+	c.Assert(len(headers), Equals, 0)
+	u, err := url.Parse(signedURL)
+	c.Assert(err, IsNil)
+	c.Assert(u.Query().Get("x-oss-traffic-limit"), Equals, "819200")
+	c.Assert(bucket.ValidateSignedURL(signedURL, HTTPGet, nil), IsNil)
+}
+
+func (s *OssBucketMockSuite) TestValidateSignedURL(c *C) {
+	for _, endpoint := range []string{"oss-cn-hangzhou.aliyuncs.com", "http://127.0.0.1:8080", "https://static.example.com"} {
+		client, err := New(endpoint, "ak", "sk", UseCname(strings.HasSuffix(endpoint, "example.com")))
+		c.Assert(err, IsNil)
+		bucket, err := client.Bucket("mock-bucket")
+		c.Assert(err, IsNil)
+
+		signedURL, err := bucket.SignURL("dir/a b+c", HTTPGet, 60, VersionId("v1"))
+		c.Assert(err, IsNil)
+		c.Assert(bucket.ValidateSignedURL(signedURL, HTTPGet, nil), IsNil)
+		c.Assert(bucket.ValidateSignedURL(signedURL, HTTPPut, nil), NotNil)
+
+		// the signed headers must be sent
+		signedURL, headers, err := bucket.SignURLWithHeaders("object", HTTPPut, 60, ContentType("text/plain"),
+			Meta("owner", "tom"), CacheControl("no-cache"))
+		c.Assert(err, IsNil)
+		c.Assert(headers, DeepEquals, http.Header{
+			HTTPHeaderContentType: []string{"text/plain"},
+			"X-Oss-Meta-Owner":    []string{"tom"},
+		})
+		err = bucket.ValidateSignedURL(signedURL, HTTPPut, nil)
+		c.Assert(err, NotNil)
+		c.Assert(strings.Contains(err.Error(), "headers signed by SignURL may be missing"), Equals, true)
+		delete(headers, "X-Oss-Meta-Owner")
+		c.Assert(bucket.ValidateSignedURL(signedURL, HTTPPut, headers), NotNil)
+		headers.Set("X-Oss-Meta-Owner", "tom")
+		headers.Set(HTTPHeaderCacheControl, "max-age=1")
+		c.Assert(bucket.ValidateSignedURL(signedURL, HTTPPut, headers), IsNil)
+	}
+
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+
+	// the url of another bucket
+	other, err := client.Bucket("other-bucket")
+	c.Assert(err, IsNil)
+	c.Assert(other.ValidateSignedURL(signedURL, HTTPGet, nil), NotNil)
+
+	// expired
+	expired := regexp.MustCompile("Expires=[0-9]+").ReplaceAllString(signedURL, "Expires=1500000000")
+	err = bucket.ValidateSignedURL(expired, HTTPGet, nil)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "expired"), Equals, true)
+
+	// another AccessKeyId
+	otherClient, err := New("oss-cn-hangzhou.aliyuncs.com", "ak2", "sk")
+	c.Assert(err, IsNil)
+	otherBucket, err := otherClient.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	c.Assert(otherBucket.ValidateSignedURL(signedURL, HTTPGet, nil), NotNil)
+
+	// the key changed after signing
+	changed := strings.Replace(signedURL, "/object?", "/object2?", 1)
+	c.Assert(bucket.ValidateSignedURL(changed, HTTPGet, nil), NotNil)
+}
+
+func (s *OssBucketMockSuite) TestOptionErrors(c *C) {
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}, c)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "oss-option-errors")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "file")
+	err = ioutil.WriteFile(filePath, []byte("option errors"), 0644)
+	c.Assert(err, IsNil)
+	signedURL := server.URL + "/mock-bucket/object"
+
+	calls := map[string]func(option Option) error{
+		"PutObject": func(option Option) error {
+			return bucket.PutObject("object", strings.NewReader("value"), option)
+		},
+		"PutObjectFromFile": func(option Option) error {
+			return bucket.PutObjectFromFile("object", filePath, option)
+		},
+		"GetObject": func(option Option) error {
+			_, err := bucket.GetObject("object", option)
+			return err
+		},
+		"GetObjects": func(option Option) error {
+			_, errs := bucket.GetObjects([]string{"object"}, 1, option)
+			return errs["object"]
+		},
+		"GetObjectToFile": func(option Option) error {
+			return bucket.GetObjectToFile("object", filepath.Join(dir, "get"), option)
+		},
+		"CopyObject": func(option Option) error {
+			_, err := bucket.CopyObject("src", "dest", option)
+			return err
+		},
+		"CopyObjectTo": func(option Option) error {
+			_, err := bucket.CopyObjectTo("mock-bucket", "dest", "src", option)
+			return err
+		},
+		"CopyObjectFrom": func(option Option) error {
+			_, err := bucket.CopyObjectFrom("mock-bucket", "src", "dest", option)
+			return err
+		},
+		"AppendObject": func(option Option) error {
+			_, err := bucket.AppendObject("object", strings.NewReader("value"), 0, option)
+			return err
+		},
+		"AppendObjectVerified": func(option Option) error {
+			_, err := bucket.AppendObjectVerified("object", strings.NewReader("value"), option)
+			return err
+		},
+		"DeleteObject": func(option Option) error {
+			return bucket.DeleteObject("object", option)
+		},
+		"DeleteObjects": func(option Option) error {
+			_, err := bucket.DeleteObjects([]string{"object"}, option)
+			return err
+		},
+		"ListObjects": func(option Option) error {
+			_, err := bucket.ListObjects(option)
+			return err
+		},
+		"ListObjectsSince": func(option Option) error {
+			_, err := bucket.ListObjectsSince(time.Now(), option)
+			return err
+		},
+		"ForEachObject": func(option Option) error {
+			return bucket.ForEachObject(func(ObjectProperties) error { return nil }, option)
+		},
+		"SetObjectMeta": func(option Option) error {
+			return bucket.SetObjectMeta("object", option)
+		},
+		"GetObjectDetailedMeta": func(option Option) error {
+			_, err := bucket.GetObjectDetailedMeta("object", option)
+			return err
+		},
+		"StatObject": func(option Option) error {
+			_, err := bucket.StatObject("object", option)
+			return err
+		},
+		"SetObjectACL": func(option Option) error {
+			return bucket.SetObjectACL("object", ACLPrivate, option)
+		},
+		"PutSymlink": func(option Option) error {
+			return bucket.PutSymlink("symlink", "object", option)
+		},
+		"SignURL": func(option Option) error {
+			_, err := bucket.SignURL("object", HTTPPut, 60, option)
+			return err
+		},
+		"PutObjectWithURL": func(option Option) error {
+			return bucket.PutObjectWithURL(signedURL, strings.NewReader("value"), option)
+		},
+		"GetObjectWithURL": func(option Option) error {
+			_, err := bucket.GetObjectWithURL(signedURL, option)
+			return err
+		},
+		"DownloadFile": func(option Option) error {
+			return bucket.DownloadFile("object", filepath.Join(dir, "download"), 100*1024, option)
+		},
+		"DownloadDir": func(option Option) error {
+			_, err := bucket.DownloadDir("", filepath.Join(dir, "dir"), option)
+			return err
+		},
+		"CopyFile": func(option Option) error {
+			return bucket.CopyFile("mock-bucket", "src", "dest", 100*1024, option)
+		},
+		"InitiateMultipartUpload": func(option Option) error {
+			_, err := bucket.InitiateMultipartUpload("object", option)
+			return err
+		},
+		"ListMultipartUploads": func(option Option) error {
+			_, err := bucket.ListMultipartUploads(option)
+			return err
+		},
+		"UploadFile": func(option Option) error {
+			return bucket.UploadFile("object", filePath, 100*1024, option)
+		},
+		"Sync": func(option Option) error {
+			_, err := bucket.Sync(dir, "prefix/", option)
+			return err
+		},
+		"CreateBucket": func(option Option) error {
+			return bucket.Client.CreateBucket("mock-bucket", option)
+		},
+		"ListBuckets": func(option Option) error {
+			_, err := bucket.Client.ListBuckets(option)
+			return err
+		},
+	}
+
+	options := map[string]Option{
+		"oss: invalid traffic limit": TrafficLimit(1),
+		"oss: invalid storage class": ObjectStorageClass("Bogus"),
+	}
+	for name, call := range calls {
+		for message, option := range options {
+			err := func() (err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("panic: %v", r)
+					}
+				}()
+				return call(option)
+			}()
+			c.Assert(err, NotNil, Commentf("%s: %s", name, message))
+			c.Assert(strings.Contains(err.Error(), message), Equals, true, Commentf("%s: %s", name, err))
+		}
+	}
+}
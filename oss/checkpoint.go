@@ -0,0 +1,45 @@
+package oss
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// CheckpointStore persists the checkpoints of UploadFile, DownloadFile and CopyFile, so that the transfer could be
+// resumed. The key is the checkpoint file path set by Checkpoint, or the default one when it's empty.
+// Implement it to keep the checkpoints in a database or a cache when the local file system is not durable.
+type CheckpointStore interface {
+	// Load gets the checkpoint data saved with the key, it returns an error if it does not exist
+	Load(key string) ([]byte, error)
+	// Save saves the checkpoint data with the key, the existing data is overwritten
+	Save(key string, data []byte) error
+	// Delete deletes the checkpoint data of the key
+	Delete(key string) error
+}
+
+// FileCheckpointStore is the default CheckpointStore, which saves the checkpoint in the local file named by the key
+type FileCheckpointStore struct{}
+
+// Load reads the checkpoint file
+func (store FileCheckpointStore) Load(key string) ([]byte, error) {
+	return ioutil.ReadFile(key)
+}
+
+// Save writes the checkpoint file
+func (store FileCheckpointStore) Save(key string, data []byte) error {
+	return ioutil.WriteFile(key, data, FilePermMode)
+}
+
+// Delete removes the checkpoint file
+func (store FileCheckpointStore) Delete(key string) error {
+	return os.Remove(key)
+}
+
+// gets the checkpoint store. by default it's FileCheckpointStore.
+func getCpStore(options []Option) CheckpointStore {
+	store, err := findOption(options, checkpointStore, nil)
+	if err != nil || store == nil {
+		return FileCheckpointStore{}
+	}
+	return store.(CheckpointStore)
+}
@@ -4,8 +4,12 @@ package oss
 
 import (
 	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
@@ -49,7 +53,7 @@ func New(endpoint, accessKeyID, accessKeySecret string, options ...ClientOption)
 	url.Init(config.Endpoint, config.IsCname, config.IsUseProxy)
 
 	// http connect
-	conn := &Conn{config: config, url: url}
+	conn := &Conn{config: config, url: url, buckets: newBucketInfoCache()}
 
 	// oss client
 	client := &Client{
@@ -83,23 +87,58 @@ func (client Client) Bucket(bucketName string) (*Bucket, error) {
 	}, nil
 }
 
+//
+// BucketWithEndpoint Gets the bucket instance whose requests are sent to the endpoint, such as the bucket in another region.
+//
+// The bucket has the same configuration as the client except the endpoint, and it's signed by the same credentials.
+// The HTTP client is shared, so are the connection pool and the bandwidth limits. The configuration set on the client
+// later is not applied to the bucket, except the credentials such as SecurityToken.
+//
+// bucketName bucket name.
+// endpoint   the OSS datacenter endpoint of the bucket such as http://oss-cn-beijing.aliyuncs.com.
+//
+// Bucket     the bucket object, when error is nil.
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) BucketWithEndpoint(bucketName, endpoint string) (*Bucket, error) {
+	if strings.TrimSpace(endpoint) == "" {
+		return nil, errors.New("oss: the endpoint of the bucket is empty")
+	}
+
+	config := *client.Config
+	config.Endpoint = endpoint
+	url := &urlMaker{}
+	url.Init(config.Endpoint, config.IsCname, config.IsUseProxy)
+	conn := &Conn{config: &config, url: url, client: client.Conn.client, buckets: client.Conn.buckets}
+
+	return &Bucket{
+		Client:     Client{Config: &config, Conn: conn},
+		BucketName: bucketName,
+	}, nil
+}
+
 //
 // CreateBucket Creates a bucket。
 //
 // bucketName bucket name, it's globably unique and immutable. The bucket name can only consists of lowercase letters, numbers and dash ('-').
 // It must start with lowercase letter or number and the length can only be between 3 to 255.
 // options  Options for creating the bucket, with optional ACL. The ACL could be ACLPrivate, ACLPublicRead, and ACLPublicReadWrite. By default it's ACLPrivate.
-// It could also be specified with StorageClass option, which supports StorageStandard, StorageIA(infrequent access), StorageArchive.
+// It could also be specified with StorageClass option, which supports StorageStandard, StorageIA(infrequent access), StorageArchive, StorageColdArchive.
 //
 // error It's nil if no errors; otherwise it's the error object.
 //
 func (client Client) CreateBucket(bucketName string, options ...Option) error {
 	headers := make(map[string]string)
-	handleOptions(headers, options)
+	if err := handleOptions(headers, options); err != nil {
+		return err
+	}
 
 	buffer := new(bytes.Buffer)
 
-	isOptSet, val, _ := isOptionSet(options, storageClass)
+	isOptSet, val, err := isOptionSet(options, storageClass)
+	if err != nil {
+		return err
+	}
 	if isOptSet {
 		cbConfig := createBucketConfiguration{StorageClass: val.(StorageClassType)}
 		bs, err := xml.Marshal(cbConfig)
@@ -146,29 +185,65 @@ func (client Client) ListBuckets(options ...Option) (ListBucketsResult, error) {
 		return out, err
 	}
 	defer resp.Body.Close()
+	captureResponseHeaders(options, resp)
 
 	err = xmlUnmarshal(resp.Body, &out)
 	return out, err
 }
 
+//
+// SignURL signs a service-scoped URL, such as the URL of ListBuckets for the browser.
+//
+// The canonical resource of the service-scoped request is "/", so the URL is valid for the requests without bucket.
+// Use Bucket.SignURL for the bucket or object requests.
+//
+// method        HTTP method, for ListBuckets it's HTTPGet.
+// expiredInSec  the URL's expiration time in seconds.
+// options       the options such as Prefix, Marker and MaxKeys of ListBuckets.
+//
+// string  the signed URL, it's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (client Client) SignURL(method HTTPMethod, expiredInSec int64, options ...Option) (string, error) {
+	if expiredInSec < 0 {
+		return "", fmt.Errorf("invalid expires: %d, expires must bigger than 0", expiredInSec)
+	}
+	expiration := time.Now().Unix() + expiredInSec
+
+	params, err := getRawParams(options)
+	if err != nil {
+		return "", err
+	}
+
+	headers := make(map[string]string)
+	err = handleOptions(headers, options)
+	if err != nil {
+		return "", err
+	}
+
+	return client.Conn.signURL(method, "", "", expiration, params, headers)
+}
+
 //
 // IsBucketExist Checks if the bucket exists
 //
+// The bucket is checked by GetBucketInfo, so the answer does not depend on the other buckets listed. The bucket exists
+// if the access is denied, which means it's owned by another account or the caller has no permission to get its info.
+//
 // bucketName the bucket name
 //
 // bool  true if it exists, and it's only valid when error is nil.
 // error It's nil if no errors; otherwise it's the error object.
 //
 func (client Client) IsBucketExist(bucketName string) (bool, error) {
-	listRes, err := client.ListBuckets(Prefix(bucketName), MaxKeys(1))
-	if err != nil {
-		return false, err
-	}
-
-	if len(listRes.Buckets) == 1 && listRes.Buckets[0].Name == bucketName {
+	_, err := client.GetBucketInfo(bucketName)
+	if err == nil || IsAccessDenied(err) {
 		return true, nil
 	}
-	return false, nil
+	if IsNoSuchBucket(err) {
+		return false, nil
+	}
+	return false, err
 }
 
 //
@@ -263,11 +338,17 @@ func (client Client) GetBucketACL(bucketName string) (GetBucketACLResult, error)
 //
 // bucketName bucket name.
 // rules lifecycle rules. There're two kind of rules: absolute time expiration and relative time expiration in day/month/year and days respectively.
-// Check out sample/bucket_lifecycle.go for more detail.
+// Check out sample/bucket_lifecycle.go for more detail. The rules are validated before sending, the duplicate ids, the
+// same action set twice on the same prefix, both the days and the date set for an action, and the transitions not
+// getting colder along the time fail locally.
 //
 // error It's nil if no errors; otherwise it's the error object.
 //
 func (client Client) SetBucketLifecycle(bucketName string, rules []LifecycleRule) error {
+	if err := validateLifecycleRules(rules); err != nil {
+		return err
+	}
+
 	lxml := lifecycleXML{Rules: convLifecycleRule(rules)}
 	bs, err := xml.Marshal(lxml)
 	if err != nil {
@@ -344,12 +425,23 @@ func (client Client) GetBucketLifecycle(bucketName string) (GetBucketLifecycleRe
 // referers referrer whitelist. A bucket could have a referrer list and each referrer supports one * and multiple ? as wildcard.
 // The sample could could be found in sample/bucket_referer.go
 // allowEmptyReferer  flag of allowing empty referrer. By default it's true.
+// options  RefererTruncatePath and RefererAllowHTTPSOnly set the flags of the referrer check, by default they're false.
 //
 // error It's nil if no errors; otherwise it's the error object.
 //
-func (client Client) SetBucketReferer(bucketName string, referers []string, allowEmptyReferer bool) error {
+func (client Client) SetBucketReferer(bucketName string, referers []string, allowEmptyReferer bool, options ...Option) error {
 	rxml := RefererXML{}
 	rxml.AllowEmptyReferer = allowEmptyReferer
+	isTruncatePath, err := findOption(options, refererTruncate, false)
+	if err != nil {
+		return err
+	}
+	rxml.TruncatePath = isTruncatePath.(bool)
+	isHTTPSOnly, err := findOption(options, refererHTTPSOnly, false)
+	if err != nil {
+		return err
+	}
+	rxml.AllowHTTPSOnly = isHTTPSOnly.(bool)
 	if referers == nil {
 		rxml.RefererList = append(rxml.RefererList, "")
 	} else {
@@ -502,9 +594,9 @@ func (client Client) GetBucketLogging(bucketName string) (GetBucketLoggingResult
 // error  It's nil if no errors; otherwise it's the error object.
 //
 func (client Client) SetBucketWebsite(bucketName, indexDocument, errorDocument string) error {
-	wxml := WebsiteXML{}
-	wxml.IndexDocument.Suffix = indexDocument
-	wxml.ErrorDocument.Key = errorDocument
+	wxml := websiteXML{}
+	wxml.IndexDocument = &IndexDocument{Suffix: indexDocument}
+	wxml.ErrorDocument = &ErrorDocument{Key: errorDocument}
 
 	bs, err := xml.Marshal(wxml)
 	if err != nil {
@@ -527,6 +619,55 @@ func (client Client) SetBucketWebsite(bucketName, indexDocument, errorDocument s
 	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
 }
 
+//
+// SetBucketWebsiteDetail Sets the bucket's static website with the routing rules or the redirect of all the requests.
+//
+// The empty index and error page are not sent. The routing rules with RuleNumber 0 are numbered from 1 in order.
+//
+// bucketName  The bucket name to enable static web site.
+// wxml        the website configuration, such as the index page, the error page and the routing rules.
+//
+// error  It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) SetBucketWebsiteDetail(bucketName string, wxml WebsiteXML) error {
+	out := websiteXML{RedirectAllRequestsTo: wxml.RedirectAllRequestsTo}
+	if wxml.IndexDocument.Suffix != "" {
+		out.IndexDocument = &IndexDocument{Suffix: wxml.IndexDocument.Suffix}
+	}
+	if wxml.ErrorDocument.Key != "" {
+		out.ErrorDocument = &ErrorDocument{Key: wxml.ErrorDocument.Key}
+	}
+	if len(wxml.RoutingRules) > 0 {
+		out.RoutingRules = &websiteRoutingRules{}
+		for i, rule := range wxml.RoutingRules {
+			if rule.RuleNumber == 0 {
+				rule.RuleNumber = i + 1
+			}
+			out.RoutingRules.Rules = append(out.RoutingRules.Rules, rule)
+		}
+	}
+
+	bs, err := xml.Marshal(out)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	contentType := http.DetectContentType(buffer.Bytes())
+	headers := make(map[string]string)
+	headers[HTTPHeaderContentType] = contentType
+
+	params := map[string]interface{}{}
+	params["website"] = nil
+	resp, err := client.do("PUT", bucketName, params, headers, buffer)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
 //
 // DeleteBucketWebsite deletes the bucket's static web site settings.
 //
@@ -673,6 +814,364 @@ func (client Client) GetBucketInfo(bucketName string) (GetBucketInfoResult, erro
 	return out, err
 }
 
+//
+// GetBucketStat Gets the bucket's storage size and object counts. They're not updated in real time.
+//
+// The stat is calculated by OSS periodically, so the recent uploads and deletions may be reflected after a delay.
+// The values are returned as OSS reports them, they fit the dashboards and the quota checks rather than the exact
+// accounting, and they are much cheaper than counting the objects by ListObjects.
+//
+// bucketName  bucket name
+// GetBucketStatResult  The result object upon successful request. It's only valid when error is nil.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) GetBucketStat(bucketName string) (GetBucketStatResult, error) {
+	var out GetBucketStatResult
+	err := client.getBucketStat(bucketName, &out)
+	return out, err
+}
+
+//
+// GetBucketStorageSize Gets the bucket's storage size only, checks out GetBucketStat for the details.
+//
+// bucketName  bucket name
+//
+// int64  the storage size in byte. It's only valid when error is nil.
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) GetBucketStorageSize(bucketName string) (int64, error) {
+	var out struct {
+		XMLName xml.Name `xml:"BucketStat"`
+		Storage int64    `xml:"Storage"`
+	}
+	err := client.getBucketStat(bucketName, &out)
+	return out.Storage, err
+}
+
+//
+// GetBucketObjectCount Gets the count of the bucket's objects only, checks out GetBucketStat for the details.
+//
+// bucketName  bucket name
+//
+// int64  the count of the objects. It's only valid when error is nil.
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) GetBucketObjectCount(bucketName string) (int64, error) {
+	var out struct {
+		XMLName     xml.Name `xml:"BucketStat"`
+		ObjectCount int64    `xml:"ObjectCount"`
+	}
+	err := client.getBucketStat(bucketName, &out)
+	return out.ObjectCount, err
+}
+
+// getBucketStat gets the ?stat of the bucket into out, which has the fields needed only
+func (client Client) getBucketStat(bucketName string, out interface{}) error {
+	params := map[string]interface{}{}
+	params["stat"] = nil
+	resp, err := client.do("GET", bucketName, params, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return xmlUnmarshal(resp.Body, out)
+}
+
+//
+// SetBucketVersioning Sets the bucket's versioning status.
+//
+// Once the versioning is enabled it can only be suspended, but not disabled.
+//
+// bucketName  bucket name
+// status      the versioning status, VersionEnabled or VersionSuspended.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) SetBucketVersioning(bucketName string, status VersioningStatus) error {
+	if status != VersionEnabled && status != VersionSuspended {
+		return fmt.Errorf("invalid versioning status: %s", status)
+	}
+
+	vxml := VersioningConfig{Status: string(status)}
+	bs, err := xml.Marshal(vxml)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	contentType := http.DetectContentType(buffer.Bytes())
+	headers := map[string]string{}
+	headers[HTTPHeaderContentType] = contentType
+
+	params := map[string]interface{}{}
+	params["versioning"] = nil
+	resp, err := client.do("PUT", bucketName, params, headers, buffer)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetBucketVersioning Gets the bucket's versioning status.
+//
+// bucketName  bucket name
+// GetBucketVersioningResult  The result object upon successful request. Its Status is empty if the versioning was never enabled.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) GetBucketVersioning(bucketName string) (GetBucketVersioningResult, error) {
+	var out GetBucketVersioningResult
+	params := map[string]interface{}{}
+	params["versioning"] = nil
+	resp, err := client.do("GET", bucketName, params, nil, nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	return out, err
+}
+
+//
+// SetBucketEncryption Sets the bucket's default server side encryption, OSS encrypts the new objects uploaded without the
+// ServerSideEncryption option by the rule. The existing objects are not changed.
+//
+// bucketName  bucket name
+// rule        the encryption rule, its SSEAlgorithm is SSEAES256 or SSEKMS. The KMSMasterKeyID is only valid for SSEKMS.
+//             The related sample code is in sample/bucket_encryption.go.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) SetBucketEncryption(bucketName string, rule ServerEncryptionRule) error {
+	if rule.SSEAlgorithm != SSEAES256 && rule.SSEAlgorithm != SSEKMS {
+		return fmt.Errorf("invalid server side encryption algorithm: %s", rule.SSEAlgorithm)
+	}
+	if rule.SSEAlgorithm != SSEKMS && rule.KMSMasterKeyID != "" {
+		return fmt.Errorf("the KMS master key id is only valid for the %s algorithm", SSEKMS)
+	}
+
+	exml := serverEncryptionXML{}
+	exml.SSEDefault.SSEAlgorithm = rule.SSEAlgorithm
+	exml.SSEDefault.KMSMasterKeyID = rule.KMSMasterKeyID
+	bs, err := xml.Marshal(exml)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	contentType := http.DetectContentType(buffer.Bytes())
+	headers := map[string]string{}
+	headers[HTTPHeaderContentType] = contentType
+
+	params := map[string]interface{}{}
+	params["encryption"] = nil
+	client.Conn.buckets.setEncryption(bucketName, nil)
+	resp, err := client.do("PUT", bucketName, params, headers, buffer)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetBucketEncryption Gets the bucket's default server side encryption.
+//
+// The rule is cached by the client, then the ServerSideEncryption option of PutObject and InitiateMultipartUpload
+// conflicting with it is warned in the log when the logging is enabled.
+//
+// bucketName  bucket name
+// GetBucketEncryptionResult  The result object upon successful request. It's only valid when error is nil.
+//
+// error It's nil if no errors; otherwise it's the error object. It's the NoSuchServerSideEncryptionRule
+// ServiceError if the bucket has no encryption rule.
+//
+func (client Client) GetBucketEncryption(bucketName string) (GetBucketEncryptionResult, error) {
+	var out GetBucketEncryptionResult
+	params := map[string]interface{}{}
+	params["encryption"] = nil
+	resp, err := client.do("GET", bucketName, params, nil, nil)
+	if err != nil {
+		if code, _ := ServiceErrorCode(err); code == "NoSuchServerSideEncryptionRule" {
+			client.Conn.buckets.setEncryption(bucketName, &ServerEncryptionRule{})
+		}
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	var exml serverEncryptionXML
+	if err = xmlUnmarshal(resp.Body, &exml); err != nil {
+		return out, err
+	}
+	out.SSEAlgorithm = exml.SSEDefault.SSEAlgorithm
+	out.KMSMasterKeyID = exml.SSEDefault.KMSMasterKeyID
+	rule := ServerEncryptionRule(out)
+	client.Conn.buckets.setEncryption(bucketName, &rule)
+	return out, nil
+}
+
+//
+// DeleteBucketEncryption Deletes the bucket's default server side encryption.
+//
+// bucketName  bucket name
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) DeleteBucketEncryption(bucketName string) error {
+	params := map[string]interface{}{}
+	params["encryption"] = nil
+	client.Conn.buckets.setEncryption(bucketName, nil)
+	resp, err := client.do("DELETE", bucketName, params, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
+}
+
+//
+// SetBucketPolicy Sets the bucket's access policy, the existing policy is overwritten.
+//
+// bucketName  bucket name
+// policy      the policy in JSON, it's sent as is.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) SetBucketPolicy(bucketName string, policy string) error {
+	var v interface{}
+	if err := json.Unmarshal([]byte(policy), &v); err != nil {
+		return fmt.Errorf("oss: invalid bucket policy JSON: %v", err)
+	}
+
+	headers := map[string]string{}
+	headers[HTTPHeaderContentType] = "application/json"
+
+	params := map[string]interface{}{}
+	params["policy"] = nil
+	resp, err := client.do("PUT", bucketName, params, headers, strings.NewReader(policy))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetBucketPolicy Gets the bucket's access policy.
+//
+// bucketName  bucket name
+//
+// string  the policy in JSON as it's set. It's only valid when error is nil.
+// error It's nil if no errors; otherwise it's the error object. It's the NoSuchBucketPolicy ServiceError if the
+// bucket has no policy, which could be checked by IsNoSuchBucketPolicy.
+//
+func (client Client) GetBucketPolicy(bucketName string) (string, error) {
+	params := map[string]interface{}{}
+	params["policy"] = nil
+	resp, err := client.do("GET", bucketName, params, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", ServiceError{
+			Code:       "NoSuchBucketPolicy",
+			Message:    "The bucket policy does not exist.",
+			RequestID:  resp.Headers.Get(HTTPHeaderOssRequestID),
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return string(body), nil
+}
+
+//
+// DeleteBucketPolicy Deletes the bucket's access policy.
+//
+// bucketName  bucket name
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) DeleteBucketPolicy(bucketName string) error {
+	params := map[string]interface{}{}
+	params["policy"] = nil
+	resp, err := client.do("DELETE", bucketName, params, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusNoContent})
+}
+
+//
+// SetBucketRequestPayment Sets the payer of the bucket's requests and traffic.
+//
+// When the payer is Requester, the others than the bucket owner access the objects with the RequestPayer option.
+//
+// bucketName  bucket name
+// payer       the configuration, its Payer is Requester or BucketOwner.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) SetBucketRequestPayment(bucketName string, payer RequestPaymentConfiguration) error {
+	if payer.Payer != string(Requester) && payer.Payer != string(BucketOwner) {
+		return fmt.Errorf("invalid request payer: %s", payer.Payer)
+	}
+
+	bs, err := xml.Marshal(payer)
+	if err != nil {
+		return err
+	}
+	buffer := new(bytes.Buffer)
+	buffer.Write(bs)
+
+	contentType := http.DetectContentType(buffer.Bytes())
+	headers := map[string]string{}
+	headers[HTTPHeaderContentType] = contentType
+
+	params := map[string]interface{}{}
+	params["requestPayment"] = nil
+	resp, err := client.do("PUT", bucketName, params, headers, buffer)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkRespCode(resp.StatusCode, []int{http.StatusOK})
+}
+
+//
+// GetBucketRequestPayment Gets the payer of the bucket's requests and traffic.
+//
+// bucketName  bucket name
+// GetBucketRequestPaymentResult  The result object upon successful request. It's only valid when error is nil.
+//
+// error It's nil if no errors; otherwise it's the error object.
+//
+func (client Client) GetBucketRequestPayment(bucketName string) (GetBucketRequestPaymentResult, error) {
+	var out GetBucketRequestPaymentResult
+	params := map[string]interface{}{}
+	params["requestPayment"] = nil
+	resp, err := client.do("GET", bucketName, params, nil, nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	err = xmlUnmarshal(resp.Body, &out)
+	return out, err
+}
+
 //
 // UseCname Sets the flag of using CName. By default it's false.
 //
@@ -704,6 +1203,30 @@ func Timeout(connectTimeoutSec, readWriteTimeout int64) ClientOption {
 	}
 }
 
+//
+// TransportSettings Sets the connection pool of the transport.
+//
+// By default the transport keeps 100 idle connections of each host for 50s, rather than 2 of http.DefaultTransport,
+// so that the client shared by many goroutines reuses the connections to OSS. The connections more than
+// MaxIdleConnsPerHost are still opened for the concurrent requests, while they're closed instead of kept after
+// the requests. It's not used when HTTPClient is set.
+//
+// settings  the pool settings, the zero fields keep the defaults.
+//
+func TransportSettings(settings TransportConfig) ClientOption {
+	return func(client *Client) {
+		if settings.MaxIdleConns > 0 {
+			client.Config.Transport.MaxIdleConns = settings.MaxIdleConns
+		}
+		if settings.MaxIdleConnsPerHost > 0 {
+			client.Config.Transport.MaxIdleConnsPerHost = settings.MaxIdleConnsPerHost
+		}
+		if settings.IdleConnTimeout > 0 {
+			client.Config.Transport.IdleConnTimeout = settings.IdleConnTimeout
+		}
+	}
+}
+
 //
 // SecurityToken Sets the temporary user's SecurityToken。
 //
@@ -715,6 +1238,22 @@ func SecurityToken(token string) ClientOption {
 	}
 }
 
+//
+// SetCredentialsProvider Sets the provider of the credentials, which is called for each request to sign it, so that
+// the temporary credentials refreshed by the provider are used, such as the STS token of the ECS RAM role.
+// The accessKeyID and the accessKeySecret of New and SecurityToken are not used then.
+//
+// provider the credentials provider, nil restores the default one providing the credentials of New and SecurityToken.
+//
+func SetCredentialsProvider(provider CredentialsProvider) ClientOption {
+	return func(client *Client) {
+		if provider == nil {
+			provider = &defaultCredentialsProvider{config: client.Config}
+		}
+		client.Config.CredentialsProvider = provider
+	}
+}
+
 //
 // EnableMD5 Enable MD5 validation
 //
@@ -748,6 +1287,33 @@ func EnableCRC(isEnableCRC bool) ClientOption {
 	}
 }
 
+//
+// MinPartSizeOverride Overrides the min part size accepted by UploadFile and CopyFile. The default is MinPartSize.
+//
+// It's for the OSS compatible gateways or the test backends which accept smaller parts, OSS itself rejects them.
+//
+// minPartSize the min part size in byte, 0 means MinPartSize is used.
+//
+func MinPartSizeOverride(minPartSize int64) ClientOption {
+	return func(client *Client) {
+		client.Config.MinPartSizeOverride = minPartSize
+	}
+}
+
+//
+// SignHash Sets the HMAC hash function of the signature. The default is SignHashSHA1.
+//
+// The string to sign is the same for both hashes. With SignHashSHA256 the Authorization header starts with
+// "OSS-HMAC-SHA256 " instead of "OSS ", it's for the OSS compatible gateways, OSS itself only accepts SignHashSHA1.
+//
+// signHash SignHashSHA1 or SignHashSHA256, the other values are taken as SignHashSHA1.
+//
+func SignHash(signHash SignHashType) ClientOption {
+	return func(client *Client) {
+		client.Config.SignHash = signHash
+	}
+}
+
 //
 // UserAgent Specifies UserAgent. The default is aliyun-sdk-go/1.2.0 (windows/-/amd64;go1.5.2).
 //
@@ -790,6 +1356,175 @@ func AuthProxy(proxyHost, proxyUser, proxyPassword string) ClientOption {
 	}
 }
 
+//
+// HTTPClient Sets the custom HTTP client, such as with the custom TLS config, the shared transport or the HTTP tracing.
+//
+// The requests are sent by the client as it is, so the Timeout, Proxy and AuthProxy options do not take effect on
+// the transport, which should be configured in the client instead.
+//
+// httpClient the HTTP client to send the requests.
+//
+func HTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *Client) {
+		client.Config.HTTPClient = httpClient
+	}
+}
+
+//
+// MaxRetries Sets the max retries of the idempotent requests.
+//
+// The GET, HEAD and PUT requests are retried with the exponential backoff when OSS returns 500, 502 or 503, or the
+// connection is reset. The request with the body which is not an io.Seeker, such as UploadPart, is not retried. The
+// conditional PUT, such as PutObject with ForbidOverwrite(true) or IfNoneMatch("*"), is not retried either, because
+// the first attempt may have created the object and the retry would fail with ErrObjectAlreadyExists.
+// When the 503 response has the Retry-After header, the request is retried after its delay instead of the backoff,
+// which is bounded by MaxRetryBackoff. The request still throttled by OSS after the retries fails with ThrottleError.
+// RetryPredicate replaces the conditions and the methods.
+//
+// n the max retries, 0 means no retry.
+//
+func MaxRetries(n int) ClientOption {
+	return func(client *Client) {
+		client.Config.MaxRetries = n
+	}
+}
+
+//
+// RetryPredicate Sets the function deciding whether to retry, instead of retrying GET, HEAD and PUT on 500, 502, 503
+// and the connection reset. It's consulted for the request of any method whose body is absent or rewindable, so it
+// must not retry the request which isn't idempotent, such as POST. The retries are still bounded by MaxRetries.
+//
+// predicate the function, it returns true to retry the request after the backoff.
+//
+func RetryPredicate(predicate RetryFunc) ClientOption {
+	return func(client *Client) {
+		client.Config.RetryPredicate = predicate
+	}
+}
+
+//
+// SetLogger Sets the logger of the requests, the signing and the retries. The secrets such as the security token
+// are masked in the logs.
+//
+// logger the logger, nil means logging nothing.
+//
+func SetLogger(logger Logger) ClientOption {
+	return func(client *Client) {
+		if logger == nil {
+			logger = nopLogger{}
+		}
+		client.Config.Logger = logger
+	}
+}
+
+//
+// EnableLog Sets the logger writing the messages at the level or above to the stderr.
+//
+// level the log level, such as LogDebug to log the requests, the responses and the strings to sign. LogOff logs nothing.
+//
+func EnableLog(level LogLevel) ClientOption {
+	return func(client *Client) {
+		if level <= LogOff {
+			client.Config.Logger = nopLogger{}
+			return
+		}
+		client.Config.Logger = newLevelLogger(level)
+	}
+}
+
+//
+// AddRequestInterceptor Adds the interceptors called with each HTTP request signed before it's sent, such as to
+// start the tracing span. The interceptors are called in the order they're added.
+//
+// interceptors the request interceptors.
+//
+func AddRequestInterceptor(interceptors ...RequestInterceptor) ClientOption {
+	return func(client *Client) {
+		client.Config.RequestInterceptors = append(client.Config.RequestInterceptors, interceptors...)
+	}
+}
+
+//
+// AddResponseInterceptor Adds the interceptors called after each HTTP request with the response or the error and the
+// elapsed time, such as to record the metrics. The interceptors are called in the order they're added.
+//
+// interceptors the response interceptors.
+//
+func AddResponseInterceptor(interceptors ...ResponseInterceptor) ClientOption {
+	return func(client *Client) {
+		client.Config.ResponseInterceptors = append(client.Config.ResponseInterceptors, interceptors...)
+	}
+}
+
+//
+// OnServerTime Sets the callback called with the server time of each response, including the error responses, such
+// as to monitor the clock skew. The responses without the valid Date header are skipped.
+//
+// callback the callback with the server time and the local time, nil removes it.
+//
+func OnServerTime(callback ServerTimeFunc) ClientOption {
+	return func(client *Client) {
+		client.Config.ServerTimeCallback = callback
+	}
+}
+
+//
+// UploadBandwidthLimit Sets the max bandwidth of the uploads.
+//
+// The limit is shared by all the requests of the client, including the concurrent parts of UploadFile, so the
+// total bandwidth of the request bodies stays under it. The progress events reflect the limited rate.
+//
+// bytesPerSec the max bytes per second, 0 means no limit.
+//
+func UploadBandwidthLimit(bytesPerSec int64) ClientOption {
+	return func(client *Client) {
+		client.Config.UploadBandwidthLimit = bytesPerSec
+	}
+}
+
+//
+// DownloadBandwidthLimit Sets the max bandwidth of the downloads.
+//
+// The limit is shared by all the requests of the client, including the concurrent parts of DownloadFile, so the
+// total bandwidth of the response bodies stays under it. The progress events reflect the limited rate.
+//
+// bytesPerSec the max bytes per second, 0 means no limit.
+//
+func DownloadBandwidthLimit(bytesPerSec int64) ClientOption {
+	return func(client *Client) {
+		client.Config.DownloadBandwidthLimit = bytesPerSec
+	}
+}
+
+//
+// RetryBackoff Sets the backoff between the retries.
+//
+// base the backoff before the first retry, it's doubled for each retry.
+// max the max backoff.
+//
+func RetryBackoff(base, max time.Duration) ClientOption {
+	return func(client *Client) {
+		client.Config.RetryBackoff = base
+		client.Config.MaxRetryBackoff = max
+	}
+}
+
+//
+// IOBufferSize Sets the buffer size for copying the bodies.
+//
+// The buffer is only used to copy the response bodies to the files by GetObjectToFile, DownloadFile and
+// GetObjectToFileWithURL, and to spool the request bodies to the temporary file when their MD5 is calculated. The
+// bigger buffer takes fewer read and write calls for these copies. The uploads, including the parts of UploadFile and
+// UploadStream, are not affected, their bodies are written to the connection by http.Transport with its own buffer.
+//
+// n the buffer size in bytes, 0 or negative means io.Copy's default 32KB.
+//
+func IOBufferSize(n int) ClientOption {
+	return func(client *Client) {
+		client.Config.IOBufferSize = n
+	}
+}
+
 // Private
 func (client Client) do(method, bucketName string, params map[string]interface{},
 	headers map[string]string, data io.Reader) (*Response, error) {
@@ -1281,6 +1281,38 @@ func (s *OssClientSuite) TestGetBucketInfo(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// TestSetBucketVersioning
+func (s *OssClientSuite) TestSetBucketVersioning(c *C) {
+	var bucketNameTest = bucketNamePrefix + "tsbv"
+
+	client, err := New(endpoint, accessID, accessKey)
+	c.Assert(err, IsNil)
+
+	err = client.CreateBucket(bucketNameTest)
+	c.Assert(err, IsNil)
+
+	res, err := client.GetBucketVersioning(bucketNameTest)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, "")
+
+	err = client.SetBucketVersioning(bucketNameTest, VersionEnabled)
+	c.Assert(err, IsNil)
+
+	res, err = client.GetBucketVersioning(bucketNameTest)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, string(VersionEnabled))
+
+	err = client.SetBucketVersioning(bucketNameTest, VersionSuspended)
+	c.Assert(err, IsNil)
+
+	res, err = client.GetBucketVersioning(bucketNameTest)
+	c.Assert(err, IsNil)
+	c.Assert(res.Status, Equals, string(VersionSuspended))
+
+	err = client.DeleteBucket(bucketNameTest)
+	c.Assert(err, IsNil)
+}
+
 // TestGetBucketInfoNegative
 func (s *OssClientSuite) TestGetBucketInfoNegative(c *C) {
 	var bucketNameTest = bucketNamePrefix + "tgbig"
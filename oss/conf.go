@@ -1,6 +1,7 @@
 package oss
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -12,26 +13,118 @@ type HTTPTimeout struct {
 	LongTimeout      time.Duration
 }
 
+// RequestInterceptor is called with each HTTP request signed, right before it's sent. It must not read the body.
+type RequestInterceptor func(req *http.Request)
+
+// ResponseInterceptor is called after each HTTP request with its response, which is nil when err is not nil.
+// elapsed is the time from sending the request to receiving the response headers, the body is not read yet and it
+// must not be read or closed by the interceptor. The retries of a request are intercepted one by one.
+type ResponseInterceptor func(req *http.Request, resp *http.Response, err error, elapsed time.Duration)
+
+// ServerTimeFunc is called with the server time in the Date header of each response and the local time receiving
+// it, so that the clock skew is detected before the requests fail with RequestTimeTooSkewed. The Date header is in
+// seconds, so the skew within a second is not meaningful.
+type ServerTimeFunc func(server, local time.Time)
+
+// CredentialsProvider provides the credentials to sign the requests. It's called for each request, so the provider
+// could refresh the temporary credentials such as the STS token before they expire. It must be safe for the
+// concurrent use.
+type CredentialsProvider interface {
+	GetCredentials() (accessKeyID, accessKeySecret, securityToken string)
+}
+
+// defaultCredentialsProvider provides the static credentials of the config, which are set by New and SecurityToken
+type defaultCredentialsProvider struct {
+	config *Config
+}
+
+// GetCredentials gets the credentials of the config
+func (provider *defaultCredentialsProvider) GetCredentials() (string, string, string) {
+	return provider.config.AccessKeyID, provider.config.AccessKeySecret, provider.config.SecurityToken
+}
+
+// RetryFunc decides whether to retry the request. req is the request sent, whose method tells if it's idempotent.
+// resp is the response without the body and err is nil when OSS responds, otherwise resp is nil and err is the error
+// of sending the request. attempt is 0 for the first request.
+type RetryFunc func(req *http.Request, resp *Response, err error, attempt int) bool
+
+// TransportConfig the connection pool of the transport built by the SDK
+type TransportConfig struct {
+	MaxIdleConns        int           // the max idle connections of all the hosts, by default it's 100.
+	MaxIdleConnsPerHost int           // the max idle connections of each host, by default it's 100.
+	IdleConnTimeout     time.Duration // the time an idle connection is kept, by default it's 50s.
+}
+
 // Config oss configure
 type Config struct {
-	Endpoint        string      // oss endpoint
-	AccessKeyID     string      // accessId
-	AccessKeySecret string      // accessKey
-	RetryTimes      uint        // retry count by default it's 5.
-	UserAgent       string      // SDK name/version/system information
-	IsDebug         bool        // enable debug mode. Default is false.
-	Timeout         uint        // timeout in seconds. By default it's 60.
-	SecurityToken   string      // STS Token
-	IsCname         bool        // if cname is in the endpoint.
-	HTTPTimeout     HTTPTimeout // HTTP timeout
-	IsUseProxy      bool        // flag of using proxy.
-	ProxyHost       string      // flag of using proxy host.
-	IsAuthProxy     bool        // flag of needs authentication
-	ProxyUser       string      // proxy user
-	ProxyPassword   string      // proxy password
-	IsEnableMD5     bool        // flag of enabling MD5 for upload
-	MD5Threshold    int64       // Memory footprint threshold for each MD5 computation (16MB is the default), in byte. When the data is more than that, temp file is used.
-	IsEnableCRC     bool        // flag of enabling CRC for upload.
+	Endpoint            string       // oss endpoint
+	AccessKeyID         string       // accessId
+	AccessKeySecret     string       // accessKey
+	RetryTimes          uint         // retry count by default it's 5.
+	UserAgent           string       // SDK name/version/system information
+	IsDebug             bool         // enable debug mode. Default is false.
+	Timeout             uint         // timeout in seconds. By default it's 60.
+	SecurityToken       string       // STS Token
+	IsCname             bool         // if cname is in the endpoint.
+	HTTPTimeout         HTTPTimeout  // HTTP timeout
+	IsUseProxy          bool         // flag of using proxy.
+	ProxyHost           string       // flag of using proxy host.
+	IsAuthProxy         bool         // flag of needs authentication
+	ProxyUser           string       // proxy user
+	ProxyPassword       string       // proxy password
+	IsEnableMD5         bool         // flag of enabling MD5 for upload
+	MD5Threshold        int64        // Memory footprint threshold for each MD5 computation (16MB is the default), in byte. When the data is more than that, temp file is used.
+	IsEnableCRC         bool         // flag of enabling CRC for upload.
+	MinPartSizeOverride int64        // the min part size accepted by UploadFile and CopyFile, 0 means MinPartSize is used. It's for the gateways accepting smaller parts.
+	HTTPClient          *http.Client // the custom HTTP client, the SDK does not build the transport from HTTPTimeout and the proxy when it's set.
+	SignHash            SignHashType // the HMAC hash of the signature, by default it's SignHashSHA1.
+	IOBufferSize        int          // the buffer size for copying the response bodies to the files and spooling the MD5 bodies, 0 means io.Copy's default 32KB.
+
+	// the GET, HEAD and PUT requests are retried on 500, 502, 503 and the connection reset when MaxRetries is positive,
+	// except the conditional PUT with If-Match, If-None-Match or ForbidOverwrite(true)
+	MaxRetries      int           // the max retries of a request, by default it's 0 and the request is not retried.
+	RetryBackoff    time.Duration // the backoff before the first retry, it's doubled for each retry. By default it's 200ms.
+	MaxRetryBackoff time.Duration // the max backoff between the retries. By default it's 10s.
+	RetryPredicate  RetryFunc     // decides whether to retry instead of the status codes above, it's nil by default.
+
+	Transport TransportConfig // the connection pool of the transport, it's not used when HTTPClient is set.
+
+	// the logger of the requests, the signing and the retries, by default it logs nothing
+	Logger Logger
+
+	// the interceptors are called in order around each HTTP request, they're added by the ClientOptions
+	RequestInterceptors  []RequestInterceptor
+	ResponseInterceptors []ResponseInterceptor
+
+	// it's called with the server time of each response, it's nil by default
+	ServerTimeCallback ServerTimeFunc
+
+	// the credentials are got from the provider at the request time, by default it provides AccessKeyID,
+	// AccessKeySecret and SecurityToken above
+	CredentialsProvider CredentialsProvider
+
+	// the bandwidth limits are shared by all the requests of the client, they're read when the client is created by New
+	UploadBandwidthLimit   int64        // the max bytes per second of the request bodies, 0 means no limit.
+	DownloadBandwidthLimit int64        // the max bytes per second of the response bodies, 0 means no limit.
+	uploadLimiter          *rateLimiter // the limiter of UploadBandwidthLimit
+	downloadLimiter        *rateLimiter // the limiter of DownloadBandwidthLimit
+}
+
+// getCredentials gets the credentials to sign the request, with the error of the CredentialsRetriever.
+func (config *Config) getCredentials() (string, string, string, error) {
+	if retriever, ok := config.CredentialsProvider.(CredentialsRetriever); ok {
+		return retriever.Retrieve()
+	}
+	accessKeyID, accessKeySecret, securityToken := config.CredentialsProvider.GetCredentials()
+	return accessKeyID, accessKeySecret, securityToken, nil
+}
+
+// getMinPartSize gets the min part size accepted, the override takes effect when it's positive.
+func (config *Config) getMinPartSize() int64 {
+	if config.MinPartSizeOverride > 0 {
+		return config.MinPartSizeOverride
+	}
+	return MinPartSize
 }
 
 // Gets the default config.
@@ -46,6 +139,8 @@ func getDefaultOssConfig() *Config {
 	config.UserAgent = userAgent
 	config.Timeout = 60 // seconds
 	config.SecurityToken = ""
+	config.CredentialsProvider = &defaultCredentialsProvider{config: &config}
+	config.Logger = nopLogger{}
 	config.IsCname = false
 
 	config.HTTPTimeout.ConnectTimeout = time.Second * 30   // 30s
@@ -53,6 +148,10 @@ func getDefaultOssConfig() *Config {
 	config.HTTPTimeout.HeaderTimeout = time.Second * 60    // 60s
 	config.HTTPTimeout.LongTimeout = time.Second * 300     // 300s
 
+	config.Transport.MaxIdleConns = 100
+	config.Transport.MaxIdleConnsPerHost = 100
+	config.Transport.IdleConnTimeout = time.Second * 50 // 50s
+
 	config.IsUseProxy = false
 	config.ProxyHost = ""
 	config.IsAuthProxy = false
@@ -62,6 +161,12 @@ func getDefaultOssConfig() *Config {
 	config.MD5Threshold = 16 * 1024 * 1024 // 16MB
 	config.IsEnableMD5 = false
 	config.IsEnableCRC = true
+	config.MinPartSizeOverride = 0
+	config.SignHash = SignHashSHA1
+
+	config.MaxRetries = 0
+	config.RetryBackoff = time.Millisecond * 200 // 200ms
+	config.MaxRetryBackoff = time.Second * 10    // 10s
 
 	return &config
 }
@@ -2,9 +2,12 @@ package oss
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -16,6 +19,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -24,12 +29,26 @@ type Conn struct {
 	config *Config
 	url    *urlMaker
 	client *http.Client
+
+	buckets *bucketInfoCache // the cached information of the buckets, it's shared by the buckets of the client
 }
 
-var signKeyList = []string{"acl", "uploads", "location", "cors", "logging", "website", "referer", "lifecycle", "delete", "append", "tagging", "objectMeta", "uploadId", "partNumber", "security-token", "position", "img", "style", "styleName", "replication", "replicationProgress", "replicationLocation", "cname", "bucketInfo", "comp", "qos", "live", "status", "vod", "startTime", "endTime", "symlink", "x-oss-process", "response-content-type", "response-content-language", "response-expires", "response-cache-control", "response-content-disposition", "response-content-encoding", "udf", "udfName", "udfImage", "udfId", "udfImageDesc", "udfApplication", "comp", "udfApplicationLog", "restore"}
+var signKeyList = []string{"acl", "uploads", "location", "cors", "logging", "website", "referer", "lifecycle", "delete", "append", "tagging", "objectMeta", "uploadId", "partNumber", "security-token", "position", "img", "style", "styleName", "replication", "replicationProgress", "replicationLocation", "cname", "bucketInfo", "comp", "qos", "live", "status", "vod", "startTime", "endTime", "symlink", "x-oss-process", "response-content-type", "response-content-language", "response-expires", "response-cache-control", "response-content-disposition", "response-content-encoding", "udf", "udfName", "udfImage", "udfId", "udfImageDesc", "udfApplication", "comp", "udfApplicationLog", "restore", "versioning", "versionId", "encryption", "policy", "requestPayment", "stat", "legal-hold", "x-oss-traffic-limit"}
 
 // init initialize Conn
 func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
+	// the limiters are shared by the buckets of the client
+	config.uploadLimiter = newRateLimiter(config.UploadBandwidthLimit)
+	config.downloadLimiter = newRateLimiter(config.DownloadBandwidthLimit)
+
+	// the custom client is used as it is
+	if config.HTTPClient != nil {
+		conn.config = config
+		conn.url = urlMaker
+		conn.client = config.HTTPClient
+		return nil
+	}
+
 	httpTimeOut := conn.config.HTTPTimeout
 
 	// new Transport
@@ -42,6 +61,9 @@ func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
 			return newTimeoutConn(conn, httpTimeOut.ReadWriteTimeout, httpTimeOut.LongTimeout), nil
 		},
 		ResponseHeaderTimeout: httpTimeOut.HeaderTimeout,
+		MaxIdleConns:          config.Transport.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.Transport.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.Transport.IdleConnTimeout,
 	}
 
 	// Proxy
@@ -63,15 +85,29 @@ func (conn *Conn) init(config *Config, urlMaker *urlMaker) error {
 // Do sends request and returns the response
 func (conn Conn) Do(method, bucketName, objectName string, params map[string]interface{}, headers map[string]string,
 	data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
+	return conn.DoWithContext(context.Background(), method, bucketName, objectName, params, headers,
+		data, initCRC, listener)
+}
+
+// DoWithContext sends request bound to the context and returns the response.
+// The request is aborted and ctx.Err() is returned when the context is cancelled or its deadline exceeds.
+func (conn Conn) DoWithContext(ctx context.Context, method, bucketName, objectName string, params map[string]interface{},
+	headers map[string]string, data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
 	urlParams := conn.getURLParams(params)
 	subResource := conn.getSubResource(params)
 	uri := conn.url.getURL(bucketName, objectName, urlParams)
 	resource := conn.url.getResource(bucketName, objectName, subResource)
-	return conn.doRequest(method, uri, resource, headers, data, initCRC, listener)
+	return conn.doRequest(ctx, method, uri, resource, headers, data, initCRC, listener)
 }
 
 // DoURL sends the request with presigned url.
 func (conn Conn) DoURL(method HTTPMethod, signedURL string, headers map[string]string,
+	data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
+	return conn.DoURLWithContext(context.Background(), method, signedURL, headers, data, initCRC, listener)
+}
+
+// DoURLWithContext sends the request with presigned url bound to the context.
+func (conn Conn) DoURLWithContext(ctx context.Context, method HTTPMethod, signedURL string, headers map[string]string,
 	data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
 	// get uri form signedURL
 	uri, err := url.ParseRequestURI(signedURL)
@@ -80,57 +116,16 @@ func (conn Conn) DoURL(method HTTPMethod, signedURL string, headers map[string]s
 	}
 
 	m := strings.ToUpper(string(method))
-	req := &http.Request{
-		Method:     m,
-		URL:        uri,
-		Proto:      "HTTP/1.1",
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Header:     make(http.Header),
-		Host:       uri.Host,
-	}
-
-	tracker := &readerTracker{completedBytes: 0}
-	fd, crc := conn.handleBody(req, data, initCRC, listener, tracker)
-	if fd != nil {
-		defer func() {
-			fd.Close()
-			os.Remove(fd.Name())
-		}()
-	}
-
-	if conn.config.IsAuthProxy {
-		auth := conn.config.ProxyUser + ":" + conn.config.ProxyPassword
-		basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
-		req.Header.Set("Proxy-Authorization", basic)
-	}
-
-	req.Header.Set(HTTPHeaderHost, conn.config.Endpoint)
-	req.Header.Set(HTTPHeaderUserAgent, conn.config.UserAgent)
+	return conn.sendRequest(ctx, m, uri, data, initCRC, listener, func(req *http.Request) {
+		req.Header.Set(HTTPHeaderHost, conn.config.Endpoint)
+		req.Header.Set(HTTPHeaderUserAgent, conn.config.UserAgent)
 
-	if headers != nil {
-		for k, v := range headers {
-			req.Header.Set(k, v)
+		if headers != nil {
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
 		}
-	}
-
-	// transfer started
-	event := newProgressEvent(TransferStartedEvent, 0, req.ContentLength)
-	publishProgress(listener, event)
-
-	resp, err := conn.client.Do(req)
-	if err != nil {
-		// transfer failed
-		event = newProgressEvent(TransferFailedEvent, tracker.completedBytes, req.ContentLength)
-		publishProgress(listener, event)
-		return nil, err
-	}
-
-	// transfer completed
-	event = newProgressEvent(TransferCompletedEvent, tracker.completedBytes, req.ContentLength)
-	publishProgress(listener, event)
-
-	return conn.handleResponse(resp, crc)
+	})
 }
 
 func (conn Conn) getURLParams(params map[string]interface{}) string {
@@ -190,70 +185,341 @@ func (conn Conn) isParamSign(paramKey string) bool {
 	return false
 }
 
-func (conn Conn) doRequest(method string, uri *url.URL, canonicalizedResource string, headers map[string]string,
-	data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
+func (conn Conn) doRequest(ctx context.Context, method string, uri *url.URL, canonicalizedResource string,
+	headers map[string]string, data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
 	method = strings.ToUpper(method)
-	req := &http.Request{
-		Method:     method,
-		URL:        uri,
-		Proto:      "HTTP/1.1",
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Header:     make(http.Header),
-		Host:       uri.Host,
+	// the credentials are got once for all the retries of the request
+	accessKeyID, accessKeySecret, securityToken, err := conn.config.getCredentials()
+	if err != nil {
+		return nil, err
 	}
+	return conn.sendRequest(ctx, method, uri, data, initCRC, listener, func(req *http.Request) {
+		date := time.Now().UTC().Format(http.TimeFormat)
+		req.Header.Set(HTTPHeaderDate, date)
+		req.Header.Set(HTTPHeaderHost, conn.config.Endpoint)
+		req.Header.Set(HTTPHeaderUserAgent, conn.config.UserAgent)
 
-	tracker := &readerTracker{completedBytes: 0}
-	fd, crc := conn.handleBody(req, data, initCRC, listener, tracker)
-	if fd != nil {
-		defer func() {
-			fd.Close()
-			os.Remove(fd.Name())
-		}()
-	}
+		if headers != nil {
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+		}
 
-	if conn.config.IsAuthProxy {
-		auth := conn.config.ProxyUser + ":" + conn.config.ProxyPassword
-		basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
-		req.Header.Set("Proxy-Authorization", basic)
+		conn.signHeaderWithCredentials(req, canonicalizedResource, accessKeyID, accessKeySecret, securityToken)
+	})
+}
+
+// sendRequest sends the request whose headers are set by prepare. When MaxRetries is set, the idempotent request
+// is retried on the transient errors, or the request is retried as RetryPredicate decides. The request is built again for each attempt, so the body is read from the
+// start and its MD5 and CRC are calculated again. TransferFailedEvent is only published after the last attempt.
+func (conn Conn) sendRequest(ctx context.Context, method string, uri *url.URL, data io.Reader, initCRC uint64,
+	listener ProgressListener, prepare func(req *http.Request)) (*Response, error) {
+	retries, offset := conn.getRetries(method, data)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && data != nil {
+			if _, err := data.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		req := &http.Request{
+			Method:     method,
+			URL:        uri,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Host:       uri.Host,
+		}
+		req = req.WithContext(ctx)
+
+		tracker := &readerTracker{completedBytes: 0}
+		fd, crc := conn.handleBody(req, data, initCRC, listener, tracker)
+
+		// the body is rewound for the retry, it must not be closed by the transport
+		var body *retryBody
+		if retries > 0 && req.Body != nil {
+			body = &retryBody{reader: req.Body, closed: make(chan struct{})}
+			req.Body = body
+		}
+
+		if conn.config.IsAuthProxy {
+			auth := conn.config.ProxyUser + ":" + conn.config.ProxyPassword
+			basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+			req.Header.Set("Proxy-Authorization", basic)
+		}
+
+		prepare(req)
+
+		if attempt == 0 {
+			// transfer started
+			event := newProgressEvent(TransferStartedEvent, 0, req.ContentLength)
+			publishProgress(listener, event)
+		}
+
+		for _, intercept := range conn.config.RequestInterceptors {
+			intercept(req)
+		}
+		isLogging := conn.config.isLogging()
+		if isLogging {
+			conn.config.Logger.Debugf("oss: request %s %s, attempt %d", method, redactURL(uri), attempt)
+		}
+		start := time.Now()
+		resp, err := conn.client.Do(req)
+		elapsed := time.Since(start)
+		if isLogging {
+			if err != nil {
+				conn.config.Logger.Errorf("oss: request %s %s failed in %v, %v", method, redactURL(uri), elapsed, err)
+			} else {
+				conn.config.Logger.Debugf("oss: response %s %s, status %d, request id %s, elapsed %v", method,
+					redactURL(uri), resp.StatusCode, resp.Header.Get(HTTPHeaderOssRequestID), elapsed)
+			}
+		}
+		for _, intercept := range conn.config.ResponseInterceptors {
+			intercept(req, resp, err, elapsed)
+		}
+		if conn.config.ServerTimeCallback != nil && err == nil {
+			if serverTime, perr := http.ParseTime(resp.Header.Get(HTTPHeaderDate)); perr == nil {
+				conn.config.ServerTimeCallback(serverTime, start.Add(elapsed))
+			}
+		}
+
+		retry := attempt < retries && ctx.Err() == nil && conn.isRetryable(req, resp, err, attempt)
+		backoff := conn.getRetryBackoff(attempt)
+		if retry && resp != nil && resp.StatusCode == http.StatusServiceUnavailable {
+			// the throttled request is retried after the delay OSS asks for, which is bounded by MaxRetryBackoff
+			if retryAfter, ok := getRetryAfter(resp); ok {
+				backoff = retryAfter
+				if maxBackoff := conn.config.MaxRetryBackoff; maxBackoff > 0 && backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+			}
+		}
+		if retry {
+			if resp != nil {
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			if body != nil {
+				// the transport may still read the body after the response
+				<-body.closed
+			}
+		}
+		closeTempFile(fd)
+
+		if retry {
+			if isLogging {
+				conn.config.Logger.Infof("oss: request %s %s is retried in %v, attempt %d", method, redactURL(uri), backoff, attempt+1)
+			}
+			if err = waitRetry(ctx, backoff); err == nil {
+				continue
+			}
+			resp = nil
+		}
+
+		if err != nil {
+			// transfer failed
+			event := newProgressEvent(TransferFailedEvent, tracker.completedBytes, req.ContentLength)
+			publishProgress(listener, event)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		// transfer completed
+		event := newProgressEvent(TransferCompletedEvent, tracker.completedBytes, req.ContentLength)
+		publishProgress(listener, event)
+
+		response, err := conn.handleResponse(resp, crc)
+		if err != nil {
+			// the throttled request is only reported as ThrottleError when it's retried and the retries are exhausted
+			if throttleErr, ok := getThrottleError(resp, err, attempt); ok && attempt > 0 && attempt == retries {
+				err = throttleErr
+			}
+		}
+		return response, err
 	}
+}
 
-	date := time.Now().UTC().Format(http.TimeFormat)
-	req.Header.Set(HTTPHeaderDate, date)
-	req.Header.Set(HTTPHeaderHost, conn.config.Endpoint)
-	req.Header.Set(HTTPHeaderUserAgent, conn.config.UserAgent)
-	if conn.config.SecurityToken != "" {
-		req.Header.Set(HTTPHeaderOssSecurityToken, conn.config.SecurityToken)
+// getRetries gets the max retries of the request and the offset the body is rewound to. Without RetryPredicate, only
+// the GET, HEAD and unconditional PUT requests are retried. The body must be seekable to send it again.
+func (conn Conn) getRetries(method string, data io.Reader) (int, int64) {
+	if conn.config.MaxRetries <= 0 {
+		return 0, 0
 	}
 
-	if headers != nil {
-		for k, v := range headers {
-			req.Header.Set(k, v)
+	if conn.config.RetryPredicate == nil {
+		switch method {
+		case "GET", "HEAD", "PUT":
+		default:
+			return 0, 0
 		}
 	}
 
-	conn.signHeader(req, canonicalizedResource)
+	if data == nil {
+		return conn.config.MaxRetries, 0
+	}
+
+	seeker, ok := data.(io.Seeker)
+	if !ok {
+		return 0, 0
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0
+	}
+	return conn.config.MaxRetries, offset
+}
+
+// getRetryBackoff gets the backoff before the retry, it's doubled for each attempt up to MaxRetryBackoff
+func (conn Conn) getRetryBackoff(attempt int) time.Duration {
+	backoff := conn.config.RetryBackoff
+	maxBackoff := conn.config.MaxRetryBackoff
+	for i := 0; i < attempt && (maxBackoff <= 0 || backoff < maxBackoff); i++ {
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
 
-	// transfer started
-	event := newProgressEvent(TransferStartedEvent, 0, req.ContentLength)
-	publishProgress(listener, event)
+// waitRetry waits for the backoff, it returns ctx.Err() when the context is done before that
+func waitRetry(ctx context.Context, backoff time.Duration) error {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
 
-	resp, err := conn.client.Do(req)
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRetryable checks whether to retry the request by the predicate of the config or isRetryableError. The conditional
+// PUT is not retried by default, the first attempt may have written the object before the failure, and the retry
+// would fail on the condition the request itself changed.
+func (conn Conn) isRetryable(req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if conn.config.RetryPredicate == nil {
+		return !isConditionalWrite(req) && isRetryableError(resp, err)
+	}
+	var response *Response
+	if resp != nil {
+		response = &Response{StatusCode: resp.StatusCode, Headers: resp.Header}
+	}
+	return conn.config.RetryPredicate(req, response, err, attempt)
+}
+
+// isConditionalWrite checks if the request is PUT with If-Match, If-None-Match or X-Oss-Forbid-Overwrite: true
+func isConditionalWrite(req *http.Request) bool {
+	if req.Method != "PUT" {
+		return false
+	}
+	return req.Header.Get(HTTPHeaderIfMatch) != "" || req.Header.Get(HTTPHeaderIfNoneMatch) != "" ||
+		strings.EqualFold(req.Header.Get(HTTPHeaderOssForbidOverwrite), "true")
+}
+
+// isRetryableError checks if the request fails on the transient error, which is 500, 502 or 503 returned by
+// the server or the connection reset.
+func isRetryableError(resp *http.Response, err error) bool {
 	if err != nil {
-		// transfer failed
-		event = newProgressEvent(TransferFailedEvent, tracker.completedBytes, req.ContentLength)
-		publishProgress(listener, event)
-		return nil, err
+		return isConnectionReset(err)
 	}
 
-	// transfer completed
-	event = newProgressEvent(TransferCompletedEvent, tracker.completedBytes, req.ContentLength)
-	publishProgress(listener, event)
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
 
-	return conn.handleResponse(resp, crc)
+// getThrottleError gets the error of the request throttled by OSS, which is 503 SlowDown or 503 with the Retry-After
+// header. The response without the body, such as HEAD, is throttled by Retry-After only.
+func getThrottleError(resp *http.Response, err error, retries int) (ThrottleError, bool) {
+	srvErr, ok := err.(ServiceError)
+	if !ok || resp.StatusCode != http.StatusServiceUnavailable {
+		return ThrottleError{}, false
+	}
+	retryAfter, hasRetryAfter := getRetryAfter(resp)
+	if srvErr.Code != "SlowDown" && !hasRetryAfter {
+		return ThrottleError{}, false
+	}
+	return ThrottleError{ServiceError: srvErr, RetryAfter: retryAfter, Retries: retries}, true
+}
+
+// getRetryAfter gets the delay of the Retry-After header, which is the seconds or the HTTP date
+func getRetryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get(HTTPHeaderRetryAfter)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := date.Sub(time.Now())
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+func isConnectionReset(err error) bool {
+	for err != nil {
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+		case *net.OpError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		case syscall.Errno:
+			return e == syscall.ECONNRESET
+		default:
+			return strings.Contains(err.Error(), "connection reset by peer")
+		}
+	}
+	return false
+}
+
+func closeTempFile(fd *os.File) {
+	if fd != nil {
+		fd.Close()
+		os.Remove(fd.Name())
+	}
+}
+
+// retryBody is the request body which is not closed by the transport, closed is closed instead.
+type retryBody struct {
+	reader io.Reader
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (body *retryBody) Read(p []byte) (int, error) {
+	return body.reader.Read(p)
+}
+
+func (body *retryBody) Close() error {
+	body.once.Do(func() {
+		close(body.closed)
+	})
+	return nil
 }
 
-func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expiration int64, params map[string]interface{}, headers map[string]string) string {
+func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expiration int64, params map[string]interface{}, headers map[string]string) (string, error) {
+	accessKeyID, accessKeySecret, securityToken, err := conn.config.getCredentials()
+	if err != nil {
+		return "", err
+	}
 	subResource := conn.getSubResource(params)
 	canonicalizedResource := conn.url.getResource(bucketName, objectName, subResource)
 
@@ -279,17 +545,17 @@ func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expir
 		}
 	}
 
-	signedStr := conn.getSignedStr(req, canonicalizedResource)
+	signedStr := conn.getSignedStr(req, canonicalizedResource, accessKeySecret)
 
 	params[HTTPParamExpires] = strconv.FormatInt(expiration, 10)
-	params[HTTPParamAccessKeyID] = conn.config.AccessKeyID
+	params[HTTPParamAccessKeyID] = accessKeyID
 	params[HTTPParamSignature] = signedStr
-	if conn.config.SecurityToken != "" {
-		params[HTTPParamSecurityToken] = conn.config.SecurityToken
+	if securityToken != "" {
+		params[HTTPParamSecurityToken] = securityToken
 	}
 
 	urlParams := conn.getURLParams(params)
-	return conn.url.getSignURL(bucketName, objectName, urlParams)
+	return conn.url.getSignURL(bucketName, objectName, urlParams), nil
 }
 
 // handle request body
@@ -317,10 +583,13 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader, initCRC uint64,
 	// md5
 	if body != nil && conn.config.IsEnableMD5 && req.Header.Get(HTTPHeaderContentMD5) == "" {
 		md5 := ""
-		reader, md5, file, _ = calcMD5(body, req.ContentLength, conn.config.MD5Threshold)
+		reader, md5, file, _ = calcMD5(body, req.ContentLength, conn.config.MD5Threshold, conn.config.IOBufferSize)
 		req.Header.Set(HTTPHeaderContentMD5, md5)
 	}
 
+	// the limiter is under the progress, so the progress reflects the limited rate
+	reader = newLimitedReader(reader, conn.config.uploadLimiter)
+
 	// crc
 	if reader != nil && conn.config.IsEnableCRC {
 		crc = NewCRC(crcTable(), initCRC)
@@ -356,18 +625,21 @@ func (conn Conn) handleResponse(resp *http.Response, crc hash.Hash64) (*Response
 			return nil, err
 		}
 
-		if len(respBody) == 0 {
-			// no error in response body
-			err = fmt.Errorf("oss: service returned without a response body (%s)", resp.Status)
-		} else {
-			// response contains storage service error object, unmarshal
-			srvErr, errIn := serviceErrFromXML(respBody, resp.StatusCode,
-				resp.Header.Get(HTTPHeaderOssRequestID))
-			if err != nil { // error unmarshaling the error response
-				err = errIn
-			}
-			err = srvErr
+		// the response without the error body, such as HEAD, carries it in the X-Oss-Err header. Without it, or with
+		// the body not from OSS, such as a proxy's, the ServiceError has the status and the request id in the header
+		errBody := respBody
+		if len(errBody) == 0 {
+			errBody, _ = base64.StdEncoding.DecodeString(resp.Header.Get(HTTPHeaderOssErr))
 		}
+		srvErr, errIn := serviceErrFromXML(errBody, resp.StatusCode, resp.Header.Get(HTTPHeaderOssRequestID))
+		if len(errBody) == 0 || errIn != nil {
+			srvErr = ServiceError{Message: resp.Status, RequestID: resp.Header.Get(HTTPHeaderOssRequestID),
+				RawMessage: string(respBody), StatusCode: resp.StatusCode}
+		}
+		if srvErr.HostID == "" && resp.Request != nil {
+			srvErr.HostID = resp.Request.Host
+		}
+		err = srvErr
 
 		return &Response{
 			StatusCode: resp.StatusCode,
@@ -389,25 +661,33 @@ func (conn Conn) handleResponse(resp *http.Response, crc hash.Hash64) (*Response
 	}
 	srvCRC, _ = strconv.ParseUint(resp.Header.Get(HTTPHeaderOssCRC64), 10, 64)
 
+	body := resp.Body
+	if conn.config.downloadLimiter != nil {
+		body = struct {
+			io.Reader
+			io.Closer
+		}{newLimitedReader(resp.Body, conn.config.downloadLimiter), resp.Body}
+	}
+
 	// 2xx, successful
 	return &Response{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
-		Body:       resp.Body,
+		Body:       body,
 		ClientCRC:  cliCRC,
 		ServerCRC:  srvCRC,
 	}, nil
 }
 
-func calcMD5(body io.Reader, contentLen, md5Threshold int64) (reader io.Reader, b64 string, tempFile *os.File, err error) {
+func calcMD5(body io.Reader, contentLen, md5Threshold int64, bufSize int) (reader io.Reader, b64 string, tempFile *os.File, err error) {
 	if contentLen == 0 || contentLen > md5Threshold {
 		// huge body, use temporary file
 		tempFile, err = ioutil.TempFile(os.TempDir(), TempFilePrefix)
 		if tempFile != nil {
-			io.Copy(tempFile, body)
+			copyBuffer(tempFile, body, bufSize)
 			tempFile.Seek(0, os.SEEK_SET)
 			md5 := md5.New()
-			io.Copy(md5, tempFile)
+			copyBuffer(md5, tempFile, bufSize)
 			sum := md5.Sum(nil)
 			b64 = base64.StdEncoding.EncodeToString(sum[:])
 			tempFile.Seek(0, os.SEEK_SET)
@@ -423,6 +703,29 @@ func calcMD5(body io.Reader, contentLen, md5Threshold int64) (reader io.Reader,
 	return
 }
 
+// calcSeekableMD5 computes the hex MD5 of the remaining data and then seeks the reader back
+func calcSeekableMD5(body io.Reader) (string, error) {
+	seeker, ok := body.(io.ReadSeeker)
+	if !ok {
+		return "", errors.New("oss: the reader must be seekable to verify the etag")
+	}
+
+	pos, err := seeker.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return "", err
+	}
+
+	h := md5.New()
+	if _, err = io.Copy(h, seeker); err != nil {
+		return "", err
+	}
+
+	if _, err = seeker.Seek(pos, os.SEEK_SET); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func readResponseBody(resp *http.Response) ([]byte, error) {
 	defer resp.Body.Close()
 	out, err := ioutil.ReadAll(resp.Body)
@@ -438,7 +741,9 @@ func serviceErrFromXML(body []byte, statusCode int, requestID string) (ServiceEr
 		return storageErr, err
 	}
 	storageErr.StatusCode = statusCode
-	storageErr.RequestID = requestID
+	if requestID != "" {
+		storageErr.RequestID = requestID
+	}
 	storageErr.RawMessage = string(body)
 	return storageErr, nil
 }
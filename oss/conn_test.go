@@ -1,7 +1,21 @@
 package oss
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -74,7 +88,7 @@ func (s *OssConnSuite) TestAuth(c *C) {
 	cfg := getDefaultOssConfig()
 	um := urlMaker{}
 	um.Init(endpoint, false, false)
-	conn := Conn{cfg, &um, nil}
+	conn := Conn{config: cfg, url: &um}
 	uri := um.getURL("bucket", "object", "")
 	req := &http.Request{
 		Method:     "PUT",
@@ -98,6 +112,39 @@ func (s *OssConnSuite) TestAuth(c *C) {
 	testLogger.Println("AUTHORIZATION:", req.Header.Get(HTTPHeaderAuthorization))
 }
 
+func (s *OssConnSuite) TestAuthSignHash(c *C) {
+	// the example of the OSS document
+	um := urlMaker{}
+	um.Init("http://oss-cn-hangzhou.aliyuncs.com", false, false)
+	newReq := func() *http.Request {
+		req := &http.Request{Method: "PUT", Header: make(http.Header)}
+		req.Header.Set("Content-Type", "text/html")
+		req.Header.Set("Date", "Thu, 17 Nov 2005 18:49:58 GMT")
+		req.Header.Set("X-OSS-Meta-Author", "foo@bar.com")
+		req.Header.Set("X-OSS-Magic", "abracadabra")
+		req.Header.Set("Content-Md5", "ODBGOERFMDMzQTczRUY3NUE3NzA5QzdFNUYzMDQxNEM=")
+		return req
+	}
+	resource := um.getResource("oss-example", "nelson", "")
+
+	cfg := getDefaultOssConfig()
+	cfg.AccessKeyID = "44CF9590006BF252F707"
+	cfg.AccessKeySecret = "OtxrzxIsfpFjA7SwPzILwy8Bw21TLhquhboDYROV"
+	c.Assert(cfg.SignHash, Equals, SignHashSHA1)
+	conn := Conn{config: cfg, url: &um}
+	req := newReq()
+	conn.signHeader(req, resource)
+	c.Assert(req.Header.Get(HTTPHeaderAuthorization), Equals, "OSS 44CF9590006BF252F707:26NBxoKdsyly4EDv6inkoDft/yA=")
+
+	// the same string to sign by HMAC-SHA256
+	client, err := New("http://oss-cn-hangzhou.aliyuncs.com", cfg.AccessKeyID, cfg.AccessKeySecret, SignHash(SignHashSHA256))
+	c.Assert(err, IsNil)
+	req = newReq()
+	client.Conn.signHeader(req, resource)
+	c.Assert(req.Header.Get(HTTPHeaderAuthorization), Equals,
+		"OSS-HMAC-SHA256 44CF9590006BF252F707:lco2MJMoi45Rzwggkywl9zXPD6g5dnS8HpxbT3l/yzc=")
+}
+
 func (s *OssConnSuite) TestConnToolFunc(c *C) {
 	err := checkRespCode(202, []int{})
 	c.Assert(err, NotNil)
@@ -124,3 +171,431 @@ func (s *OssConnSuite) TestConnToolFunc(c *C) {
 	c.Assert(len(unexpect.Error()) > 0, Equals, true)
 	c.Assert(unexpect.Got(), Equals, 202)
 }
+
+func (s *OssConnSuite) TestAccelerateEndpointSign(c *C) {
+	// the stub checks the host the request is sent to and verifies the signature of the bucket's resource
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		h := hmac.New(sha1.New, []byte("sk"))
+		io.WriteString(h, r.Method+"\n\n\n"+r.Header.Get(HTTPHeaderDate)+"\n/bucket/object")
+		signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		if r.Header.Get(HTTPHeaderAuthorization) != "OSS ak:"+signature {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>")
+		}
+	}))
+	defer server.Close()
+
+	// the accelerate endpoint is connected to the stub
+	transport := &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			return net.Dial(network, server.Listener.Addr().String())
+		},
+	}
+	client, err := New("http://oss-accelerate.aliyuncs.com", "ak", "sk", HTTPClient(&http.Client{Transport: transport}))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	body, err := bucket.GetObject("object")
+	c.Assert(err, IsNil)
+	body.Close()
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, IsNil)
+
+	// the signed url of the accelerate endpoint
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(signedURL, "http://bucket.oss-accelerate.aliyuncs.com/object?"), Equals, true)
+	c.Assert(hosts, DeepEquals, []string{"bucket.oss-accelerate.aliyuncs.com", "bucket.oss-accelerate.aliyuncs.com"})
+}
+
+func (s *OssConnSuite) TestServiceSignURL(c *C) {
+	um := urlMaker{}
+	um.Init("oss-cn-hangzhou.aliyuncs.com", false, false)
+	c.Assert(um.getResource("", "", ""), Equals, "/")
+
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+
+	str, err := client.SignURL(HTTPGet, 60)
+	c.Assert(err, IsNil)
+
+	u, err := url.Parse(str)
+	c.Assert(err, IsNil)
+	c.Assert(u.Host, Equals, "oss-cn-hangzhou.aliyuncs.com")
+	c.Assert(u.Path, Equals, "/")
+
+	query := u.Query()
+	c.Assert(query.Get(HTTPParamAccessKeyID), Equals, "ak")
+
+	h := hmac.New(sha1.New, []byte("sk"))
+	h.Write([]byte("GET\n\n\n" + query.Get(HTTPParamExpires) + "\n/"))
+	c.Assert(query.Get(HTTPParamSignature), Equals, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+
+	_, err = client.SignURL(HTTPGet, -1)
+	c.Assert(err, NotNil)
+}
+
+// rotatingCredentialsProvider provides the new credentials for each call, like the provider refreshing the STS token
+type rotatingCredentialsProvider struct {
+	calls int64
+}
+
+func (provider *rotatingCredentialsProvider) GetCredentials() (string, string, string) {
+	n := atomic.AddInt64(&provider.calls, 1)
+	return fmt.Sprintf("ak-%d", n), fmt.Sprintf("sk-%d", n), fmt.Sprintf("token-%d", n)
+}
+
+func (s *OssConnSuite) TestCredentialsProvider(c *C) {
+	// the default provider reads the credentials at the request time
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+	client.Config.SecurityToken = "token"
+	id, secret, token := client.Config.CredentialsProvider.GetCredentials()
+	c.Assert([]string{id, secret, token}, DeepEquals, []string{"ak", "sk", "token"})
+
+	var auths, tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get(HTTPHeaderAuthorization))
+		tokens = append(tokens, r.Header.Get(HTTPHeaderOssSecurityToken))
+	}))
+	defer server.Close()
+
+	provider := &rotatingCredentialsProvider{}
+	client, err = New(server.URL, "ak", "sk", SecurityToken("token"), SetCredentialsProvider(provider))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	for i := 0; i < 2; i++ {
+		_, err = bucket.GetObjectMeta("object")
+		c.Assert(err, IsNil)
+	}
+	c.Assert(len(auths), Equals, 2)
+	c.Assert(auths[0][:len("OSS ak-1:")], Equals, "OSS ak-1:")
+	c.Assert(auths[1][:len("OSS ak-2:")], Equals, "OSS ak-2:")
+	c.Assert(tokens, DeepEquals, []string{"token-1", "token-2"})
+
+	// the signed url is signed by one set of the credentials
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	u, err := url.Parse(signedURL)
+	c.Assert(err, IsNil)
+	query := u.Query()
+	c.Assert(query.Get(HTTPParamAccessKeyID), Equals, "ak-3")
+	c.Assert(query.Get(HTTPParamSecurityToken), Equals, "token-3")
+	h := hmac.New(sha1.New, []byte("sk-3"))
+	h.Write([]byte("GET\n\n\n" + query.Get(HTTPParamExpires) + "\n/mock-bucket/object"))
+	c.Assert(query.Get(HTTPParamSignature), Equals, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+
+	// nil restores the default
+	client, err = New(server.URL, "ak", "sk", SetCredentialsProvider(provider), SetCredentialsProvider(nil))
+	c.Assert(err, IsNil)
+	id, secret, token = client.Config.CredentialsProvider.GetCredentials()
+	c.Assert([]string{id, secret, token}, DeepEquals, []string{"ak", "sk", ""})
+}
+
+func (s *OssConnSuite) TestBucketWithEndpoint(c *C) {
+	var hosts, auths []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		auths = append(auths, r.Header.Get(HTTPHeaderAuthorization))
+	}
+	home := httptest.NewServer(http.HandlerFunc(handler))
+	defer home.Close()
+	other := httptest.NewServer(http.HandlerFunc(handler))
+	defer other.Close()
+
+	client, err := New(home.URL, "ak", "sk", SecurityToken("token"))
+	c.Assert(err, IsNil)
+	bucket, err := client.BucketWithEndpoint("mock-bucket", other.URL)
+	c.Assert(err, IsNil)
+	c.Assert(bucket.BucketName, Equals, "mock-bucket")
+
+	// the request is routed to the endpoint and signed by the client's credentials
+	_, err = bucket.GetObjectMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(hosts, DeepEquals, []string{strings.TrimPrefix(other.URL, "http://")})
+	c.Assert(strings.HasPrefix(auths[0], "OSS ak:"), Equals, true)
+
+	// the signed url is of the endpoint
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(signedURL, other.URL+"/mock-bucket/object?"), Equals, true)
+	c.Assert(bucket.ValidateSignedURL(signedURL, HTTPGet, nil), IsNil)
+	u, err := url.Parse(signedURL)
+	c.Assert(err, IsNil)
+	h := hmac.New(sha1.New, []byte("sk"))
+	h.Write([]byte("GET\n\n\n" + u.Query().Get(HTTPParamExpires) + "\n/mock-bucket/object"))
+	c.Assert(u.Query().Get(HTTPParamSignature), Equals, base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	c.Assert(u.Query().Get(HTTPParamSecurityToken), Equals, "token")
+
+	// the client's bucket is not changed
+	homeBucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = homeBucket.GetObjectMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(hosts[1], Equals, strings.TrimPrefix(home.URL, "http://"))
+	c.Assert(client.Config.Endpoint, Equals, home.URL)
+
+	_, err = client.BucketWithEndpoint("mock-bucket", "")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssConnSuite) TestInterceptors(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set(HTTPHeaderOssRequestID, "req-"+r.Method)
+	}))
+	defer server.Close()
+
+	var calls []string
+	var elapsed time.Duration
+	client, err := New(server.URL, "ak", "sk",
+		AddRequestInterceptor(func(req *http.Request) {
+			// the request is signed
+			c.Assert(strings.HasPrefix(req.Header.Get(HTTPHeaderAuthorization), "OSS ak:") ||
+				req.URL.Query().Get(HTTPParamSignature) != "", Equals, true)
+			calls = append(calls, "request-1 "+req.Method+" "+req.URL.Path)
+		}),
+		AddRequestInterceptor(func(req *http.Request) {
+			calls = append(calls, "request-2")
+		}),
+		AddResponseInterceptor(func(req *http.Request, resp *http.Response, err error, d time.Duration) {
+			c.Assert(err, IsNil)
+			calls = append(calls, "response-1 "+resp.Header.Get(HTTPHeaderOssRequestID))
+			elapsed = d
+		}, func(req *http.Request, resp *http.Response, err error, d time.Duration) {
+			calls = append(calls, "response-2")
+		}))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(calls, DeepEquals, []string{"request-1 HEAD /mock-bucket/object", "request-2", "response-1 req-HEAD", "response-2"})
+	c.Assert(elapsed >= 10*time.Millisecond, Equals, true)
+
+	// the requests with the signed url
+	calls = nil
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	body, err := bucket.GetObjectWithURL(signedURL)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(calls, DeepEquals, []string{"request-1 GET /mock-bucket/object", "request-2", "response-1 req-GET", "response-2"})
+
+	// the error without the response
+	var failure error
+	client, err = New("http://127.0.0.1:1", "ak", "sk",
+		AddResponseInterceptor(func(req *http.Request, resp *http.Response, err error, d time.Duration) {
+			c.Assert(req.Method, Equals, "HEAD")
+			c.Assert(resp, IsNil)
+			failure = err
+		}))
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(failure, NotNil)
+}
+
+func (s *OssConnSuite) TestOnServerTime(c *C) {
+	// the server clock is an hour ahead
+	serverTime := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mock-bucket/skewed":
+			w.Header().Set(HTTPHeaderDate, serverTime.Format(http.TimeFormat))
+			w.WriteHeader(http.StatusForbidden)
+		case "/mock-bucket/nodate":
+			w.Header()[HTTPHeaderDate] = nil
+		default:
+			w.Header().Set(HTTPHeaderDate, serverTime.Format(http.TimeFormat))
+		}
+	}))
+	defer server.Close()
+
+	var servers, locals []time.Time
+	client, err := New(server.URL, "ak", "sk", OnServerTime(func(server, local time.Time) {
+		servers = append(servers, server)
+		locals = append(locals, local)
+	}))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+
+	start := time.Now()
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(len(servers), Equals, 1)
+	c.Assert(servers[0].Equal(serverTime), Equals, true)
+	c.Assert(locals[0].Before(start), Equals, false)
+	c.Assert(locals[0].After(time.Now()), Equals, false)
+	c.Assert(servers[0].Sub(locals[0]) > 59*time.Minute, Equals, true)
+
+	// the error response is called too
+	_, err = bucket.GetObjectDetailedMeta("skewed")
+	c.Assert(err, NotNil)
+	c.Assert(len(servers), Equals, 2)
+	c.Assert(servers[1].Equal(serverTime), Equals, true)
+
+	// the response without Date is skipped
+	_, err = bucket.GetObjectDetailedMeta("nodate")
+	c.Assert(err, IsNil)
+	c.Assert(len(servers), Equals, 2)
+}
+
+// recordingLogger records the messages with the levels
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.record("INFO", format, args...)
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.record("ERROR", format, args...)
+}
+
+func (s *OssConnSuite) TestLogger(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderOssRequestID, "req-1")
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client, err := New(server.URL, "ak", "secret-key", SecurityToken("secret-token"), SetLogger(logger))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, IsNil)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	c.Assert(logger.messages, HasLen, 3)
+	c.Assert(strings.HasPrefix(logger.messages[0], `DEBUG oss: the string to sign "HEAD\n\n\n`), Equals, true)
+	c.Assert(strings.HasSuffix(logger.messages[0], `\nx-oss-security-token:******\n/mock-bucket/object"`), Equals, true)
+	c.Assert(logger.messages[1], Equals, "DEBUG oss: request HEAD http://"+host+"/mock-bucket/object, attempt 0")
+	c.Assert(strings.HasPrefix(logger.messages[2], "DEBUG oss: response HEAD http://"+host+"/mock-bucket/object, "+
+		"status 200, request id req-1, elapsed "), Equals, true)
+
+	// the signed url
+	logger.messages = nil
+	signedURL, err := bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	body, err := bucket.GetObjectWithURL(signedURL)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(logger.messages, HasLen, 3)
+	c.Assert(strings.Contains(logger.messages[1], "Signature=%2A%2A%2A%2A%2A%2A"), Equals, true)
+	c.Assert(strings.Contains(logger.messages[1], "security-token=%2A%2A%2A%2A%2A%2A"), Equals, true)
+
+	// the secrets are never logged
+	for _, message := range logger.messages {
+		c.Assert(strings.Contains(message, "secret"), Equals, false, Commentf(message))
+	}
+
+	// the error
+	logger.messages = nil
+	client, err = New("http://127.0.0.1:1", "ak", "sk", SetLogger(logger))
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectDetailedMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(strings.HasPrefix(logger.messages[len(logger.messages)-1], "ERROR oss: request HEAD http://127.0.0.1:1/mock-bucket/object failed"), Equals, true)
+
+	// the default logger logs nothing, EnableLog logs by the level
+	client, err = New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.isLogging(), Equals, false)
+	client, err = New(server.URL, "ak", "sk", EnableLog(LogInfo))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.isLogging(), Equals, true)
+	c.Assert(client.Config.Logger.(*levelLogger).level, Equals, LogInfo)
+	client, err = New(server.URL, "ak", "sk", EnableLog(LogDebug), EnableLog(LogOff))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.isLogging(), Equals, false)
+}
+
+func (s *OssConnSuite) TestTransportSettings(c *C) {
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+	transport := client.Conn.client.Transport.(*http.Transport)
+	c.Assert(transport.MaxIdleConns, Equals, 100)
+	c.Assert(transport.MaxIdleConnsPerHost, Equals, 100)
+	c.Assert(transport.IdleConnTimeout, Equals, 50*time.Second)
+
+	// the zero fields keep the defaults
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk",
+		TransportSettings(TransportConfig{MaxIdleConnsPerHost: 500, IdleConnTimeout: time.Minute}))
+	c.Assert(err, IsNil)
+	transport = client.Conn.client.Transport.(*http.Transport)
+	c.Assert(transport.MaxIdleConns, Equals, 100)
+	c.Assert(transport.MaxIdleConnsPerHost, Equals, 500)
+	c.Assert(transport.IdleConnTimeout, Equals, time.Minute)
+
+	// the custom client is not changed
+	httpClient := &http.Client{Transport: &http.Transport{}}
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk", HTTPClient(httpClient),
+		TransportSettings(TransportConfig{MaxIdleConnsPerHost: 500}))
+	c.Assert(err, IsNil)
+	c.Assert(client.Conn.client.Transport.(*http.Transport).MaxIdleConnsPerHost, Equals, 0)
+}
+
+// BenchmarkTransportSettings compares the client shared by 256 goroutines with the idle connections per host of
+// http.DefaultTransport and of the SDK's default, the new connections are logged, run it by
+// "go test -run NONE -bench TransportSettings".
+func BenchmarkTransportSettings(b *testing.B) {
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderContentLength, "0")
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, perHost := range []int{2, 100} {
+		b.Run(fmt.Sprintf("MaxIdleConnsPerHost-%d", perHost), func(b *testing.B) {
+			client, err := New(server.URL, "ak", "sk", TransportSettings(TransportConfig{MaxIdleConnsPerHost: perHost}))
+			if err != nil {
+				b.Fatal(err)
+			}
+			bucket, _ := client.Bucket("mock-bucket")
+			atomic.StoreInt64(&conns, 0)
+			b.SetParallelism(256 / runtime.GOMAXPROCS(0))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := bucket.GetObjectMeta("object"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+			b.Logf("%d requests on %d new connections", b.N, atomic.LoadInt64(&conns))
+			client.Conn.client.Transport.(*http.Transport).CloseIdleConnections()
+		})
+	}
+}
@@ -42,6 +42,76 @@ const (
 
 	// StorageArchive archive
 	StorageArchive StorageClassType = "Archive"
+
+	// StorageColdArchive cold archive, it's restored by the tiers
+	StorageColdArchive StorageClassType = "ColdArchive"
+)
+
+// RestoreTierType The restore priority of the ColdArchive object
+type RestoreTierType string
+
+const (
+	// RestoreExpedited restored in one hour
+	RestoreExpedited RestoreTierType = "Expedited"
+
+	// RestoreStandard restored in 2 to 5 hours
+	RestoreStandard RestoreTierType = "Standard"
+
+	// RestoreBulk restored in 5 to 12 hours
+	RestoreBulk RestoreTierType = "Bulk"
+)
+
+// PayerType the payer of the requests and the traffic of the bucket
+type PayerType string
+
+const (
+	// Requester the requester pays
+	Requester PayerType = "Requester"
+
+	// BucketOwner the bucket owner pays, it's the default
+	BucketOwner PayerType = "BucketOwner"
+)
+
+// VersioningStatus Bucket versioning status
+type VersioningStatus string
+
+const (
+	// VersionEnabled versioning is enabled
+	VersionEnabled VersioningStatus = "Enabled"
+
+	// VersionSuspended versioning is suspended
+	VersionSuspended VersioningStatus = "Suspended"
+)
+
+// LegalHoldStatus the legal hold status of the object
+type LegalHoldStatus string
+
+const (
+	// LegalHoldOn the object is under the legal hold, it can not be deleted or overwritten
+	LegalHoldOn LegalHoldStatus = "ON"
+
+	// LegalHoldOff the object is not under the legal hold
+	LegalHoldOff LegalHoldStatus = "OFF"
+)
+
+// The server side encryption algorithms
+const (
+	// SSEAES256 the data is encrypted by AES256 with the key managed by OSS
+	SSEAES256 = "AES256"
+
+	// SSEKMS the data is encrypted with the key managed by KMS
+	SSEKMS = "KMS"
+)
+
+// SignHashType the HMAC hash function of the signature
+type SignHashType string
+
+const (
+	// SignHashSHA1 HMAC-SHA1, it's the hash of OSS
+	SignHashSHA1 SignHashType = "HmacSHA1"
+
+	// SignHashSHA256 HMAC-SHA256, it's for the OSS compatible gateways which expect it with the same string to sign
+	SignHashSHA256 SignHashType = "HmacSHA256"
 )
 
 // HTTPMethod HTTP request method
@@ -81,6 +151,7 @@ const (
 	HTTPHeaderHost                      = "Host"
 	HTTPHeaderLastModified              = "Last-Modified"
 	HTTPHeaderRange                     = "Range"
+	HTTPHeaderRetryAfter                = "Retry-After"
 	HTTPHeaderLocation                  = "Location"
 	HTTPHeaderOrigin                    = "Origin"
 	HTTPHeaderServer                    = "Server"
@@ -90,11 +161,20 @@ const (
 	HTTPHeaderIfMatch                   = "If-Match"
 	HTTPHeaderIfNoneMatch               = "If-None-Match"
 
+	HTTPHeaderAccessControlRequestMethod  = "Access-Control-Request-Method"
+	HTTPHeaderAccessControlRequestHeaders = "Access-Control-Request-Headers"
+	HTTPHeaderAccessControlAllowOrigin    = "Access-Control-Allow-Origin"
+	HTTPHeaderAccessControlAllowMethods   = "Access-Control-Allow-Methods"
+	HTTPHeaderAccessControlAllowHeaders   = "Access-Control-Allow-Headers"
+	HTTPHeaderAccessControlExposeHeaders  = "Access-Control-Expose-Headers"
+	HTTPHeaderAccessControlMaxAge         = "Access-Control-Max-Age"
+
 	HTTPHeaderOssACL                         = "X-Oss-Acl"
 	HTTPHeaderOssMetaPrefix                  = "X-Oss-Meta-"
 	HTTPHeaderOssObjectACL                   = "X-Oss-Object-Acl"
 	HTTPHeaderOssSecurityToken               = "X-Oss-Security-Token"
 	HTTPHeaderOssServerSideEncryption        = "X-Oss-Server-Side-Encryption"
+	HTTPHeaderOssServerSideEncryptionKeyID   = "X-Oss-Server-Side-Encryption-Key-Id"
 	HTTPHeaderOssCopySource                  = "X-Oss-Copy-Source"
 	HTTPHeaderOssCopySourceRange             = "X-Oss-Copy-Source-Range"
 	HTTPHeaderOssCopySourceIfMatch           = "X-Oss-Copy-Source-If-Match"
@@ -106,6 +186,16 @@ const (
 	HTTPHeaderOssRequestID                   = "X-Oss-Request-Id"
 	HTTPHeaderOssCRC64                       = "X-Oss-Hash-Crc64ecma"
 	HTTPHeaderOssSymlinkTarget               = "X-Oss-Symlink-Target"
+	HTTPHeaderOssObjectType                  = "X-Oss-Object-Type"
+	HTTPHeaderOssStorageClass                = "X-Oss-Storage-Class"
+	HTTPHeaderOssTagging                     = "X-Oss-Tagging"
+	HTTPHeaderOssTaggingCount                = "X-Oss-Tagging-Count"
+	HTTPHeaderOssRequester                   = "X-Oss-Request-Payer"
+	HTTPHeaderOssVersionID                   = "X-Oss-Version-Id"
+	HTTPHeaderOssForbidOverwrite             = "X-Oss-Forbid-Overwrite"
+	HTTPHeaderOssDeleteMarker                = "X-Oss-Delete-Marker"
+	HTTPHeaderOssTrafficLimit                = "X-Oss-Traffic-Limit"
+	HTTPHeaderOssErr                         = "X-Oss-Err" // the base64 encoded error body of the response without the body, such as HEAD
 )
 
 // Http Param
@@ -114,12 +204,21 @@ const (
 	HTTPParamAccessKeyID   = "OSSAccessKeyId"
 	HTTPParamSignature     = "Signature"
 	HTTPParamSecurityToken = "security-token"
+	HTTPParamVersionID     = "versionId"
 )
 
 // other constants
 const (
 	MaxPartSize = 5 * 1024 * 1024 * 1024 // max part size，5GB
 	MinPartSize = 100 * 1024             // min part size，100KB.
+	MaxPartNum  = 10000                  // max part count of a multipart upload
+
+	MaxCopyObjectSize = 5 * 1024 * 1024 * 1024 // max source object size of CopyObject, 5GB
+
+	MaxDeleteObjectsKeys = 1000 // max object count of a DeleteObjects request
+
+	MinTrafficLimit = 100 * 1024 * 8        // min bits per second of TrafficLimit, 100KB/s
+	MaxTrafficLimit = 100 * 1024 * 1024 * 8 // max bits per second of TrafficLimit, 100MB/s
 
 	FilePermMode = os.FileMode(0664) // default file permission
 
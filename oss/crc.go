@@ -42,3 +42,67 @@ func (d *digest) Sum(in []byte) []byte {
 	s := d.Sum64()
 	return append(in, byte(s>>56), byte(s>>48), byte(s>>40), byte(s>>32), byte(s>>24), byte(s>>16), byte(s>>8), byte(s))
 }
+
+// CRC64Combine combines the CRC-64 ECMA of two consecutive blocks, it's the CRC of the block1 followed by the block2.
+// crc1 and crc2 are the CRCs of the two blocks, len2 is the length of the block2.
+func CRC64Combine(crc1 uint64, crc2 uint64, len2 uint64) uint64 {
+	if len2 == 0 {
+		return crc1
+	}
+
+	// the operators of the zero bits
+	var even [64]uint64
+	var odd [64]uint64
+
+	// the operator for one zero bit in odd
+	odd[0] = crc64.ECMA
+	row := uint64(1)
+	for n := 1; n < 64; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+
+	// the operator for two zero bits in even, then for four zero bits in odd
+	gf2MatrixSquare(&even, &odd)
+	gf2MatrixSquare(&odd, &even)
+
+	// applies len2 zeros to crc1, the first square puts the operator for one zero byte in even
+	for {
+		gf2MatrixSquare(&even, &odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+
+		gf2MatrixSquare(&odd, &even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(&odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+
+	return crc1 ^ crc2
+}
+
+func gf2MatrixTimes(mat *[64]uint64, vec uint64) uint64 {
+	var sum uint64
+	for i := 0; vec != 0; i++ {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+		vec >>= 1
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square *[64]uint64, mat *[64]uint64) {
+	for n := 0; n < 64; n++ {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
@@ -396,3 +396,13 @@ func (s *OssCrcSuite) TestAppendObjectNegative(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(strings.HasPrefix(err.Error(), "oss: the crc"), Equals, true)
 }
+
+// TestCRC64Combine combines the CRCs of the consecutive blocks
+func (s *OssCrcSuite) TestCRC64Combine(c *C) {
+	data := []byte("Go is an open source programming language that makes it easy to build simple, reliable, and efficient software.")
+	for _, n := range []int{0, 1, 7, 8, 50, len(data)} {
+		crc1 := crc64.Checksum(data[:n], crcTable())
+		crc2 := crc64.Checksum(data[n:], crcTable())
+		c.Assert(CRC64Combine(crc1, crc2, uint64(len(data)-n)), Equals, crc64.Checksum(data, crcTable()))
+	}
+}
@@ -0,0 +1,138 @@
+package oss
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialsRetriever is the CredentialsProvider which may fail to get the credentials, such as the one getting
+// them from the remote service. The request fails with the error of Retrieve instead of being sent unsigned.
+type CredentialsRetriever interface {
+	CredentialsProvider
+	Retrieve() (accessKeyID, accessKeySecret, securityToken string, err error)
+}
+
+const (
+	// the ECS metadata url of the credentials of the RAM role, the role name is appended
+	ecsRAMRoleCredentialsURL = "http://100.100.100.200/latest/meta-data/ram/security-credentials/"
+
+	// the credentials are refreshed the time before they expire, so that the requests signed by them do not expire
+	// on the way
+	ecsRAMRoleRefreshAhead = 5 * time.Minute
+)
+
+// ecsRAMRoleCredentials the credentials of the RAM role from the ECS metadata
+type ecsRAMRoleCredentials struct {
+	Code            string `json:"Code"` // it's "Success" if the credentials are got
+	AccessKeyID     string `json:"AccessKeyId"`
+	AccessKeySecret string `json:"AccessKeySecret"`
+	SecurityToken   string `json:"SecurityToken"`
+	Expiration      string `json:"Expiration"` // the UTC time in ISO8601, such as "2017-11-01T05:20:01Z"
+}
+
+// EcsRAMRoleCredentialsProvider provides the temporary credentials of the RAM role of the ECS instance, which are got
+// from the ECS metadata service. They're cached and refreshed shortly before they expire, so the long-term keys are
+// not needed on ECS. It's safe for the concurrent use.
+type EcsRAMRoleCredentialsProvider struct {
+	roleName   string
+	url        string // the metadata url of the credentials
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	credentials ecsRAMRoleCredentials
+	expiration  time.Time // the expiration of the cached credentials, zero if none is cached
+}
+
+//
+// NewEcsRAMRoleCredentialsProvider Creates the provider of the credentials of the RAM role attached to the ECS instance.
+//
+// roleName  the name of the RAM role.
+//
+// *EcsRAMRoleCredentialsProvider  the provider to set by SetCredentialsProvider.
+//
+func NewEcsRAMRoleCredentialsProvider(roleName string) *EcsRAMRoleCredentialsProvider {
+	return &EcsRAMRoleCredentialsProvider{
+		roleName:   roleName,
+		url:        ecsRAMRoleCredentialsURL + roleName,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+//
+// Retrieve Gets the credentials of the RAM role, the cached ones are used until shortly before they expire.
+//
+// The cached credentials not expired yet are still used if the refresh fails.
+//
+// error  it's nil if no error; otherwise it's the error of getting the credentials from the ECS metadata.
+//
+func (provider *EcsRAMRoleCredentialsProvider) Retrieve() (string, string, string, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	now := time.Now()
+	if now.Add(ecsRAMRoleRefreshAhead).Before(provider.expiration) {
+		return provider.cached()
+	}
+
+	credentials, expiration, err := provider.fetch()
+	if err != nil {
+		if now.Before(provider.expiration) {
+			return provider.cached()
+		}
+		return "", "", "", err
+	}
+	provider.credentials = credentials
+	provider.expiration = expiration
+	return provider.cached()
+}
+
+// GetCredentials gets the credentials of the RAM role, they're empty if Retrieve fails
+func (provider *EcsRAMRoleCredentialsProvider) GetCredentials() (string, string, string) {
+	accessKeyID, accessKeySecret, securityToken, _ := provider.Retrieve()
+	return accessKeyID, accessKeySecret, securityToken
+}
+
+// cached gets the cached credentials
+func (provider *EcsRAMRoleCredentialsProvider) cached() (string, string, string, error) {
+	return provider.credentials.AccessKeyID, provider.credentials.AccessKeySecret, provider.credentials.SecurityToken, nil
+}
+
+// fetch gets the credentials and their expiration from the ECS metadata
+func (provider *EcsRAMRoleCredentialsProvider) fetch() (ecsRAMRoleCredentials, time.Time, error) {
+	var credentials ecsRAMRoleCredentials
+	resp, err := provider.httpClient.Get(provider.url)
+	if err != nil {
+		return credentials, time.Time{}, fmt.Errorf("oss: failed to get the credentials of the ECS RAM role %s, %s",
+			provider.roleName, err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return credentials, time.Time{}, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return credentials, time.Time{}, fmt.Errorf("oss: failed to get the credentials of the ECS RAM role %s, "+
+			"the metadata responds %s: %s", provider.roleName, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err = json.Unmarshal(body, &credentials); err != nil {
+		return credentials, time.Time{}, fmt.Errorf("oss: failed to parse the credentials of the ECS RAM role %s, %s",
+			provider.roleName, err.Error())
+	}
+	if credentials.Code != "Success" || credentials.AccessKeyID == "" || credentials.AccessKeySecret == "" {
+		return credentials, time.Time{}, fmt.Errorf("oss: failed to get the credentials of the ECS RAM role %s, "+
+			"the code is %q", provider.roleName, credentials.Code)
+	}
+	expiration, err := time.Parse(time.RFC3339, credentials.Expiration)
+	if err != nil {
+		return credentials, time.Time{}, fmt.Errorf("oss: invalid expiration %q of the credentials of the ECS RAM role %s",
+			credentials.Expiration, provider.roleName)
+	}
+	return credentials, expiration, nil
+}
@@ -0,0 +1,121 @@
+package oss
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// mockEcsMetadata serves the credentials of the RAM role "role" like the ECS metadata
+type mockEcsMetadata struct {
+	calls      int
+	status     int           // the status to respond, 200 if it's 0
+	expiration time.Duration // the credentials expire in the duration
+}
+
+func (m *mockEcsMetadata) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.calls++
+	if r.URL.Path != "/latest/meta-data/ram/security-credentials/role" {
+		http.NotFound(w, r)
+		return
+	}
+	if m.status != 0 {
+		w.WriteHeader(m.status)
+		w.Write([]byte("forbidden"))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"AccessKeyId":     "STS.ak",
+		"AccessKeySecret": "sk",
+		"SecurityToken":   "token",
+		"Expiration":      time.Now().Add(m.expiration).UTC().Format(time.RFC3339),
+		"LastUpdated":     time.Now().UTC().Format(time.RFC3339),
+		"Code":            "Success",
+	})
+}
+
+func newMockEcsProvider(metadata *mockEcsMetadata, roleName string) (*EcsRAMRoleCredentialsProvider, *httptest.Server) {
+	server := httptest.NewServer(metadata)
+	provider := NewEcsRAMRoleCredentialsProvider(roleName)
+	provider.url = strings.Replace(provider.url, "http://100.100.100.200", server.URL, 1)
+	return provider, server
+}
+
+func (s *OssConnSuite) TestEcsRAMRoleCredentialsProvider(c *C) {
+	metadata := &mockEcsMetadata{expiration: time.Hour}
+	provider, server := newMockEcsProvider(metadata, "role")
+	defer server.Close()
+
+	// the credentials are cached
+	for i := 0; i < 3; i++ {
+		id, secret, token, err := provider.Retrieve()
+		c.Assert(err, IsNil)
+		c.Assert([]string{id, secret, token}, DeepEquals, []string{"STS.ak", "sk", "token"})
+	}
+	c.Assert(metadata.calls, Equals, 1)
+
+	// they're refreshed shortly before they expire
+	provider.expiration = time.Now().Add(time.Minute)
+	_, _, _, err := provider.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(metadata.calls, Equals, 2)
+
+	// the cached ones not expired are used if the refresh fails
+	metadata.status = http.StatusForbidden
+	provider.expiration = time.Now().Add(time.Minute)
+	id, _, _, err := provider.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, "STS.ak")
+	c.Assert(metadata.calls, Equals, 3)
+
+	// the error after they expire
+	provider.expiration = time.Now().Add(-time.Second)
+	_, _, _, err = provider.Retrieve()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "ECS RAM role role, the metadata responds 403 Forbidden: forbidden"), Equals, true)
+	id, secret, token := provider.GetCredentials()
+	c.Assert([]string{id, secret, token}, DeepEquals, []string{"", "", ""})
+
+	// the unknown role
+	unknown, server := newMockEcsProvider(&mockEcsMetadata{}, "unknown")
+	defer server.Close()
+	_, _, _, err = unknown.Retrieve()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "404 Not Found"), Equals, true)
+}
+
+func (s *OssConnSuite) TestEcsRAMRoleCredentialsRequest(c *C) {
+	metadata := &mockEcsMetadata{expiration: time.Hour}
+	provider, metadataServer := newMockEcsProvider(metadata, "role")
+	defer metadataServer.Close()
+
+	var auths, tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get(HTTPHeaderAuthorization))
+		tokens = append(tokens, r.Header.Get(HTTPHeaderOssSecurityToken))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "", "", SetCredentialsProvider(provider))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(auths[0], "OSS STS.ak:"), Equals, true)
+	c.Assert(tokens, DeepEquals, []string{"token"})
+
+	// the request and the signed url fail with the error of the metadata, nothing is sent
+	metadata.status = http.StatusForbidden
+	provider.expiration = time.Time{}
+	_, err = bucket.GetObjectMeta("object")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "ECS RAM role"), Equals, true)
+	_, err = bucket.SignURL("object", HTTPGet, 60)
+	c.Assert(err, NotNil)
+	c.Assert(len(auths), Equals, 1)
+}
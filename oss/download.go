@@ -1,12 +1,14 @@
 package oss
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/crc64"
 	"io"
-	"io/ioutil"
 	"os"
 	"strconv"
 )
@@ -14,6 +16,9 @@ import (
 //
 // DownloadFile Download files with multipart download
 //
+// The parts are downloaded concurrently and written at their offsets of the file, so they complete in any order.
+// When the whole object is downloaded with CRC enabled, the CRC64 of the file is checked against the object's.
+//
 // objectKey  object key。
 // filePath   local file to download from objectKey in OSS
 // partSize   The part size in bytes.
@@ -26,7 +31,7 @@ func (bucket Bucket) DownloadFile(objectKey, filePath string, partSize int64, op
 		return errors.New("oss: part size smaller than 1.")
 	}
 
-	cpConf, err := getCpConfig(options, filePath)
+	cpConf, err := getCpConfig(options, filePath+CheckpointFileSuffix)
 	if err != nil {
 		return err
 	}
@@ -45,6 +50,16 @@ func (bucket Bucket) DownloadFile(objectKey, filePath string, partSize int64, op
 	return bucket.downloadFile(objectKey, filePath, partSize, options, routines, uRange)
 }
 
+//
+// DownloadFileWithContext Downloads the object by multipart download bound to the context, checks out DownloadFile for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) DownloadFileWithContext(ctx context.Context, objectKey, filePath string, partSize int64, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DownloadFile(objectKey, filePath, partSize, options...)
+}
+
 // gets the download range from the options.
 func getRangeConfig(options []Option) (*unpackedRange, error) {
 	rangeOpt, err := findOption(options, HTTPHeaderRange, nil)
@@ -58,11 +73,11 @@ func getRangeConfig(options []Option) (*unpackedRange, error) {
 
 // download worker's parameters
 type downloadWorkerArg struct {
-	bucket   *Bucket
-	key      string
-	filePath string
-	options  []Option
-	hook     downloadPartHook
+	bucket  *Bucket
+	key     string
+	file    io.WriterAt // the parts are written at their offsets concurrently
+	options []Option
+	hook    downloadPartHook
 }
 
 // Hook for test
@@ -85,55 +100,61 @@ func (listener *defaultDownloadProgressListener) ProgressChanged(event *Progress
 // download worker
 func downloadWorker(id int, arg downloadWorkerArg, jobs <-chan downloadPart, results chan<- downloadPart, failed chan<- error, die <-chan bool) {
 	for part := range jobs {
-		if err := arg.hook(part); err != nil {
-			failed <- err
-			break
+		err := arg.hook(part)
+		if err == nil {
+			part.CRC64, err = downloadPartTo(arg, part)
 		}
-
-		// resolve options
-		r := Range(part.Start, part.End)
-		p := Progress(&defaultDownloadProgressListener{})
-		opts := make([]Option, len(arg.options)+2)
-		// append orderly, can not be reversed!
-		opts = append(opts, arg.options...)
-		opts = append(opts, r, p)
-
-		rd, err := arg.bucket.GetObject(arg.key, opts...)
 		if err != nil {
-			failed <- err
-			break
+			select {
+			case failed <- err:
+			case <-die:
+			}
+			return
 		}
-		defer rd.Close()
 
 		select {
 		case <-die:
 			return
-		default:
+		case results <- part:
 		}
+	}
+}
 
-		fd, err := os.OpenFile(arg.filePath, os.O_WRONLY, FilePermMode)
-		if err != nil {
-			failed <- err
-			break
-		}
+// downloadPartTo downloads the part and writes it at its offset of the file, it returns the CRC64 of the part
+func downloadPartTo(arg downloadWorkerArg, part downloadPart) (uint64, error) {
+	// append orderly, can not be reversed!
+	opts := make([]Option, 0, len(arg.options)+2)
+	opts = append(opts, arg.options...)
+	opts = append(opts, Range(part.Start, part.End), Progress(&defaultDownloadProgressListener{}))
 
-		_, err = fd.Seek(part.Start-part.Offset, os.SEEK_SET)
-		if err != nil {
-			fd.Close()
-			failed <- err
-			break
-		}
-
-		_, err = io.Copy(fd, rd)
-		if err != nil {
-			fd.Close()
-			failed <- err
-			break
-		}
+	rd, err := arg.bucket.GetObject(arg.key, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer rd.Close()
 
-		fd.Close()
-		results <- part
+	crc := crc64.New(crcTable())
+	w := &offsetWriter{w: arg.file, offset: part.Start - part.Offset}
+	written, err := copyBuffer(io.MultiWriter(w, crc), rd, arg.bucket.getConfig().IOBufferSize)
+	if err != nil {
+		return 0, err
 	}
+	if size := part.End - part.Start + 1; written != size {
+		return 0, fmt.Errorf("oss: the part %d is %d bytes, %d bytes are downloaded", part.Index, size, written)
+	}
+	return crc.Sum64(), nil
+}
+
+// offsetWriter writes to the WriterAt from the offset sequentially
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	return n, err
 }
 
 // download scheduler
@@ -146,25 +167,16 @@ func downloadScheduler(jobs chan downloadPart, parts []downloadPart) {
 
 // download part
 type downloadPart struct {
-	Index  int   // part number, starting from 0
-	Start  int64 // start index
-	End    int64 // end index
-	Offset int64 // offset
+	Index  int    // part number, starting from 0
+	Start  int64  // start index
+	End    int64  // end index
+	Offset int64  // offset
+	CRC64  uint64 // the CRC64 of the part downloaded
 }
 
 // get download parts
-func getDownloadParts(bucket *Bucket, objectKey string, partSize int64, uRange *unpackedRange) ([]downloadPart, error) {
-	meta, err := bucket.GetObjectDetailedMeta(objectKey)
-	if err != nil {
-		return nil, err
-	}
-
+func getDownloadParts(objectSize, partSize int64, uRange *unpackedRange) []downloadPart {
 	parts := []downloadPart{}
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 0)
-	if err != nil {
-		return nil, err
-	}
-
 	part := downloadPart{}
 	i := 0
 	start, end := adjustRange(uRange, objectSize)
@@ -176,7 +188,24 @@ func getDownloadParts(bucket *Bucket, objectKey string, partSize int64, uRange *
 		parts = append(parts, part)
 		i++
 	}
-	return parts, nil
+	return parts
+}
+
+// checks the CRC64 of the file combined from the parts against the object's, it's checked only when the whole object is downloaded
+func checkDownloadCRC(bucket *Bucket, parts []downloadPart, objectSize int64, serverCRC string) error {
+	if !bucket.getConfig().IsEnableCRC || serverCRC == "" || getObjectBytes(parts) != objectSize {
+		return nil
+	}
+
+	var clientCRC uint64
+	for _, part := range parts {
+		clientCRC = CRC64Combine(clientCRC, part.CRC64, uint64(part.End-part.Start+1))
+	}
+	srvCRC, _ := strconv.ParseUint(serverCRC, 10, 64)
+	if clientCRC != srvCRC {
+		return CRCCheckError{clientCRC, srvCRC, "DownloadFile", ""}
+	}
+	return nil
 }
 
 // get object bytes length
@@ -193,18 +222,23 @@ func (bucket Bucket) downloadFile(objectKey, filePath string, partSize int64, op
 	tempFilePath := filePath + TempFileSuffix
 	listener := getProgressListener(options)
 
-	// If the file does not exist, create one. If exists, the download will overwrite it.
-	fd, err := os.OpenFile(tempFilePath, os.O_WRONLY|os.O_CREATE, FilePermMode)
+	// gets the parts of the file
+	meta, err := bucket.GetObjectDetailedMeta(objectKey)
+	if err != nil {
+		return err
+	}
+	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 0)
 	if err != nil {
 		return err
 	}
-	fd.Close()
+	parts := getDownloadParts(objectSize, partSize, uRange)
 
-	// gets the parts of the file
-	parts, err := getDownloadParts(&bucket, objectKey, partSize, uRange)
+	// If the file does not exist, create one. If exists, the download will overwrite it.
+	fd, err := os.OpenFile(tempFilePath, os.O_WRONLY|os.O_CREATE, FilePermMode)
 	if err != nil {
 		return err
 	}
+	defer fd.Close()
 
 	jobs := make(chan downloadPart, len(parts))
 	results := make(chan downloadPart, len(parts))
@@ -217,7 +251,7 @@ func (bucket Bucket) downloadFile(objectKey, filePath string, partSize int64, op
 	publishProgress(listener, event)
 
 	// start the download workers
-	arg := downloadWorkerArg{&bucket, objectKey, tempFilePath, options, downloadPartHooker}
+	arg := downloadWorkerArg{&bucket, objectKey, fd, options, downloadPartHooker}
 	for w := 1; w <= routines; w++ {
 		go downloadWorker(w, arg, jobs, results, failed, die)
 	}
@@ -251,6 +285,13 @@ func (bucket Bucket) downloadFile(objectKey, filePath string, partSize int64, op
 	event = newProgressEvent(TransferCompletedEvent, completedBytes, totalBytes)
 	publishProgress(listener, event)
 
+	if err = fd.Close(); err != nil {
+		return err
+	}
+	if err = checkDownloadCRC(&bucket, ps, objectSize, meta.Get(HTTPHeaderOssCRC64)); err != nil {
+		os.Remove(tempFilePath)
+		return err
+	}
 	return os.Rename(tempFilePath, filePath)
 }
 
@@ -264,8 +305,8 @@ type downloadCheckpoint struct {
 	FilePath string         // local file
 	Object   string         // key
 	ObjStat  objectStat     // object status
-	Parts    []downloadPart // all download parts
-	PartStat []bool         // parts' download status
+	Parts    []downloadPart // all download parts, the byte ranges with their CRC64 once downloaded
+	PartStat []bool         // parts' download status, only the parts not completed are downloaded on resume
 	Start    int64          // start point of the file
 	End      int64          // end point of the file
 }
@@ -274,6 +315,7 @@ type objectStat struct {
 	Size         int64  // object size
 	LastModified string // last modified time
 	Etag         string // etag
+	CRC64        string // the object's CRC64, it's empty if OSS does not return it
 }
 
 // flag of CP data is valid. return true when the data is valid and the checkpoint is valid and the object is not updated.
@@ -319,8 +361,8 @@ func (cp downloadCheckpoint) isValid(bucket *Bucket, objectKey string, uRange *u
 }
 
 // load CP from local file
-func (cp *downloadCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
+func (cp *downloadCheckpoint) load(store CheckpointStore, key string) error {
+	contents, err := store.Load(key)
 	if err != nil {
 		return err
 	}
@@ -330,7 +372,7 @@ func (cp *downloadCheckpoint) load(filePath string) error {
 }
 
 // dump to file
-func (cp *downloadCheckpoint) dump(filePath string) error {
+func (cp *downloadCheckpoint) dump(store CheckpointStore, key string) error {
 	bcp := *cp
 
 	// calculate MD5
@@ -350,7 +392,7 @@ func (cp *downloadCheckpoint) dump(filePath string) error {
 	}
 
 	// dump
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return store.Save(key, js)
 }
 
 // gets unfinished parts
@@ -396,12 +438,10 @@ func (cp *downloadCheckpoint) prepare(bucket *Bucket, objectKey, filePath string
 	cp.ObjStat.Size = objectSize
 	cp.ObjStat.LastModified = meta.Get(HTTPHeaderLastModified)
 	cp.ObjStat.Etag = meta.Get(HTTPHeaderEtag)
+	cp.ObjStat.CRC64 = meta.Get(HTTPHeaderOssCRC64)
 
 	// parts
-	cp.Parts, err = getDownloadParts(bucket, objectKey, partSize, uRange)
-	if err != nil {
-		return err
-	}
+	cp.Parts = getDownloadParts(objectSize, partSize, uRange)
 	cp.PartStat = make([]bool, len(cp.Parts))
 	for i := range cp.PartStat {
 		cp.PartStat[i] = false
@@ -410,8 +450,14 @@ func (cp *downloadCheckpoint) prepare(bucket *Bucket, objectKey, filePath string
 	return nil
 }
 
-func (cp *downloadCheckpoint) complete(cpFilePath, downFilepath string) error {
-	os.Remove(cpFilePath)
+func (cp *downloadCheckpoint) complete(bucket *Bucket, cpStore CheckpointStore, cpFilePath, downFilepath string) error {
+	// the corrupted file is downloaded again from the start
+	if err := checkDownloadCRC(bucket, cp.Parts, cp.ObjStat.Size, cp.ObjStat.CRC64); err != nil {
+		cpStore.Delete(cpFilePath)
+		os.Remove(downFilepath)
+		return err
+	}
+	cpStore.Delete(cpFilePath)
 	return os.Rename(downFilepath, cp.FilePath)
 }
 
@@ -419,12 +465,13 @@ func (cp *downloadCheckpoint) complete(cpFilePath, downFilepath string) error {
 func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpFilePath string, routines int, uRange *unpackedRange) error {
 	tempFilePath := filePath + TempFileSuffix
 	listener := getProgressListener(options)
+	cpStore := getCpStore(options)
 
 	// LOAD CP data
 	dcp := downloadCheckpoint{}
-	err := dcp.load(cpFilePath)
+	err := dcp.load(cpStore, cpFilePath)
 	if err != nil {
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	// LOAD error or data invalid. Re-initialize the download
@@ -433,7 +480,7 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 		if err = dcp.prepare(&bucket, objectKey, filePath, partSize, uRange); err != nil {
 			return err
 		}
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	// Creates the file if not exists. Otherwise the parts download will overwrite it
@@ -441,7 +488,7 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 	if err != nil {
 		return err
 	}
-	fd.Close()
+	defer fd.Close()
 
 	// unfinished parts
 	parts := dcp.todoParts()
@@ -455,7 +502,7 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 	publishProgress(listener, event)
 
 	// starts the download workers
-	arg := downloadWorkerArg{&bucket, objectKey, tempFilePath, options, downloadPartHooker}
+	arg := downloadWorkerArg{&bucket, objectKey, fd, options, downloadPartHooker}
 	for w := 1; w <= routines; w++ {
 		go downloadWorker(w, arg, jobs, results, failed, die)
 	}
@@ -464,18 +511,25 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 	go downloadScheduler(jobs, parts)
 
 	// waits for the parts download finished
+	batcher := getCpDumpBatcher(options)
 	completed := 0
 	for completed < len(parts) {
 		select {
 		case part := <-results:
 			completed++
+			dcp.Parts[part.Index] = part
 			dcp.PartStat[part.Index] = true
-			dcp.dump(cpFilePath)
+			if batcher.next() {
+				dcp.dump(cpStore, cpFilePath)
+			}
 			completedBytes += (part.End - part.Start + 1)
 			event = newProgressEvent(TransferDataEvent, completedBytes, dcp.ObjStat.Size)
 			publishProgress(listener, event)
 		case err := <-failed:
 			close(die)
+			if batcher.flush() {
+				dcp.dump(cpStore, cpFilePath)
+			}
 			event = newProgressEvent(TransferFailedEvent, completedBytes, dcp.ObjStat.Size)
 			publishProgress(listener, event)
 			return err
@@ -486,8 +540,15 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 		}
 	}
 
+	if batcher.flush() {
+		dcp.dump(cpStore, cpFilePath)
+	}
+
 	event = newProgressEvent(TransferCompletedEvent, completedBytes, dcp.ObjStat.Size)
 	publishProgress(listener, event)
 
-	return dcp.complete(cpFilePath, tempFilePath)
+	if err = fd.Close(); err != nil {
+		return err
+	}
+	return dcp.complete(&bucket, cpStore, cpFilePath, tempFilePath)
 }
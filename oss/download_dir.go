@@ -0,0 +1,121 @@
+package oss
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// the prefix of the staging directory created under the local directory by DownloadDir with StageDownloads
+const downloadStagingPrefix = ".oss-download-"
+
+//
+// DownloadDir Downloads the objects under the key prefix into the local directory.
+//
+// The file path of an object is its key relative to keyPrefix under localDir, so keyPrefix should end with "/" to
+// download a folder. The folder objects ending with "/" are skipped. The objects are downloaded one by one by
+// GetObjectToFile, so each file is written completely or not at all, while the files downloaded before a failure
+// are kept. With StageDownloads(true) all the files are downloaded into a staging directory under localDir first
+// and moved into place only after all of them succeed, the staging directory is removed on the failure.
+//
+// keyPrefix  the key prefix of the objects.
+// localDir   the local directory to download into, it's created if it does not exist.
+// options    the options for downloading the objects, such as the context and RequestPayer. MaxPages bounds the
+//            pages of listing the objects.
+//
+// []string  the keys of the objects downloaded into place. It's valid when error is nil, or the keys downloaded before the error.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) DownloadDir(keyPrefix, localDir string, options ...Option) ([]string, error) {
+	downloaded := []string{}
+	ctxOptions, err := filterOptions(options, isListArg)
+	if err != nil {
+		return downloaded, err
+	}
+
+	remote, err := bucket.listSyncObjects(keyPrefix, ctxOptions)
+	if err != nil {
+		return downloaded, err
+	}
+	keys := []string{}
+	for key := range remote {
+		if !strings.HasSuffix(key, "/") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if err = os.MkdirAll(localDir, 0755); err != nil {
+		return downloaded, err
+	}
+
+	isStage, err := findOption(options, stageDownloads, false)
+	if err != nil {
+		return downloaded, err
+	}
+	if !isStage.(bool) {
+		for _, key := range keys {
+			filePath, err := getDownloadDirPath(localDir, keyPrefix, key)
+			if err != nil {
+				return downloaded, err
+			}
+			if err = bucket.downloadDirFile(key, filePath, options); err != nil {
+				return downloaded, err
+			}
+			downloaded = append(downloaded, key)
+		}
+		return downloaded, nil
+	}
+
+	// the staging directory is under localDir, so the files are moved by rename in the same file system
+	stagingDir, err := ioutil.TempDir(localDir, downloadStagingPrefix)
+	if err != nil {
+		return downloaded, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	for _, key := range keys {
+		stagingPath, err := getDownloadDirPath(stagingDir, keyPrefix, key)
+		if err != nil {
+			return downloaded, err
+		}
+		if err = bucket.downloadDirFile(key, stagingPath, options); err != nil {
+			return downloaded, err
+		}
+	}
+
+	for _, key := range keys {
+		stagingPath, _ := getDownloadDirPath(stagingDir, keyPrefix, key)
+		filePath, _ := getDownloadDirPath(localDir, keyPrefix, key)
+		if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return downloaded, err
+		}
+		if err = os.Rename(stagingPath, filePath); err != nil {
+			return downloaded, err
+		}
+		downloaded = append(downloaded, key)
+	}
+	return downloaded, nil
+}
+
+// downloadDirFile downloads the object into the file, the directory of the file is created
+func (bucket Bucket) downloadDirFile(objectKey, filePath string, options []Option) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return bucket.GetObjectToFile(objectKey, filePath, options...)
+}
+
+// getDownloadDirPath gets the file path of the object under the directory, the object key such as "a/../../b"
+// which leads out of the directory is rejected
+func getDownloadDirPath(dir, keyPrefix, objectKey string) (string, error) {
+	rel := filepath.FromSlash(strings.TrimPrefix(objectKey, keyPrefix))
+	filePath := filepath.Join(dir, rel)
+	if rel == "" || !strings.HasPrefix(filePath, filepath.Clean(dir)+string(filepath.Separator)) {
+		return "", fmt.Errorf("oss: the object %s is out of the directory %s", objectKey, dir)
+	}
+	return filePath, nil
+}
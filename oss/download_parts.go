@@ -0,0 +1,187 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+//
+// DownloadPartsChan Downloads the object by parts concurrently and delivers them in order by the channel.
+//
+// The parts are fetched by the routines in any order, while the ones completed ahead are held until the parts before
+// them are delivered, so the consumer gets the data of the object from the start to the end. At most 2*routines parts
+// are fetched ahead of the consumer, which bounds the memory to hold them.
+//
+// The parts are downloaded with IfMatch the ETag of the object got at the start, so the object changed during the
+// download fails the part with PreconditionFailed instead of mixing the data of the two versions.
+//
+// The channel is closed after the last part, or after the part carrying the error, which is delivered after the parts
+// before the failed one. The consumer stopping early should cancel the context of WithContext, then the routines exit
+// and the channel is closed, it's not necessary to drain the channel.
+//
+// objectKey  the object key.
+// partSize   the part size in bytes.
+// routines   the count of the parts fetched concurrently, it's from 1 to 100.
+// options    the options for getting the object, such as Range, VersionId, WithContext and Progress.
+//
+// <-chan DownloadedPart  the channel of the parts, it's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error of getting the object size.
+//
+func (bucket Bucket) DownloadPartsChan(objectKey string, partSize int64, routines int, options ...Option) (<-chan DownloadedPart, error) {
+	if partSize < 1 {
+		return nil, errors.New("oss: part size smaller than 1.")
+	}
+	if routines < 1 {
+		routines = 1
+	} else if routines > 100 {
+		routines = 100
+	}
+
+	uRange, err := getRangeConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	ctxOptions, err := filterOptions(options, func(key string) bool {
+		return isRequestArg(key) || key == HTTPParamVersionID
+	})
+	if err != nil {
+		return nil, err
+	}
+	meta, err := bucket.GetObjectDetailedMeta(objectKey, ctxOptions...)
+	if err != nil {
+		return nil, err
+	}
+	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan DownloadedPart)
+	parts := getDownloadParts(objectSize, partSize, uRange)
+	go bucket.downloadPartsInOrder(objectKey, meta.Get(HTTPHeaderEtag), parts, routines, options, out)
+	return out, nil
+}
+
+// downloadPartsInOrder fetches the parts of the object with the ETag by the routines and delivers them to out in order,
+// out is closed at the end
+func (bucket Bucket) downloadPartsInOrder(objectKey, etag string, parts []downloadPart, routines int, options []Option,
+	out chan<- DownloadedPart) {
+	defer close(out)
+	ctx := getContext(options)
+	listener := getProgressListener(options)
+	done := make(chan struct{})
+	defer close(done)
+
+	// the window is taken by the part dispatched and released by the part delivered
+	window := make(chan struct{}, 2*routines)
+	jobs := make(chan downloadPart)
+	results := make(chan DownloadedPart)
+	go func() {
+		defer close(jobs)
+		for _, part := range parts {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- part:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < routines; w++ {
+		go func() {
+			for part := range jobs {
+				result := bucket.downloadPartData(objectKey, etag, part, options)
+				select {
+				case results <- result:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	var completedBytes int64
+	totalBytes := getObjectBytes(parts)
+	publishProgress(listener, newProgressEvent(TransferStartedEvent, 0, totalBytes))
+	fail := func(err error) {
+		publishProgress(listener, newProgressEvent(TransferFailedEvent, completedBytes, totalBytes))
+		// the consumer stopping early may not receive the error
+		select {
+		case out <- DownloadedPart{Index: -1, Err: err}:
+		case <-ctx.Done():
+		}
+	}
+
+	held := map[int]DownloadedPart{}
+	for next := 0; next < len(parts); {
+		select {
+		case result := <-results:
+			held[result.Index] = result
+		case <-ctx.Done():
+			fail(ctx.Err())
+			return
+		}
+
+		for part, ok := held[next]; ok; part, ok = held[next] {
+			// the error of the part is delivered in order too, after the parts before it
+			if part.Err != nil {
+				fail(part.Err)
+				return
+			}
+			select {
+			case out <- part:
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			delete(held, next)
+			<-window
+			next++
+			completedBytes += int64(len(part.Data))
+			publishProgress(listener, newProgressEvent(TransferDataEvent, completedBytes, totalBytes))
+		}
+	}
+	publishProgress(listener, newProgressEvent(TransferCompletedEvent, completedBytes, totalBytes))
+}
+
+// downloadPartData gets the data of the part, the object must have the ETag
+func (bucket Bucket) downloadPartData(objectKey, etag string, part downloadPart, options []Option) DownloadedPart {
+	result := DownloadedPart{Index: part.Index, Offset: part.Start}
+
+	// append orderly, can not be reversed!
+	opts := make([]Option, 0, len(options)+3)
+	opts = append(opts, options...)
+	opts = append(opts, Range(part.Start, part.End), Progress(&defaultDownloadProgressListener{}))
+	if etag != "" {
+		opts = append(opts, IfMatch(etag))
+	}
+
+	rd, err := bucket.GetObject(objectKey, opts...)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer rd.Close()
+
+	result.Data, result.Err = ioutil.ReadAll(rd)
+	if size := part.End - part.Start + 1; result.Err == nil && int64(len(result.Data)) != size {
+		result.Err = fmt.Errorf("oss: the part %d is %d bytes, %d bytes are downloaded", part.Index, size, len(result.Data))
+	}
+	return result
+}
+
+//
+// DownloadPartsChanWithContext Downloads the object by parts bound to the context, checks out DownloadPartsChan for the details.
+//
+func (bucket Bucket) DownloadPartsChanWithContext(ctx context.Context, objectKey string, partSize int64, routines int,
+	options ...Option) (<-chan DownloadedPart, error) {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.DownloadPartsChan(objectKey, partSize, routines, options...)
+}
@@ -2,8 +2,18 @@ package oss
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -153,7 +163,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 
 	// check
 	dcp := downloadCheckpoint{}
-	err = dcp.load(newFile + ".cp")
+	err = dcp.load(FileCheckpointStore{}, newFile+".cp")
 	c.Assert(err, IsNil)
 	c.Assert(dcp.Magic, Equals, downloadCpMagic)
 	c.Assert(len(dcp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
@@ -168,7 +178,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 	err = s.bucket.DownloadFile(objectName, newFile, 100*1024, Checkpoint(true, ""))
 	c.Assert(err, IsNil)
 
-	err = dcp.load(newFile + ".cp")
+	err = dcp.load(FileCheckpointStore{}, newFile+".cp")
 	c.Assert(err, NotNil)
 
 	eq, err := compareFiles(fileName, newFile)
@@ -185,7 +195,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 
 	// check
 	dcp = downloadCheckpoint{}
-	err = dcp.load(objectName + ".cp")
+	err = dcp.load(FileCheckpointStore{}, objectName+".cp")
 	c.Assert(err, IsNil)
 	c.Assert(dcp.Magic, Equals, downloadCpMagic)
 	c.Assert(len(dcp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
@@ -200,7 +210,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 	err = s.bucket.DownloadFile(objectName, newFile, 100*1024, Checkpoint(true, objectName+".cp"))
 	c.Assert(err, IsNil)
 
-	err = dcp.load(objectName + ".cp")
+	err = dcp.load(FileCheckpointStore{}, objectName+".cp")
 	c.Assert(err, NotNil)
 
 	eq, err = compareFiles(fileName, newFile)
@@ -212,7 +222,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 	err = s.bucket.DownloadFile(objectName, newFile, 100*1024, Checkpoint(true, ""))
 	c.Assert(err, IsNil)
 
-	err = dcp.load(newFile + ".cp")
+	err = dcp.load(FileCheckpointStore{}, newFile+".cp")
 	c.Assert(err, NotNil)
 
 	eq, err = compareFiles(fileName, newFile)
@@ -224,7 +234,7 @@ func (s *OssDownloadSuite) TestDownloadRoutineWithRecovery(c *C) {
 	err = s.bucket.DownloadFile(objectName, newFile, 100*1024, Routines(10), Checkpoint(true, ""))
 	c.Assert(err, IsNil)
 
-	err = dcp.load(newFile + ".cp")
+	err = dcp.load(FileCheckpointStore{}, newFile+".cp")
 	c.Assert(err, NotNil)
 
 	eq, err = compareFiles(fileName, newFile)
@@ -619,3 +629,231 @@ func compareFilesWithRange(fileL string, offsetL int64, fileR string, offsetR in
 
 	return true, nil
 }
+
+// mockDownloadObject serves the object with the range, it counts the range requests
+type mockDownloadObject struct {
+	data   []byte
+	crc64  string
+	ranges int32
+}
+
+func (object *mockDownloadObject) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Range") != "" {
+		atomic.AddInt32(&object.ranges, 1)
+	}
+	w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+	w.Header().Set(HTTPHeaderOssCRC64, object.crc64)
+	http.ServeContent(w, r, "", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), bytes.NewReader(object.data))
+}
+
+func newMockDownloadObject(size int) *mockDownloadObject {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	return &mockDownloadObject{data: data, crc64: mockCRC64(data)}
+}
+
+// TestDownloadFileOutOfOrder writes the parts completed out of order at their offsets
+func (s *OssBucketMockSuite) TestDownloadFileOutOfOrder(c *C) {
+	object := newMockDownloadObject(1000)
+	bucket, server := newMockBucket(object.ServeHTTP, c)
+	defer server.Close()
+
+	// the first part completes last
+	downloadPartHooker = func(part downloadPart) error {
+		if part.Index == 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return nil
+	}
+	defer func() { downloadPartHooker = defaultDownloadPartHook }()
+
+	filePath := "mock-download-out-of-order.data"
+	defer os.Remove(filePath)
+	err := bucket.DownloadFile("obj", filePath, 100, Routines(4))
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filePath)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, object.data), Equals, true)
+
+	// the range is written from the start of the file
+	err = bucket.DownloadFile("obj", filePath, 100, Routines(4), Range(250, 749))
+	c.Assert(err, IsNil)
+	data, err = ioutil.ReadFile(filePath)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data[:500], object.data[250:750]), Equals, true)
+}
+
+// TestDownloadFileWithCpResume downloads the parts not completed only on resume
+func (s *OssBucketMockSuite) TestDownloadFileWithCpResume(c *C) {
+	object := newMockDownloadObject(1000)
+	bucket, server := newMockBucket(object.ServeHTTP, c)
+	defer server.Close()
+
+	filePath := "mock-download-resume.data"
+	cpKey := "checkpoints/obj"
+	store := &memCheckpointStore{data: map[string][]byte{}}
+	defer os.Remove(filePath)
+	defer os.Remove(filePath + TempFileSuffix)
+
+	downloadPartHooker = func(part downloadPart) error {
+		if part.Index == 6 {
+			return fmt.Errorf("part %d failed", part.Index)
+		}
+		return nil
+	}
+	defer func() { downloadPartHooker = defaultDownloadPartHook }()
+	err := bucket.DownloadFile("obj", filePath, 100, Routines(1), Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, NotNil)
+
+	// the completed ranges are recorded with their CRC64
+	dcp := downloadCheckpoint{}
+	c.Assert(dcp.load(store, cpKey), IsNil)
+	c.Assert(dcp.ObjStat.CRC64, Equals, object.crc64)
+	c.Assert(len(dcp.todoParts()), Equals, 4)
+	for i, part := range dcp.Parts {
+		c.Assert(dcp.PartStat[i], Equals, i < 6)
+		if dcp.PartStat[i] {
+			c.Assert(part.CRC64, Equals, crc64.Checksum(object.data[part.Start:part.End+1], crcTable()))
+		}
+	}
+
+	downloadPartHooker = defaultDownloadPartHook
+	atomic.StoreInt32(&object.ranges, 0)
+	err = bucket.DownloadFile("obj", filePath, 100, Routines(3), Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&object.ranges), Equals, int32(4))
+	c.Assert(len(store.data), Equals, 0)
+	data, err := ioutil.ReadFile(filePath)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, object.data), Equals, true)
+}
+
+// TestDownloadFileCRCMismatch checks the whole file's CRC64 against the object's
+func (s *OssBucketMockSuite) TestDownloadFileCRCMismatch(c *C) {
+	object := newMockDownloadObject(1000)
+	object.crc64 = "12345"
+	bucket, server := newMockBucket(object.ServeHTTP, c)
+	defer server.Close()
+
+	filePath := "mock-download-crc.data"
+	err := bucket.DownloadFile("obj", filePath, 300, Routines(2))
+	c.Assert(err, FitsTypeOf, CRCCheckError{})
+	_, err = os.Stat(filePath)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(filePath + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	store := &memCheckpointStore{data: map[string][]byte{}}
+	err = bucket.DownloadFile("obj", filePath, 300, Checkpoint(true, "cp"), CheckpointStorage(store))
+	c.Assert(err, FitsTypeOf, CRCCheckError{})
+	c.Assert(len(store.data), Equals, 0)
+	_, err = os.Stat(filePath + TempFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// the range is not checked
+	err = bucket.DownloadFile("obj", filePath, 300, Range(0, 499))
+	c.Assert(err, IsNil)
+	os.Remove(filePath)
+}
+
+func (s *OssBucketMockSuite) TestDownloadPartsChan(c *C) {
+	const partSize = 100
+	const partCount = 8
+	data := make([]byte, partSize*partCount-10)
+	rand.Read(data)
+	var mu sync.Mutex
+	var fetched []int
+	failPart := -1
+	etag := "\"etag\""
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set(HTTPHeaderContentLength, strconv.Itoa(len(data)))
+			w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+			return
+		}
+		if r.Header.Get(HTTPHeaderIfMatch) != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			io.WriteString(w, "<Error><Code>PreconditionFailed</Code></Error>")
+			return
+		}
+		var start, end int
+		fmt.Sscanf(r.Header.Get(HTTPHeaderRange), "bytes=%d-%d", &start, &end)
+		index := start / partSize
+		if index == failPart {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, "<Error><Code>InternalError</Code></Error>")
+			return
+		}
+		// the earlier parts complete later within each window of 4 parts
+		time.Sleep(time.Duration(4-index%4) * 20 * time.Millisecond)
+		mu.Lock()
+		fetched = append(fetched, index)
+		mu.Unlock()
+		w.Header().Set(HTTPHeaderContentLength, strconv.Itoa(end-start+1))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start : end+1])
+	}, c)
+	defer server.Close()
+
+	parts, err := bucket.DownloadPartsChan("object", partSize, 4)
+	c.Assert(err, IsNil)
+	var got []byte
+	next := 0
+	for part := range parts {
+		c.Assert(part.Err, IsNil)
+		c.Assert(part.Index, Equals, next)
+		c.Assert(part.Offset, Equals, int64(next*partSize))
+		got = append(got, part.Data...)
+		next++
+	}
+	c.Assert(next, Equals, partCount)
+	c.Assert(bytes.Equal(got, data), Equals, true)
+	c.Assert(len(fetched), Equals, partCount)
+	c.Assert(sort.IntsAreSorted(fetched), Equals, false)
+
+	// the parts before the failed one are delivered, then the error
+	failPart = 5
+	parts, err = bucket.DownloadPartsChan("object", partSize, 2)
+	c.Assert(err, IsNil)
+	next = 0
+	for part := range parts {
+		if part.Err != nil {
+			c.Assert(part.Index, Equals, -1)
+			code, _ := ServiceErrorCode(part.Err)
+			c.Assert(code, Equals, "InternalError")
+			break
+		}
+		c.Assert(part.Index, Equals, next)
+		next++
+	}
+	c.Assert(next, Equals, failPart)
+	_, ok := <-parts
+	c.Assert(ok, Equals, false)
+
+	// the object changed during the download fails the parts pinned to the ETag
+	failPart = -1
+	etag = "\"changed\""
+	parts, err = bucket.DownloadPartsChan("object", partSize, 2)
+	c.Assert(err, IsNil)
+	part := <-parts
+	c.Assert(part.Index, Equals, -1)
+	code, _ := ServiceErrorCode(part.Err)
+	c.Assert(code, Equals, "PreconditionFailed")
+	_, ok = <-parts
+	c.Assert(ok, Equals, false)
+
+	// the consumer stopping early cancels the context, the channel is closed without being drained
+	etag = "\"etag\""
+	ctx, cancel := context.WithCancel(context.Background())
+	parts, err = bucket.DownloadPartsChan("object", partSize, 2, WithContext(ctx))
+	c.Assert(err, IsNil)
+	part = <-parts
+	c.Assert(part.Index, Equals, 0)
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	_, ok = <-parts
+	c.Assert(ok, Equals, false)
+
+	_, err = bucket.DownloadPartsChan("object", 0, 1)
+	c.Assert(err, NotNil)
+}
@@ -2,11 +2,21 @@ package oss
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// ErrObjectAlreadyExists is returned by PutObject with IfNoneMatch("*") or ForbidOverwrite(true) when the object exists
+var ErrObjectAlreadyExists = errors.New("oss: the object already exists")
+
+// ErrMetaReplaceWithoutMeta is returned by SetObjectMeta and CopyObject to the object itself with MetaReplace but
+// without any metadata, which would clear the metadata of the object
+var ErrMetaReplaceWithoutMeta = errors.New("oss: MetaReplace without any metadata clears the metadata of the object")
+
 // ServiceError contains fields of the error response from Oss Service REST API.
 type ServiceError struct {
 	XMLName    xml.Name `xml:"Error"`
@@ -14,14 +24,33 @@ type ServiceError struct {
 	Message    string   `xml:"Message"`   // the detail error message from OSS
 	RequestID  string   `xml:"RequestId"` // the request Id
 	HostID     string   `xml:"HostId"`    // the OSS server cluster's Id
-	RawMessage string   // the raw messages from OSS
+	RawMessage string   // the raw messages from OSS, it's empty if the response has no body such as HEAD
 	StatusCode int      // HTTP status code
 }
 
 // Implement interface error
 func (e ServiceError) Error() string {
-	return fmt.Sprintf("oss: service returned error: StatusCode=%d, ErrorCode=%s, ErrorMessage=%s, RequestId=%s",
-		e.StatusCode, e.Code, e.Message, e.RequestID)
+	return fmt.Sprintf("oss: service returned error: StatusCode=%d, ErrorCode=%s, ErrorMessage=%s, RequestId=%s, HostId=%s",
+		e.StatusCode, e.Code, e.Message, e.RequestID, e.HostID)
+}
+
+// ThrottleError is returned when OSS throttles the request with 503, such as SlowDown, and the retries are exhausted.
+// The request which isn't retried gets the ServiceError.
+// The ServiceError is unwrapped by ServiceErrorCode and the Is functions.
+type ThrottleError struct {
+	ServiceError
+	RetryAfter time.Duration // the delay asked by the Retry-After header of the last response, 0 if absent
+	Retries    int           // the retries done before the error
+}
+
+// Implement interface error
+func (e ThrottleError) Error() string {
+	return fmt.Sprintf("oss: request throttled after %d retries, retry after %s: %s", e.Retries, e.RetryAfter, e.ServiceError.Error())
+}
+
+// Unwrap returns the ServiceError of the last response
+func (e ThrottleError) Unwrap() error {
+	return e.ServiceError
 }
 
 // UnexpectedStatusCodeError is returned when a storage service responds with neither an error
@@ -74,9 +103,169 @@ func (e CRCCheckError) Error() string {
 		e.operation, e.clientCRC, e.serverCRC, e.requestID)
 }
 
+// ETagCheckError is returned when the ETag returned by OSS is inconsistent with the content MD5 computed in client
+type ETagCheckError struct {
+	clientMD5  string // Calculated content MD5 in client, in hex
+	serverETag string // The ETag returned by OSS
+	operation  string // upload operations such as PUTOBJECT
+	requestID  string // the request id of this operation
+}
+
+// Implement interface error
+func (e ETagCheckError) Error() string {
+	return fmt.Sprintf("oss: the etag of %s is inconsistent, client md5 %s but server etag %s; request id is %s",
+		e.operation, e.clientMD5, e.serverETag, e.requestID)
+}
+
+// DeleteObjectsError is returned by DeleteObjects with FailOnPartialError when some objects failed to delete
+type DeleteObjectsError struct {
+	FailedObjects []DeleteObjectError // the objects failed to delete
+}
+
+// Implement interface error
+func (e DeleteObjectsError) Error() string {
+	failed := []string{}
+	for _, v := range e.FailedObjects {
+		failed = append(failed, fmt.Sprintf("%s(%s)", v.Key, v.Code))
+	}
+	return fmt.Sprintf("oss: failed to delete %d objects: %s", len(e.FailedObjects), strings.Join(failed, ", "))
+}
+
+// MaxPagesError is returned when the listing is still truncated after the pages bounded by MaxPages
+type MaxPagesError struct {
+	BucketName string // the bucket listed
+	Pages      int    // the pages fetched, it's the bound of MaxPages
+	NextMarker string // the marker of the next page, the listing could be continued from it by Marker
+}
+
+// Implement interface error
+func (e MaxPagesError) Error() string {
+	return fmt.Sprintf("oss: the listing of the bucket %s is still truncated after %d pages bounded by MaxPages, the next marker is %q",
+		e.BucketName, e.Pages, e.NextMarker)
+}
+
+// IsRestoreInProgress returns true if the restore failed with 409 RestoreAlreadyInProgress, which means the
+// object is being restored by the previous request. The restore status could be polled by GetObjectDetailedMeta.
+func IsRestoreInProgress(err error) bool {
+	e, ok := asServiceError(err)
+	return ok && e.StatusCode == http.StatusConflict && e.Code == "RestoreAlreadyInProgress"
+}
+
+// IsNoSuchKey returns true if the error is the ServiceError of the object not found
+func IsNoSuchKey(err error) bool {
+	code, ok := ServiceErrorCode(err)
+	return ok && code == "NoSuchKey"
+}
+
+// IsNoSuchBucket returns true if the error is the ServiceError of the bucket not found
+func IsNoSuchBucket(err error) bool {
+	code, ok := ServiceErrorCode(err)
+	return ok && code == "NoSuchBucket"
+}
+
+// IsNoSuchBucketPolicy returns true if the error is the ServiceError of the bucket without the policy
+func IsNoSuchBucketPolicy(err error) bool {
+	code, ok := ServiceErrorCode(err)
+	return ok && code == "NoSuchBucketPolicy"
+}
+
+// checkObjectAlreadyExists maps the error of the create-if-absent request to ErrObjectAlreadyExists, which is 412
+// PreconditionFailed for If-None-Match: * or 409 FileAlreadyExists for X-Oss-Forbid-Overwrite
+func checkObjectAlreadyExists(err error, options []Option) error {
+	e, ok := err.(ServiceError)
+	if !ok {
+		return err
+	}
+	ifNoneMatch, ierr := findOption(options, HTTPHeaderIfNoneMatch, "")
+	forbid, ferr := findOption(options, HTTPHeaderOssForbidOverwrite, "")
+	if ierr != nil || ferr != nil {
+		return err
+	}
+	if (e.StatusCode == http.StatusPreconditionFailed && ifNoneMatch.(string) == "*") ||
+		(e.StatusCode == http.StatusConflict && e.Code == "FileAlreadyExists" && forbid.(string) == "true") {
+		return ErrObjectAlreadyExists
+	}
+	return err
+}
+
+// IsAccessDenied returns true if the error is the ServiceError of the access denied
+func IsAccessDenied(err error) bool {
+	code, ok := ServiceErrorCode(err)
+	return ok && code == "AccessDenied"
+}
+
+// ServiceErrorCode returns the OSS error code such as NoSuchKey, the bool is false if the error is not a ServiceError.
+// The error wrapped by fmt.Errorf("...%w", err) or any error with the Unwrap method is unwrapped.
+func ServiceErrorCode(err error) (string, bool) {
+	e, ok := asServiceError(err)
+	return e.Code, ok
+}
+
+// ServiceErrorRequestID returns the request id of the ServiceError, which is asked by the support of OSS to look
+// into the request. The bool is false if the error is not a ServiceError. The wrapped error is unwrapped.
+func ServiceErrorRequestID(err error) (string, bool) {
+	e, ok := asServiceError(err)
+	return e.RequestID, ok
+}
+
+// asServiceError finds the ServiceError in the chain of the wrapped errors. It's what errors.As does,
+// but it's done here by the Unwrap method, so that it works with the Go versions before errors.As.
+func asServiceError(err error) (ServiceError, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case ServiceError:
+			return e, true
+		case *ServiceError:
+			if e != nil {
+				return *e, true
+			}
+			return ServiceError{}, false
+		}
+
+		wrapper, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = wrapper.Unwrap()
+	}
+	return ServiceError{}, false
+}
+
+// checkCRC skips the check when the server CRC header is absent (some gateways or object types do not return it),
+// rather than comparing the client CRC to zero
 func checkCRC(resp *Response, operation string) error {
 	if resp.Headers.Get(HTTPHeaderOssCRC64) == "" || resp.ClientCRC == resp.ServerCRC {
 		return nil
 	}
 	return CRCCheckError{resp.ClientCRC, resp.ServerCRC, operation, resp.Headers.Get(HTTPHeaderOssRequestID)}
 }
+
+// checkCRC checks the CRC by checkCRC, the check skipped without the server CRC header is logged at the debug level
+func (bucket Bucket) checkCRC(resp *Response, operation string) error {
+	if config := bucket.getConfig(); config.isLogging() && resp.Headers.Get(HTTPHeaderOssCRC64) == "" {
+		config.Logger.Debugf("oss: the server CRC64 header is absent in the response of %s, request id %s, the CRC check is skipped",
+			operation, resp.Headers.Get(HTTPHeaderOssRequestID))
+	}
+	return checkCRC(resp, operation)
+}
+
+// checkDownloadLength returns an error when fewer bytes than the response's Content-Length were written, which
+// happens if the connection is closed early without an error. It's skipped when Content-Length is absent.
+func checkDownloadLength(resp *Response, written int64, operation string) error {
+	contentLen, err := strconv.ParseInt(resp.Headers.Get(HTTPHeaderContentLength), 10, 64)
+	if err != nil || written == contentLen {
+		return nil
+	}
+	return fmt.Errorf("oss: %s got %d bytes, but the Content-Length is %d, RequestId: %s",
+		operation, written, contentLen, resp.Headers.Get(HTTPHeaderOssRequestID))
+}
+
+func checkETag(resp *Response, contentMD5 string, operation string) error {
+	etag := strings.Trim(resp.Headers.Get(HTTPHeaderEtag), "\"")
+	if strings.EqualFold(etag, contentMD5) {
+		return nil
+	}
+	return ETagCheckError{contentMD5, etag, operation, resp.Headers.Get(HTTPHeaderOssRequestID)}
+}
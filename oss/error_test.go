@@ -1,6 +1,7 @@
 package oss
 
 import (
+	"io"
 	"math"
 	"net/http"
 
@@ -70,3 +71,56 @@ func (s *OssErrorSuite) TestCheckCRCCNegative(c *C) {
 	c.Assert(err, NotNil)
 	testLogger.Println("error:", err)
 }
+
+func (s *OssErrorSuite) TestIsRestoreInProgress(c *C) {
+	err := ServiceError{Code: "RestoreAlreadyInProgress", StatusCode: http.StatusConflict}
+	c.Assert(IsRestoreInProgress(err), Equals, true)
+
+	c.Assert(IsRestoreInProgress(ServiceError{Code: "NoSuchKey", StatusCode: http.StatusNotFound}), Equals, false)
+	c.Assert(IsRestoreInProgress(ServiceError{Code: "OperationNotSupported", StatusCode: http.StatusConflict}), Equals, false)
+	c.Assert(IsRestoreInProgress(nil), Equals, false)
+}
+
+// wrappedError wraps an error with the Unwrap method, like fmt.Errorf with %w
+type wrappedError struct {
+	msg string
+	err error
+}
+
+func (e wrappedError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e wrappedError) Unwrap() error {
+	return e.err
+}
+
+func (s *OssErrorSuite) TestServiceErrorCode(c *C) {
+	notFound := ServiceError{Code: "NoSuchKey", StatusCode: http.StatusNotFound}
+	c.Assert(IsNoSuchKey(notFound), Equals, true)
+	c.Assert(IsNoSuchKey(&notFound), Equals, true)
+	c.Assert(IsNoSuchBucket(notFound), Equals, false)
+	c.Assert(IsAccessDenied(notFound), Equals, false)
+	c.Assert(IsNoSuchBucketPolicy(notFound), Equals, false)
+	c.Assert(IsNoSuchBucketPolicy(ServiceError{Code: "NoSuchBucketPolicy", StatusCode: http.StatusNotFound}), Equals, true)
+
+	code, ok := ServiceErrorCode(notFound)
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, "NoSuchKey")
+
+	// the wrapped errors
+	wrapped := wrappedError{"copy", wrappedError{"get", ServiceError{Code: "NoSuchBucket", StatusCode: http.StatusNotFound}}}
+	c.Assert(IsNoSuchBucket(wrapped), Equals, true)
+	c.Assert(IsAccessDenied(wrappedError{"get", ServiceError{Code: "AccessDenied", StatusCode: http.StatusForbidden}}), Equals, true)
+
+	// not a ServiceError
+	code, ok = ServiceErrorCode(wrappedError{"get", io.EOF})
+	c.Assert(ok, Equals, false)
+	c.Assert(code, Equals, "")
+	_, ok = ServiceErrorCode(nil)
+	c.Assert(ok, Equals, false)
+	var nilErr *ServiceError
+	_, ok = ServiceErrorCode(nilErr)
+	c.Assert(ok, Equals, false)
+	c.Assert(IsNoSuchKey(UnexpectedStatusCodeError{}), Equals, false)
+}
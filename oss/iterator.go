@@ -0,0 +1,111 @@
+package oss
+
+// ObjectIterator iterates the objects listed by ListObjects, the next page is fetched when the current one is consumed.
+// It's created by Bucket.ListObjectsIterator and it's not safe for the concurrent use.
+type ObjectIterator struct {
+	bucket         Bucket
+	options        []Option
+	objects        []ObjectProperties // the objects of the current page not iterated yet
+	commonPrefixes []string           // the common prefixes of the pages fetched
+	isTruncated    bool               // there're more pages to fetch
+	nextMarker     string             // the marker of the next page
+	started        bool               // the first page is fetched
+	pages          int                // the pages fetched
+	err            error              // the error of fetching the page, it's returned since then
+}
+
+//
+// ListObjectsIterator Creates the iterator of the objects, which fetches the pages of ListObjects transparently.
+//
+// options  the filters of ListObjects, such as Prefix, Delimiter and Marker. MaxKeys is the page size. MaxPages bounds
+//          the pages fetched, the objects of them are iterated before MaxPagesError is returned.
+//
+// *ObjectIterator  the iterator, the first page is fetched by the first Next.
+//
+func (bucket Bucket) ListObjectsIterator(options ...Option) *ObjectIterator {
+	return &ObjectIterator{bucket: bucket, options: options}
+}
+
+//
+// Next Gets the next object.
+//
+// ObjectProperties  the object, it's valid when the bool is true.
+// bool  false when all the objects are iterated or on the error.
+// error  it's nil if no error; otherwise it's the error of fetching the page, which is returned by the later calls too.
+//
+func (it *ObjectIterator) Next() (ObjectProperties, bool, error) {
+	for len(it.objects) == 0 {
+		if it.err != nil {
+			return ObjectProperties{}, false, it.err
+		}
+		if it.started && !it.isTruncated {
+			return ObjectProperties{}, false, nil
+		}
+		if it.err = it.fetch(); it.err != nil {
+			return ObjectProperties{}, false, it.err
+		}
+	}
+
+	object := it.objects[0]
+	it.objects = it.objects[1:]
+	return object, true, nil
+}
+
+// CommonPrefixes gets the common prefixes of the pages fetched with Delimiter, all of them are got after Next returns false
+func (it *ObjectIterator) CommonPrefixes() []string {
+	return it.commonPrefixes
+}
+
+// fetch fetches the next page, the page may have the common prefixes only
+func (it *ObjectIterator) fetch() error {
+	options := it.options
+	if it.started {
+		if max := getMaxPages(options); max > 0 && it.pages >= max {
+			return MaxPagesError{BucketName: it.bucket.BucketName, Pages: it.pages, NextMarker: it.nextMarker}
+		}
+		options = append(options[:len(options):len(options)], Marker(it.nextMarker))
+	}
+	lor, err := it.bucket.ListObjects(options...)
+	if err != nil {
+		return err
+	}
+
+	it.started = true
+	it.pages++
+	it.objects = lor.Objects
+	it.commonPrefixes = append(it.commonPrefixes, lor.CommonPrefixes...)
+	it.isTruncated = lor.IsTruncated
+	it.nextMarker = lor.NextMarker
+	if it.isTruncated && it.nextMarker == "" {
+		// the next page starts after the last key or prefix of this page
+		if n := len(lor.Objects); n > 0 {
+			it.nextMarker = lor.Objects[n-1].Key
+		}
+		if n := len(lor.CommonPrefixes); n > 0 && lor.CommonPrefixes[n-1] > it.nextMarker {
+			it.nextMarker = lor.CommonPrefixes[n-1]
+		}
+		it.isTruncated = it.nextMarker != ""
+	}
+	return nil
+}
+
+//
+// ForEachObject Calls fn with each object listed, checks out ListObjectsIterator for the details.
+//
+// fn  the function called with the object, the iteration stops when it returns an error.
+// options  the filters of ListObjects, such as Prefix, Delimiter and Marker. MaxKeys is the page size.
+//
+// error  it's nil if no error; otherwise it's the error of fetching the page or returned by fn.
+//
+func (bucket Bucket) ForEachObject(fn func(object ObjectProperties) error, options ...Option) error {
+	it := bucket.ListObjectsIterator(options...)
+	for {
+		object, ok, err := it.Next()
+		if !ok {
+			return err
+		}
+		if err = fn(object); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package oss
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// mockListObjects serves ListObjects of the keys with the prefix, marker, max-keys and delimiter, it counts the pages
+type mockListObjects struct {
+	keys  []string
+	pages int
+}
+
+func (m *mockListObjects) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.pages++
+	query := r.URL.Query()
+	prefix, marker, delimiter := query.Get("prefix"), query.Get("marker"), query.Get("delimiter")
+	maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
+	if maxKeys <= 0 {
+		maxKeys = 100
+	}
+
+	out := ListObjectsResult{Prefix: prefix, Marker: marker, Delimiter: delimiter, MaxKeys: maxKeys}
+	sort.Strings(m.keys)
+	for _, key := range m.keys {
+		if !strings.HasPrefix(key, prefix) || key <= marker {
+			continue
+		}
+		if len(out.Objects)+len(out.CommonPrefixes) == maxKeys {
+			out.IsTruncated = true
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				commonPrefix := key[:len(prefix)+i+len(delimiter)]
+				if commonPrefix <= marker {
+					continue
+				}
+				if n := len(out.CommonPrefixes); n == 0 || out.CommonPrefixes[n-1] != commonPrefix {
+					out.CommonPrefixes = append(out.CommonPrefixes, commonPrefix)
+					out.NextMarker = commonPrefix
+				}
+				continue
+			}
+		}
+		out.Objects = append(out.Objects, ObjectProperties{Key: key, Size: int64(len(key))})
+		out.NextMarker = key
+	}
+	if !out.IsTruncated {
+		out.NextMarker = ""
+	}
+	bs, _ := xml.Marshal(out)
+	w.Write(bs)
+}
+
+func (s *OssBucketMockSuite) TestListObjectsIterator(c *C) {
+	store := &mockListObjects{keys: []string{"a", "dir/1", "dir/2", "dir/sub/3", "dir/sub/4", "dir/x/5", "dir/y", "z"}}
+	bucket, server := newMockBucket(store.ServeHTTP, c)
+	defer server.Close()
+
+	collect := func(it *ObjectIterator) []string {
+		keys := []string{}
+		for {
+			object, ok, err := it.Next()
+			c.Assert(err, IsNil)
+			if !ok {
+				return keys
+			}
+			keys = append(keys, object.Key)
+		}
+	}
+
+	// all the objects with the page size 3
+	it := bucket.ListObjectsIterator(MaxKeys(3))
+	c.Assert(collect(it), DeepEquals, store.keys)
+	c.Assert(store.pages, Equals, 3)
+	_, ok, err := it.Next()
+	c.Assert(ok, Equals, false)
+	c.Assert(err, IsNil)
+	c.Assert(store.pages, Equals, 3)
+
+	// the prefix and the delimiter, the page of the common prefixes only is skipped
+	store.pages = 0
+	it = bucket.ListObjectsIterator(Prefix("dir/"), Delimiter("/"), MaxKeys(2))
+	c.Assert(collect(it), DeepEquals, []string{"dir/1", "dir/2", "dir/y"})
+	c.Assert(it.CommonPrefixes(), DeepEquals, []string{"dir/sub/", "dir/x/"})
+	c.Assert(store.pages, Equals, 3)
+
+	// the marker
+	it = bucket.ListObjectsIterator(Marker("dir/y"), MaxKeys(1))
+	c.Assert(collect(it), DeepEquals, []string{"z"})
+
+	// no object
+	it = bucket.ListObjectsIterator(Prefix("none"))
+	c.Assert(collect(it), DeepEquals, []string{})
+
+	// ForEachObject stops on the error of the callback
+	keys := []string{}
+	err = bucket.ForEachObject(func(object ObjectProperties) error {
+		keys = append(keys, object.Key)
+		return nil
+	}, Prefix("dir/sub/"), MaxKeys(1))
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"dir/sub/3", "dir/sub/4"})
+
+	stop := errors.New("stop")
+	keys = nil
+	err = bucket.ForEachObject(func(object ObjectProperties) error {
+		keys = append(keys, object.Key)
+		return stop
+	})
+	c.Assert(err, Equals, stop)
+	c.Assert(keys, DeepEquals, []string{"a"})
+}
+
+func (s *OssBucketMockSuite) TestListObjectsIteratorError(c *C) {
+	store := &mockListObjects{keys: []string{"a", "b", "c"}}
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("marker") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+			return
+		}
+		store.ServeHTTP(w, r)
+	}, c)
+	defer server.Close()
+
+	// the objects of the first page are got before the error, which is kept
+	it := bucket.ListObjectsIterator(MaxKeys(2))
+	for _, key := range []string{"a", "b"} {
+		object, ok, err := it.Next()
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+		c.Assert(object.Key, Equals, key)
+	}
+	_, ok, err := it.Next()
+	c.Assert(ok, Equals, false)
+	c.Assert(IsAccessDenied(err), Equals, true)
+	_, ok, err = it.Next()
+	c.Assert(ok, Equals, false)
+	c.Assert(IsAccessDenied(err), Equals, true)
+	c.Assert(store.pages, Equals, 1)
+}
+
+func (s *OssBucketMockSuite) TestListObjectsMaxPages(c *C) {
+	// the misbehaving server always reports truncated, each page has one object
+	pages := 0
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		key := "key-" + strconv.Itoa(pages)
+		out := ListObjectsResult{IsTruncated: true, NextMarker: key, Objects: []ObjectProperties{{Key: key}}}
+		bs, _ := xml.Marshal(out)
+		w.Write(bs)
+	}, c)
+	defer server.Close()
+
+	// the iterator stops at the limit after iterating the objects of the pages
+	it := bucket.ListObjectsIterator(MaxPages(3))
+	keys := []string{}
+	for {
+		object, ok, err := it.Next()
+		if !ok {
+			c.Assert(err, DeepEquals, MaxPagesError{BucketName: "mock-bucket", Pages: 3, NextMarker: "key-3"})
+			c.Assert(strings.Contains(err.Error(), "still truncated after 3 pages"), Equals, true)
+			break
+		}
+		keys = append(keys, object.Key)
+	}
+	c.Assert(keys, DeepEquals, []string{"key-1", "key-2", "key-3"})
+	c.Assert(pages, Equals, 3)
+
+	// so do the other listings
+	pages = 0
+	err := bucket.ForEachObject(func(object ObjectProperties) error { return nil }, MaxPages(2))
+	_, ok := err.(MaxPagesError)
+	c.Assert(ok, Equals, true)
+	c.Assert(pages, Equals, 2)
+
+	pages = 0
+	objects, err := bucket.ListObjectsSince(time.Time{}, MaxPages(2))
+	c.Assert(err, DeepEquals, MaxPagesError{BucketName: "mock-bucket", Pages: 2, NextMarker: "key-2"})
+	c.Assert(objects, HasLen, 2)
+	c.Assert(pages, Equals, 2)
+
+	pages = 0
+	_, err = bucket.Sync(os.TempDir()+"/oss-no-such-dir-"+randLowStr(5), "prefix/", MaxPages(2))
+	_, ok = err.(MaxPagesError)
+	c.Assert(ok, Equals, true)
+	c.Assert(pages, Equals, 2)
+
+	pages = 0
+	_, err = bucket.DownloadDir("prefix/", os.TempDir()+"/oss-no-such-dir-"+randLowStr(5), MaxPages(2))
+	_, ok = err.(MaxPagesError)
+	c.Assert(ok, Equals, true)
+	c.Assert(pages, Equals, 2)
+}
@@ -0,0 +1,74 @@
+package oss
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiter is the token bucket limiting the bytes per second, it's shared by the concurrent readers.
+// The bytes read are taken from the bucket, and the reader sleeps for the debt when the bucket is short.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64   // the bytes per second
+	burst  float64   // the capacity of the bucket, it's the bytes of 100ms
+	tokens float64   // the bytes available, it's negative for the debt
+	last   time.Time // the time the tokens are updated
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	burst := float64(bytesPerSec) / 10
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: float64(bytesPerSec), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait takes n bytes from the bucket and sleeps until they're paid
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// limitedReader reads at most the burst of the limiter at a time and waits for the bytes read
+type limitedReader struct {
+	reader  io.Reader
+	limiter *rateLimiter
+}
+
+// newLimitedReader wraps the reader by the limiter, the reader is returned as it is when the limiter is nil
+func newLimitedReader(reader io.Reader, limiter *rateLimiter) io.Reader {
+	if limiter == nil || reader == nil {
+		return reader
+	}
+	return &limitedReader{reader: reader, limiter: limiter}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if max := int(r.limiter.burst); len(p) > max {
+		p = p[:max]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
@@ -0,0 +1,98 @@
+package oss
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssBucketMockSuite) TestBandwidthLimit(c *C) {
+	const limit = 100 * 1024
+	const size = 20 * 1024
+	data := make([]byte, size)
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			ioutil.ReadAll(r.Body)
+			return
+		}
+		w.Write(data)
+	}, c, EnableCRC(false), UploadBandwidthLimit(limit), DownloadBandwidthLimit(limit))
+	defer server.Close()
+
+	// 4 concurrent transfers of 20KB share the 100KB/s limit, the first 10KB are the burst
+	transfer := func(upload bool) time.Duration {
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if upload {
+					c.Check(bucket.PutObject("object", bytes.NewReader(data)), IsNil)
+					return
+				}
+				body, err := bucket.GetObject("object")
+				c.Check(err, IsNil)
+				got, err := ioutil.ReadAll(body)
+				c.Check(err, IsNil)
+				c.Check(len(got), Equals, size)
+				body.Close()
+			}()
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+	minElapsed := time.Duration(float64(4*size-limit/10) / limit * float64(time.Second))
+	c.Assert(transfer(true) >= minElapsed, Equals, true)
+	c.Assert(transfer(false) >= minElapsed, Equals, true)
+
+	// the progress reflects the limited rate
+	recorder := &rateRecorder{}
+	body, err := bucket.GetObject("object", Progress(recorder))
+	c.Assert(err, IsNil)
+	ioutil.ReadAll(body)
+	body.Close()
+	last := recorder.events[len(recorder.events)-1]
+	c.Assert(last.ConsumedBytes, Equals, int64(size))
+	c.Assert(last.AverageRate < 1.5*limit, Equals, true)
+
+	// no limit by default
+	client, err := New(server.URL, "ak", "sk", EnableCRC(false))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.uploadLimiter, IsNil)
+	bucket, err = client.Bucket("mock-bucket")
+	c.Assert(err, IsNil)
+	c.Assert(transfer(true) < minElapsed, Equals, true)
+	c.Assert(transfer(false) < minElapsed, Equals, true)
+
+	// the limits set to the config fields by the custom option
+	client, err = New(server.URL, "ak", "sk", func(client *Client) {
+		client.Config.UploadBandwidthLimit = limit
+		client.Config.DownloadBandwidthLimit = limit
+	})
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.uploadLimiter, NotNil)
+	c.Assert(client.Config.downloadLimiter, NotNil)
+}
+
+func (s *OssUtilsSuite) TestRateLimiter(c *C) {
+	c.Assert(newRateLimiter(0), IsNil)
+	c.Assert(newLimitedReader(bytes.NewReader(nil), nil), FitsTypeOf, bytes.NewReader(nil))
+
+	// the reads are not larger than the burst
+	limiter := newRateLimiter(10 * 1024)
+	reader := newLimitedReader(bytes.NewReader(make([]byte, 4096)), limiter)
+	n, err := reader.Read(make([]byte, 4096))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 1024)
+
+	// the debt of the bytes over the burst is paid by the sleep
+	limiter = newRateLimiter(10 * 1024)
+	start := time.Now()
+	limiter.wait(1024 + 2048)
+	c.Assert(time.Since(start) >= 190*time.Millisecond, Equals, true)
+}
@@ -0,0 +1,109 @@
+package oss
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Logger logs the messages of the SDK, such as the requests, the signing and the retries at the debug level.
+// It must be safe for the concurrent use.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// LogLevel the level of the logger set by EnableLog
+type LogLevel int
+
+const (
+	// LogOff logs nothing
+	LogOff LogLevel = iota
+
+	// LogError logs the errors only
+	LogError
+
+	// LogInfo logs the errors and the information such as the retries
+	LogInfo
+
+	// LogDebug logs all, including the requests, the responses and the strings to sign
+	LogDebug
+)
+
+// the mask of the secrets in the logs
+const logSecretMask = "******"
+
+// nopLogger logs nothing, it's the default logger
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+// levelLogger logs the messages at the level or above by the standard logger
+type levelLogger struct {
+	level  LogLevel
+	logger *log.Logger
+}
+
+// newLevelLogger creates the logger writing to the stderr
+func newLevelLogger(level LogLevel) *levelLogger {
+	return &levelLogger{level: level, logger: log.New(os.Stderr, "[oss] ", log.LstdFlags)}
+}
+
+func (l *levelLogger) Debugf(format string, args ...interface{}) {
+	if l.level >= LogDebug {
+		l.logger.Printf("[DEBUG] "+format, args...)
+	}
+}
+
+func (l *levelLogger) Infof(format string, args ...interface{}) {
+	if l.level >= LogInfo {
+		l.logger.Printf("[INFO] "+format, args...)
+	}
+}
+
+func (l *levelLogger) Errorf(format string, args ...interface{}) {
+	if l.level >= LogError {
+		l.logger.Printf("[ERROR] "+format, args...)
+	}
+}
+
+// isLogging tells whether a logger is set, so that the messages are not built for the default one
+func (config *Config) isLogging() bool {
+	if config.Logger == nil {
+		return false
+	}
+	_, isNop := config.Logger.(nopLogger)
+	return !isNop
+}
+
+// redactStringToSign masks the security token in the canonicalized headers of the string to sign
+func redactStringToSign(signStr string) string {
+	prefix := strings.ToLower(HTTPHeaderOssSecurityToken) + ":"
+	lines := strings.Split(signStr, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			lines[i] = prefix + logSecretMask
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactURL masks the security token and the signature in the query of the signed url
+func redactURL(uri *url.URL) string {
+	query := uri.Query()
+	if query.Get(HTTPParamSecurityToken) == "" && query.Get(HTTPParamSignature) == "" {
+		return uri.String()
+	}
+	for _, key := range []string{HTTPParamSecurityToken, HTTPParamSignature} {
+		if query.Get(key) != "" {
+			query.Set(key, logSecretMask)
+		}
+	}
+	redacted := *uri
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
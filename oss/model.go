@@ -4,6 +4,7 @@ import (
 	"hash"
 	"io"
 	"net/http"
+	"time"
 )
 
 // Response Http response from oss
@@ -21,6 +22,22 @@ type PutObjectRequest struct {
 	Reader    io.Reader
 }
 
+// PutObjectResult The result of PutObjectWithResult
+type PutObjectResult struct {
+	ServerSideEncryption      string // the server side encryption applied by OSS, such as AES256 or KMS
+	ServerSideEncryptionKeyID string // the KMS master key id when the encryption is KMS
+	RequestID                 string // the request id, it's asked by the support of OSS to look into the request
+	ETag                      string // the ETag of the object
+	CRC64                     uint64 // the CRC64 ECMA of the object returned by OSS, 0 if it's not returned
+}
+
+// DeleteObjectResult The result of DeleteObjectWithResult
+type DeleteObjectResult struct {
+	VersionID    string // the deleted version, or the version of the delete marker created by the deletion without VersionId
+	DeleteMarker bool   // true if the deleted version is a delete marker or a delete marker is created
+	RequestID    string // the request id, it's asked by the support of OSS to look into the request
+}
+
 // GetObjectRequest The request of DoGetObject
 type GetObjectRequest struct {
 	ObjectKey string
@@ -33,6 +50,73 @@ type GetObjectResult struct {
 	ServerCRC uint64
 }
 
+// SelectObjectStats The statistics of SelectObject, it's available after the records are drained
+type SelectObjectStats struct {
+	ScannedBytes  int64 // the bytes of the object scanned by OSS, which is billed
+	ReturnedBytes int64 // the bytes of the records returned
+}
+
+// SelectObjectMetaResult The result of CreateSelectCsvObjectMeta and CreateSelectJsonObjectMeta
+type SelectObjectMetaResult struct {
+	ScannedBytes int64 // the bytes of the object scanned by OSS
+	SplitsCount  int   // the count of the splits, which could be selected by the split-range
+	RowsCount    int64 // the count of the rows
+	ColumnsCount int   // the count of the columns, it's 0 for JSON
+}
+
+// SyncResult The result of Sync
+type SyncResult struct {
+	Uploaded []string // the keys of the objects uploaded
+	Skipped  []string // the keys of the objects unchanged
+	Deleted  []string // the keys of the objects deleted by DeleteExtraneous
+}
+
+// SpotCheckRange A byte range of the object to check by SpotCheckObject
+type SpotCheckRange struct {
+	Start int64  // the first byte of the range
+	End   int64  // the last byte of the range, it's inclusive as the Range option
+	CRC64 uint64 // the expected CRC64 ECMA of the range, such as computed from the source data
+}
+
+// ObjectStat The result of StatObject, it's parsed from the headers of the HEAD response
+type ObjectStat struct {
+	Size         int64       // the size of the object
+	ETag         string      // the ETag of the object
+	LastModified time.Time   // the last modified time of the object
+	ObjectType   string      // the object type, such as Normal, Appendable, Multipart or Symlink
+	StorageClass string      // the storage class, it's empty for Standard in some regions
+	TaggingCount int         // the count of the object's tags, which could be got by GetObjectTagging
+	Headers      http.Header // all the headers, including the user meta
+}
+
+// SymlinkMeta The result of StatSymlink
+type SymlinkMeta struct {
+	TargetKey    string      // the key of the object the symlink points to
+	ObjectType   string      // the object type, which is Symlink
+	Size         int64       // the size of the symlink object itself
+	ETag         string      // the ETag of the symlink object
+	LastModified time.Time   // the last modified time of the symlink object
+	Headers      http.Header // all the headers of the symlink object, including the user meta
+}
+
+// PostPolicy The policy of the browser upload form, which is signed by SignPostPolicy
+type PostPolicy struct {
+	Expiration          time.Time // the time the form expires, it's required
+	KeyStartsWith       string    // the object key must start with it, it's not checked when it's empty
+	KeyEquals           string    // the object key must be it, it's not checked when it's empty
+	MinContentLength    int64     // the min size of the file uploaded
+	MaxContentLength    int64     // the max size of the file uploaded, the size is not checked when it's 0
+	SuccessActionStatus int       // the status returned on success, 200, 201 or 204. By default OSS returns 204
+}
+
+// PostSignature The result of SignPostPolicy, they are the fields of the browser upload form
+type PostSignature struct {
+	Policy        string // the base64 encoded policy, it's the form field "policy"
+	Signature     string // the signature of the policy, it's the form field "Signature"
+	AccessKeyID   string // the access key id, it's the form field "OSSAccessKeyId"
+	SecurityToken string // the STS token, it's the form field "x-oss-security-token" and it's empty without STS
+}
+
 // AppendObjectRequest  The requtest of DoAppendObject
 type AppendObjectRequest struct {
 	ObjectKey string
@@ -58,3 +142,11 @@ type UploadPartRequest struct {
 type UploadPartResult struct {
 	Part UploadPart
 }
+
+// DownloadedPart The part of the object delivered by DownloadPartsChan
+type DownloadedPart struct {
+	Index  int    // the part number starting from 0, it's -1 for the part carrying the error
+	Offset int64  // the offset of the part in the object
+	Data   []byte // the data of the part
+	Err    error  // the error of the download, the channel is closed after it
+}
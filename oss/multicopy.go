@@ -1,14 +1,15 @@
 package oss
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"os"
+	"fmt"
+	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 //
@@ -18,17 +19,19 @@ import (
 // srcObjectKey   Source object name
 // destObjectKey   Target object name in the form of bucketname.objectkey
 // partSize   part size in byte.
-// options    Object's contraints. Check out function InitiateMultipartUpload。
+// options    Object's contraints. Check out function InitiateMultipartUpload。KeepPartsOnCompleteFailure keeps the
+//            parts copied when the completion fails.
 //
 // error Error is nill if the operation succeeds, otherwise it's the error object.
 //
 func (bucket Bucket) CopyFile(srcBucketName, srcObjectKey, destObjectKey string, partSize int64, options ...Option) error {
 	destBucketName := bucket.BucketName
-	if partSize < MinPartSize || partSize > MaxPartSize {
-		return errors.New("oss: part size invalid range (1024KB, 5GB]")
+	if err := bucket.checkPartSize(partSize); err != nil {
+		return err
 	}
 
-	cpConf, err := getCpConfig(options, filepath.Base(destObjectKey))
+	cpConf, err := getCpConfig(options, getCpFilePath("", filepath.Base(destObjectKey),
+		"/"+srcBucketName+"/"+srcObjectKey, destBucketName, destObjectKey, partSize))
 	if err != nil {
 		return err
 	}
@@ -40,8 +43,191 @@ func (bucket Bucket) CopyFile(srcBucketName, srcObjectKey, destObjectKey string,
 			partSize, options, cpConf.FilePath, routines)
 	}
 
-	return bucket.copyFile(srcBucketName, srcObjectKey, destBucketName, destObjectKey,
+	_, err = bucket.copyFile(srcBucketName, srcObjectKey, destBucketName, destObjectKey,
 		partSize, options, routines)
+	return err
+}
+
+//
+// CopyFileWithContext Copies the object by multipart copy bound to the context, checks out CopyFile for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) CopyFileWithContext(ctx context.Context, srcBucketName, srcObjectKey, destObjectKey string, partSize int64, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.CopyFile(srcBucketName, srcObjectKey, destObjectKey, partSize, options...)
+}
+
+//
+// CopyObjectRange creates the target object from the byte range [start, end) of the source object in the bucket.
+//
+// The data is copied in the server side with multipart copy, so it's not downloaded.
+//
+// srcObjectKey   Source object name
+// destObjectKey  Target object name
+// start          The start index of the range in the source object, inclusive.
+// end            The end index of the range in the source object, exclusive. It must not be greater than the source object size.
+// options        Object's contraints. Check out function CopyFile.
+//
+// error Error is nill if the operation succeeds, otherwise it's the error object.
+//
+func (bucket Bucket) CopyObjectRange(srcObjectKey, destObjectKey string, start, end int64, options ...Option) error {
+	if start < 0 || end <= start {
+		return fmt.Errorf("oss: invalid copy range [%d, %d)", start, end)
+	}
+
+	meta, err := bucket.GetObjectDetailedMeta(srcObjectKey)
+	if err != nil {
+		return err
+	}
+	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 0)
+	if err != nil {
+		return err
+	}
+	if end > objectSize {
+		return fmt.Errorf("oss: copy range [%d, %d) exceeds the object size %d", start, end, objectSize)
+	}
+
+	initOptions, err := getCopyInitOptions(options)
+	if err != nil {
+		return err
+	}
+	partOptions, err := getCopyPartOptions(options)
+	if err != nil {
+		return err
+	}
+
+	imur, err := bucket.InitiateMultipartUpload(destObjectKey, initOptions...)
+	if err != nil {
+		return err
+	}
+
+	parts := []UploadPart{}
+	for offset := start; offset < end; offset += MaxPartSize {
+		partSize := end - offset
+		if partSize > MaxPartSize {
+			partSize = MaxPartSize
+		}
+		part, err := bucket.UploadPartCopy(imur, bucket.BucketName, srcObjectKey, offset, partSize,
+			len(parts)+1, partOptions...)
+		if err != nil {
+			bucket.AbortMultipartUpload(imur)
+			return err
+		}
+		parts = append(parts, part)
+	}
+
+	_, err = bucket.CompleteMultipartUpload(imur, parts)
+	if err != nil {
+		bucket.abortOnCompleteFailure(imur, options)
+		return err
+	}
+	return nil
+}
+
+// the copy conditions only apply to the part copy, the object attributes only apply to the multipart upload initialization
+var copyConditionHeaders = map[string]bool{
+	HTTPHeaderOssCopySourceIfMatch:           true,
+	HTTPHeaderOssCopySourceIfNoneMatch:       true,
+	HTTPHeaderOssCopySourceIfModifiedSince:   true,
+	HTTPHeaderOssCopySourceIfUnmodifiedSince: true,
+}
+
+// gets the options for InitiateMultipartUpload, such as Meta, ObjectACL and ServerSideEncryption.
+// The target object always gets the metadata of the initialization, so MetadataDirective is dropped.
+func getCopyInitOptions(options []Option) ([]Option, error) {
+	return filterOptions(options, func(key string) bool {
+		return !copyConditionHeaders[key] && key != HTTPHeaderOssMetadataDirective
+	})
+}
+
+// gets the options for UploadPartCopy, which are the copy conditions and the context
+func getCopyPartOptions(options []Option) ([]Option, error) {
+	return filterOptions(options, func(key string) bool {
+		return copyConditionHeaders[key] || isRequestArg(key)
+	})
+}
+
+// the part size of copying the object larger than MaxCopyObjectSize in CopyObject, 100MB
+const largeCopyPartSize = 100 * 1024 * 1024
+
+// copyLargeObject copies the source object by multipart copy after the CopyObject request fails with copyErr,
+// when the source object is larger than MaxCopyObjectSize. Otherwise copyErr is returned.
+//
+// The source object is only checked after the failure, so the small object is copied without the extra request.
+// The target object keeps the source object's metadata as CopyObject does, unless MetadataDirective is MetaReplace.
+// The specified version of the source object is not supported by the multipart copy, copyErr is returned with VersionId.
+func (bucket Bucket) copyLargeObject(srcBucketName, srcObjectKey, destBucketName, destObjectKey string,
+	options []Option, copyErr error) (CopyObjectResult, error) {
+	var out CopyObjectResult
+	if serr, ok := copyErr.(ServiceError); !ok || serr.StatusCode != http.StatusBadRequest {
+		return out, copyErr
+	}
+	if isSet, _, _ := isOptionSet(options, HTTPParamVersionID); isSet {
+		return out, copyErr
+	}
+
+	ctxOptions, err := filterOptions(options, isRequestArg)
+	if err != nil {
+		return out, copyErr
+	}
+	srcBucket, err := bucket.Client.Bucket(srcBucketName)
+	if err != nil {
+		return out, copyErr
+	}
+	meta, err := srcBucket.GetObjectDetailedMeta(srcObjectKey, ctxOptions...)
+	if err != nil {
+		return out, copyErr
+	}
+	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
+	if err != nil || objectSize <= MaxCopyObjectSize {
+		return out, copyErr
+	}
+
+	directive, err := findOption(options, HTTPHeaderOssMetadataDirective, nil)
+	if err != nil {
+		return out, err
+	}
+	if directive == nil || directive.(string) != string(MetaReplace) {
+		// the metadata in the request is ignored by CopyObject with MetaCopy, so is it here
+		options, err = filterOptions(options, func(key string) bool {
+			return !isObjectMetaHeader(key)
+		})
+		if err != nil {
+			return out, err
+		}
+		for key := range meta {
+			if isObjectMetaHeader(key) {
+				options = append(options, setHeader(key, meta.Get(key)))
+			}
+		}
+	}
+
+	partSize := GetPartSizeForFile(objectSize)
+	if partSize < largeCopyPartSize {
+		partSize = largeCopyPartSize
+	}
+	res, err := bucket.copyFile(srcBucketName, srcObjectKey, destBucketName, destObjectKey,
+		partSize, options, getRoutines(options))
+	if err != nil {
+		return out, err
+	}
+	out.ETag = res.ETag
+	return out, nil
+}
+
+// the headers of the object metadata copied from the source object
+var objectMetaHeaders = map[string]bool{
+	HTTPHeaderContentType:        true,
+	HTTPHeaderCacheControl:       true,
+	HTTPHeaderContentDisposition: true,
+	HTTPHeaderContentEncoding:    true,
+	HTTPHeaderContentLanguage:    true,
+	HTTPHeaderExpires:            true,
+}
+
+func isObjectMetaHeader(key string) bool {
+	return objectMetaHeaders[key] || strings.HasPrefix(key, HTTPHeaderOssMetaPrefix)
 }
 
 // ----- Concurrently copy without checkpoint ---------
@@ -139,21 +325,31 @@ func getSrcObjectBytes(parts []copyPart) int64 {
 
 // concurrently copy without checkpoint
 func (bucket Bucket) copyFile(srcBucketName, srcObjectKey, destBucketName, destObjectKey string,
-	partSize int64, options []Option, routines int) error {
+	partSize int64, options []Option, routines int) (CompleteMultipartUploadResult, error) {
+	var out CompleteMultipartUploadResult
 	descBucket, err := bucket.Client.Bucket(destBucketName)
 	srcBucket, err := bucket.Client.Bucket(srcBucketName)
 	listener := getProgressListener(options)
 
+	initOptions, err := getCopyInitOptions(options)
+	if err != nil {
+		return out, err
+	}
+	partOptions, err := getCopyPartOptions(options)
+	if err != nil {
+		return out, err
+	}
+
 	// get copy parts
 	parts, err := getCopyParts(srcBucket, srcObjectKey, partSize)
 	if err != nil {
-		return err
+		return out, err
 	}
 
 	// initialize the multipart upload
-	imur, err := descBucket.InitiateMultipartUpload(destObjectKey, options...)
+	imur, err := descBucket.InitiateMultipartUpload(destObjectKey, initOptions...)
 	if err != nil {
-		return err
+		return out, err
 	}
 
 	jobs := make(chan copyPart, len(parts))
@@ -167,7 +363,7 @@ func (bucket Bucket) copyFile(srcBucketName, srcObjectKey, destBucketName, destO
 	publishProgress(listener, event)
 
 	// start copy workers
-	arg := copyWorkerArg{descBucket, imur, srcBucketName, srcObjectKey, options, copyPartHooker}
+	arg := copyWorkerArg{descBucket, imur, srcBucketName, srcObjectKey, partOptions, copyPartHooker}
 	for w := 1; w <= routines; w++ {
 		go copyWorker(w, arg, jobs, results, failed, die)
 	}
@@ -191,7 +387,7 @@ func (bucket Bucket) copyFile(srcBucketName, srcObjectKey, destBucketName, destO
 			descBucket.AbortMultipartUpload(imur)
 			event = newProgressEvent(TransferFailedEvent, completedBytes, totalBytes)
 			publishProgress(listener, event)
-			return err
+			return out, err
 		}
 
 		if completed >= len(parts) {
@@ -203,12 +399,12 @@ func (bucket Bucket) copyFile(srcBucketName, srcObjectKey, destBucketName, destO
 	publishProgress(listener, event)
 
 	// complete the multipart upload
-	_, err = descBucket.CompleteMultipartUpload(imur, ups)
+	out, err = descBucket.CompleteMultipartUpload(imur, ups)
 	if err != nil {
-		bucket.AbortMultipartUpload(imur)
-		return err
+		descBucket.abortOnCompleteFailure(imur, options)
+		return out, err
 	}
-	return nil
+	return out, nil
 }
 
 // ----- Concurrently copy with checkpoint  -----
@@ -264,8 +460,8 @@ func (cp copyCheckpoint) isValid(bucket *Bucket, objectKey string) (bool, error)
 }
 
 // load from the checkpoint file
-func (cp *copyCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
+func (cp *copyCheckpoint) load(store CheckpointStore, key string) error {
+	contents, err := store.Load(key)
 	if err != nil {
 		return err
 	}
@@ -281,7 +477,7 @@ func (cp *copyCheckpoint) update(part UploadPart) {
 }
 
 // dump the cp to the file
-func (cp *copyCheckpoint) dump(filePath string) error {
+func (cp *copyCheckpoint) dump(store CheckpointStore, key string) error {
 	bcp := *cp
 
 	// calculates MD5
@@ -301,7 +497,7 @@ func (cp *copyCheckpoint) dump(filePath string) error {
 	}
 
 	// dum
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return store.Save(key, js)
 }
 
 // unfinished parts
@@ -372,14 +568,14 @@ func (cp *copyCheckpoint) prepare(srcBucket *Bucket, srcObjectKey string, destBu
 	return nil
 }
 
-func (cp *copyCheckpoint) complete(bucket *Bucket, parts []UploadPart, cpFilePath string) error {
+func (cp *copyCheckpoint) complete(bucket *Bucket, parts []UploadPart, cpStore CheckpointStore, cpFilePath string) error {
 	imur := InitiateMultipartUploadResult{Bucket: cp.DestBucketName,
 		Key: cp.DestObjectKey, UploadID: cp.CopyID}
 	_, err := bucket.CompleteMultipartUpload(imur, parts)
 	if err != nil {
 		return err
 	}
-	os.Remove(cpFilePath)
+	cpStore.Delete(cpFilePath)
 	return err
 }
 
@@ -389,21 +585,31 @@ func (bucket Bucket) copyFileWithCp(srcBucketName, srcObjectKey, destBucketName,
 	descBucket, err := bucket.Client.Bucket(destBucketName)
 	srcBucket, err := bucket.Client.Bucket(srcBucketName)
 	listener := getProgressListener(options)
+	cpStore := getCpStore(options)
+
+	initOptions, err := getCopyInitOptions(options)
+	if err != nil {
+		return err
+	}
+	partOptions, err := getCopyPartOptions(options)
+	if err != nil {
+		return err
+	}
 
 	// LOAD CP data
 	ccp := copyCheckpoint{}
-	err = ccp.load(cpFilePath)
+	err = ccp.load(cpStore, cpFilePath)
 	if err != nil {
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	// LOAD error or the cp data is invalid---reinitialize
 	valid, err := ccp.isValid(srcBucket, srcObjectKey)
 	if err != nil || !valid {
-		if err = ccp.prepare(srcBucket, srcObjectKey, descBucket, destObjectKey, partSize, options); err != nil {
+		if err = ccp.prepare(srcBucket, srcObjectKey, descBucket, destObjectKey, partSize, initOptions); err != nil {
 			return err
 		}
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	// unfinished parts.
@@ -423,7 +629,7 @@ func (bucket Bucket) copyFileWithCp(srcBucketName, srcObjectKey, destBucketName,
 	publishProgress(listener, event)
 
 	// start the worker threads
-	arg := copyWorkerArg{descBucket, imur, srcBucketName, srcObjectKey, options, copyPartHooker}
+	arg := copyWorkerArg{descBucket, imur, srcBucketName, srcObjectKey, partOptions, copyPartHooker}
 	for w := 1; w <= routines; w++ {
 		go copyWorker(w, arg, jobs, results, failed, die)
 	}
@@ -432,18 +638,24 @@ func (bucket Bucket) copyFileWithCp(srcBucketName, srcObjectKey, destBucketName,
 	go copyScheduler(jobs, parts)
 
 	// waits for the parts completed.
+	batcher := getCpDumpBatcher(options)
 	completed := 0
 	for completed < len(parts) {
 		select {
 		case part := <-results:
 			completed++
 			ccp.update(part)
-			ccp.dump(cpFilePath)
+			if batcher.next() {
+				ccp.dump(cpStore, cpFilePath)
+			}
 			completedBytes += (parts[part.PartNumber-1].End - parts[part.PartNumber-1].Start + 1)
 			event = newProgressEvent(TransferDataEvent, completedBytes, ccp.ObjStat.Size)
 			publishProgress(listener, event)
 		case err := <-failed:
 			close(die)
+			if batcher.flush() {
+				ccp.dump(cpStore, cpFilePath)
+			}
 			event = newProgressEvent(TransferFailedEvent, completedBytes, ccp.ObjStat.Size)
 			publishProgress(listener, event)
 			return err
@@ -454,8 +666,12 @@ func (bucket Bucket) copyFileWithCp(srcBucketName, srcObjectKey, destBucketName,
 		}
 	}
 
+	if batcher.flush() {
+		ccp.dump(cpStore, cpFilePath)
+	}
+
 	event = newProgressEvent(TransferCompletedEvent, completedBytes, ccp.ObjStat.Size)
 	publishProgress(listener, event)
 
-	return ccp.complete(descBucket, ccp.CopyParts, cpFilePath)
+	return ccp.complete(descBucket, ccp.CopyParts, cpStore, cpFilePath)
 }
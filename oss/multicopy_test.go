@@ -2,7 +2,11 @@ package oss
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -192,6 +196,41 @@ func (s *OssCopySuite) TestCopyRoutineWithoutRecovery(c *C) {
 	c.Assert(err, IsNil)
 }
 
+// TestCopyFileReplaceMeta copies a multipart object with replaced metadata
+func (s *OssCopySuite) TestCopyFileReplaceMeta(c *C) {
+	srcObjectName := objectNamePrefix + "tcfrm"
+	destObjectName := srcObjectName + "-copy"
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+
+	err := s.bucket.UploadFile(srcObjectName, fileName, 100*1024, Routines(3), Meta("myprop", "srcval"))
+	c.Assert(err, IsNil)
+
+	options := []Option{
+		Routines(3),
+		MetadataDirective(MetaReplace),
+		Meta("myprop", "destval"),
+		Meta("newprop", "newval"),
+		CopySourceIfModifiedSince(pastDate),
+	}
+	err = s.bucket.CopyFile(bucketName, srcObjectName, destObjectName, 100*1024, options...)
+	c.Assert(err, IsNil)
+
+	meta, err := s.bucket.GetObjectDetailedMeta(destObjectName)
+	c.Assert(err, IsNil)
+	c.Assert(meta.Get("X-Oss-Meta-Myprop"), Equals, "destval")
+	c.Assert(meta.Get("X-Oss-Meta-Newprop"), Equals, "newval")
+
+	srcMeta, err := s.bucket.GetObjectDetailedMeta(srcObjectName)
+	c.Assert(err, IsNil)
+	c.Assert(srcMeta.Get("X-Oss-Meta-Myprop"), Equals, "srcval")
+	c.Assert(meta.Get(HTTPHeaderContentLength), Equals, srcMeta.Get(HTTPHeaderContentLength))
+
+	err = s.bucket.DeleteObject(destObjectName)
+	c.Assert(err, IsNil)
+	err = s.bucket.DeleteObject(srcObjectName)
+	c.Assert(err, IsNil)
+}
+
 // CopyErrorHooker CopyPart request hook
 func CopyErrorHooker(part copyPart) error {
 	if part.Number == 5 {
@@ -250,7 +289,8 @@ func (s *OssCopySuite) TestCopyRoutineWithRecovery(c *C) {
 	c.Assert(err, IsNil)
 	os.Remove(newFile)
 
-	// Routines default value，CP's default path is destObjectName+.cp
+	// Routines default value，CP's default path is destObjectName.<md5>.cp
+	cpFile := getCpFilePath("", destObjectName, "/"+bucketName+"/"+srcObjectName, bucketName, destObjectName, 1024*100)
 	// Copy object with checkpoint enabled, single threaded.
 	// Copy 4 parts---the CopyErrorHooker makes sure the copy of part 5 will fail.
 	copyPartHooker = CopyErrorHooker
@@ -261,7 +301,7 @@ func (s *OssCopySuite) TestCopyRoutineWithRecovery(c *C) {
 
 	// check cp
 	ccp := copyCheckpoint{}
-	err = ccp.load(destObjectName + ".cp")
+	err = ccp.load(FileCheckpointStore{}, cpFile)
 	c.Assert(err, IsNil)
 	c.Assert(ccp.Magic, Equals, copyCpMagic)
 	c.Assert(len(ccp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
@@ -292,7 +332,7 @@ func (s *OssCopySuite) TestCopyRoutineWithRecovery(c *C) {
 	c.Assert(err, IsNil)
 	os.Remove(newFile)
 
-	err = ccp.load(fileName + ".cp")
+	err = ccp.load(FileCheckpointStore{}, cpFile)
 	c.Assert(err, NotNil)
 
 	// Specifies Routine and CP's path
@@ -304,7 +344,7 @@ func (s *OssCopySuite) TestCopyRoutineWithRecovery(c *C) {
 
 	// check cp
 	ccp = copyCheckpoint{}
-	err = ccp.load(srcObjectName + ".cp")
+	err = ccp.load(FileCheckpointStore{}, srcObjectName+".cp")
 	c.Assert(err, IsNil)
 	c.Assert(ccp.Magic, Equals, copyCpMagic)
 	c.Assert(len(ccp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
@@ -335,7 +375,7 @@ func (s *OssCopySuite) TestCopyRoutineWithRecovery(c *C) {
 	c.Assert(err, IsNil)
 	os.Remove(newFile)
 
-	err = ccp.load(srcObjectName + ".cp")
+	err = ccp.load(FileCheckpointStore{}, srcObjectName+".cp")
 	c.Assert(err, NotNil)
 
 	// First copy without error.
@@ -467,3 +507,120 @@ func (s *OssCopySuite) TestCopyFileCrossBucket(c *C) {
 	err = s.client.DeleteBucket(destBucketName)
 	c.Assert(err, IsNil)
 }
+
+func (s *OssBucketMockSuite) TestCopyObjectRange(c *C) {
+	var copyRanges []string
+	var completed bool
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			c.Assert(r.Header.Get(HTTPHeaderOssCopySource), Equals, "/mock-bucket/src")
+			copyRanges = append(copyRanges, r.Header.Get(HTTPHeaderOssCopySourceRange))
+			return true
+		},
+		complete: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			completed = true
+			return true
+		},
+		other: func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set(HTTPHeaderContentLength, "1000")
+			w.WriteHeader(http.StatusOK)
+		},
+	}), c)
+	defer server.Close()
+
+	err := bucket.CopyObjectRange("src", "dest", 100, 200)
+	c.Assert(err, IsNil)
+	c.Assert(copyRanges, DeepEquals, []string{"bytes=100-199"})
+	c.Assert(completed, Equals, true)
+
+	// invalid range
+	err = bucket.CopyObjectRange("src", "dest", 200, 100)
+	c.Assert(err, NotNil)
+	err = bucket.CopyObjectRange("src", "dest", -1, 100)
+	c.Assert(err, NotNil)
+	err = bucket.CopyObjectRange("src", "dest", 100, 1001)
+	c.Assert(err, NotNil)
+	c.Assert(len(copyRanges), Equals, 1)
+}
+
+func (s *OssBucketMockSuite) TestCopyObjectLarge(c *C) {
+	var mu sync.Mutex
+	var objectSize int64
+	var heads, copyParts int
+	var initHeader http.Header
+	handler := newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			initHeader = r.Header
+			return ""
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			copyParts++
+			c.Assert(r.Header.Get(HTTPHeaderOssCopySource), Equals, "/mock-bucket/src")
+			return true
+		},
+		complete: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			io.WriteString(w, "<CompleteMultipartUploadResult><Key>dest</Key><ETag>\"multipart-etag\"</ETag></CompleteMultipartUploadResult>")
+			return false
+		},
+		other: func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "HEAD":
+				heads++
+				w.Header().Set(HTTPHeaderContentLength, strconv.FormatInt(objectSize, 10))
+				w.Header().Set(HTTPHeaderContentType, "video/mp4")
+				w.Header().Set(HTTPHeaderOssMetaPrefix+"Color", "red")
+				w.WriteHeader(http.StatusOK)
+			case "PUT":
+				// the copy request fails for the large object
+				w.WriteHeader(http.StatusBadRequest)
+				io.WriteString(w, "<Error><Code>InvalidArgument</Code><Message>The source object is too large</Message></Error>")
+			}
+		},
+	})
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		handler(w, r)
+	}, c)
+	defer server.Close()
+
+	// the source object is larger than 5GB, it's copied by 100MB parts with the source metadata
+	objectSize = MaxCopyObjectSize + 1
+	res, err := bucket.CopyObject("src", "dest", ObjectACL(ACLPublicRead), Meta("color", "blue"))
+	c.Assert(err, IsNil)
+	c.Assert(res.ETag, Equals, "\"multipart-etag\"")
+	c.Assert(heads, Equals, 2)
+	c.Assert(int64(copyParts), Equals, (objectSize+largeCopyPartSize-1)/largeCopyPartSize)
+	c.Assert(initHeader.Get(HTTPHeaderOssObjectACL), Equals, string(ACLPublicRead))
+	c.Assert(initHeader.Get(HTTPHeaderContentType), Equals, "video/mp4")
+	c.Assert(initHeader.Get(HTTPHeaderOssMetaPrefix+"Color"), Equals, "red")
+	c.Assert(initHeader.Get(HTTPHeaderOssCopySource), Equals, "")
+
+	// the metadata is replaced
+	_, err = bucket.CopyObject("src", "dest", MetadataDirective(MetaReplace), Meta("color", "blue"))
+	c.Assert(err, IsNil)
+	c.Assert(initHeader.Get(HTTPHeaderContentType), Not(Equals), "video/mp4")
+	c.Assert(initHeader.Get(HTTPHeaderOssMetaPrefix+"Color"), Equals, "blue")
+
+	// to another bucket
+	_, err = bucket.CopyObjectTo("mock-bucket", "dest", "src")
+	c.Assert(err, IsNil)
+
+	// the copy error is returned for the object not larger than 5GB and the versioned source object
+	objectSize = MaxCopyObjectSize
+	heads, copyParts = 0, 0
+	_, err = bucket.CopyObject("src", "dest")
+	c.Assert(err, NotNil)
+	c.Assert(err.(ServiceError).Code, Equals, "InvalidArgument")
+	c.Assert(heads, Equals, 1)
+
+	objectSize = MaxCopyObjectSize + 1
+	_, err = bucket.CopyObject("src", "dest", VersionId("v1"))
+	c.Assert(err, NotNil)
+	c.Assert(heads, Equals, 1)
+	c.Assert(copyParts, Equals, 0)
+}
@@ -19,10 +19,12 @@ import (
 // https://help.aliyun.com/document_detail/oss/api-reference/multipart-upload/InitiateMultipartUpload.html
 //
 // InitiateMultipartUploadResult the return value of the InitiateMultipartUpload, which is used for calls later on such as UploadPartFromFile,UploadPartCopy.
+// Its ServerSideEncryption and ServerSideEncryptionKeyID are the encryption applied by OSS, which are from the response headers.
 // error  If the operation succeeds, it's nil; otherwise it's the error object
 //
 func (bucket Bucket) InitiateMultipartUpload(objectKey string, options ...Option) (InitiateMultipartUploadResult, error) {
 	var imur InitiateMultipartUploadResult
+	bucket.warnUnusualOptions(objectKey, options)
 	opts := addContentType(options, objectKey)
 	params := map[string]interface{}{}
 	params["uploads"] = nil
@@ -33,7 +35,12 @@ func (bucket Bucket) InitiateMultipartUpload(objectKey string, options ...Option
 	defer resp.Body.Close()
 
 	err = xmlUnmarshal(resp.Body, &imur)
-	return imur, err
+	if err != nil {
+		return imur, err
+	}
+	imur.ServerSideEncryption = resp.Headers.Get(HTTPHeaderOssServerSideEncryption)
+	imur.ServerSideEncryptionKeyID = resp.Headers.Get(HTTPHeaderOssServerSideEncryptionKeyID)
+	return imur, nil
 }
 
 //
@@ -111,6 +118,7 @@ func (bucket Bucket) UploadPartFromFile(imur InitiateMultipartUploadResult, file
 func (bucket Bucket) DoUploadPart(request *UploadPartRequest, options []Option) (*UploadPartResult, error) {
 	listener := getProgressListener(options)
 	opts := []Option{ContentLength(request.PartSize)}
+	opts = append(opts, options...)
 	params := map[string]interface{}{}
 	params["partNumber"] = strconv.Itoa(request.PartNumber)
 	params["uploadId"] = request.InitResult.UploadID
@@ -127,7 +135,7 @@ func (bucket Bucket) DoUploadPart(request *UploadPartRequest, options []Option)
 	}
 
 	if bucket.getConfig().IsEnableCRC {
-		err = checkCRC(resp, "DoUploadPart")
+		err = bucket.checkCRC(resp, "DoUploadPart")
 		if err != nil {
 			return &UploadPartResult{part}, err
 		}
@@ -156,7 +164,7 @@ func (bucket Bucket) UploadPartCopy(imur InitiateMultipartUploadResult, srcBucke
 	var out UploadPartCopyResult
 	var part UploadPart
 
-	opts := []Option{CopySource(srcBucketName, srcObjectKey),
+	opts := []Option{CopySource(srcBucketName, escapeCopySourceKey(srcObjectKey)),
 		CopySourceRange(startPosition, partSize)}
 	opts = append(opts, options...)
 	params := map[string]interface{}{}
@@ -183,12 +191,13 @@ func (bucket Bucket) UploadPartCopy(imur InitiateMultipartUploadResult, srcBucke
 //
 // imur   The return value of InitiateMultipartUpload.
 // parts  The array of return value of UploadPart/UploadPartFromFile/UploadPartCopy.
+// options  The options for the request, such as CaptureResponseHeaders.
 //
 // CompleteMultipartUploadResponse  The return value when the call succeeds. Only valid when the error is nil.
 // error  If the operation succeeds, it's nil; otherwise it's the error object
 //
 func (bucket Bucket) CompleteMultipartUpload(imur InitiateMultipartUploadResult,
-	parts []UploadPart) (CompleteMultipartUploadResult, error) {
+	parts []UploadPart, options ...Option) (CompleteMultipartUploadResult, error) {
 	var out CompleteMultipartUploadResult
 
 	sort.Sort(uploadParts(parts))
@@ -203,7 +212,7 @@ func (bucket Bucket) CompleteMultipartUpload(imur InitiateMultipartUploadResult,
 
 	params := map[string]interface{}{}
 	params["uploadId"] = imur.UploadID
-	resp, err := bucket.do("POST", imur.Key, params, nil, buffer, nil)
+	resp, err := bucket.do("POST", imur.Key, params, options, buffer, nil)
 	if err != nil {
 		return out, err
 	}
@@ -235,15 +244,16 @@ func (bucket Bucket) AbortMultipartUpload(imur InitiateMultipartUploadResult) er
 // ListUploadedParts Lists the uploaded parts.
 //
 // imur  The return value of InitiateMultipartUpload.
+// options  The options for the request, such as CaptureResponseHeaders.
 //
 // ListUploadedPartsResponse  the return value of the successful call. It's valid only when error is nil.
 // error  If the operation succeeds, it's nil; otherwise it's the error object
 //
-func (bucket Bucket) ListUploadedParts(imur InitiateMultipartUploadResult) (ListUploadedPartsResult, error) {
+func (bucket Bucket) ListUploadedParts(imur InitiateMultipartUploadResult, options ...Option) (ListUploadedPartsResult, error) {
 	var out ListUploadedPartsResult
 	params := map[string]interface{}{}
 	params["uploadId"] = imur.UploadID
-	resp, err := bucket.do("GET", imur.Key, params, nil, nil, nil)
+	resp, err := bucket.do("GET", imur.Key, params, options, nil, nil)
 	if err != nil {
 		return out, err
 	}
@@ -272,7 +282,7 @@ func (bucket Bucket) ListMultipartUploads(options ...Option) (ListMultipartUploa
 	}
 	params["uploads"] = nil
 
-	resp, err := bucket.do("GET", "", params, nil, nil, nil)
+	resp, err := bucket.do("GET", "", params, options, nil, nil)
 	if err != nil {
 		return out, err
 	}
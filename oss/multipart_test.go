@@ -947,3 +947,35 @@ func shuffleArray(chunks []FileChunk) []FileChunk {
 	}
 	return chunks
 }
+
+func (s *OssBucketMockSuite) TestInitiateMultipartUploadEncryption(c *C) {
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			if sse := r.Header.Get(HTTPHeaderOssServerSideEncryption); sse != "" {
+				w.Header().Set(HTTPHeaderOssServerSideEncryption, sse)
+				if sse == "KMS" {
+					w.Header().Set(HTTPHeaderOssServerSideEncryptionKeyID, "kms-key-id")
+				}
+			}
+			return ""
+		},
+	}), c)
+	defer server.Close()
+
+	imur, err := bucket.InitiateMultipartUpload("object", ServerSideEncryption("KMS"))
+	c.Assert(err, IsNil)
+	c.Assert(imur.UploadID, Equals, "upload-id")
+	c.Assert(imur.ServerSideEncryption, Equals, "KMS")
+	c.Assert(imur.ServerSideEncryptionKeyID, Equals, "kms-key-id")
+
+	imur, err = bucket.InitiateMultipartUpload("object", ServerSideEncryption("AES256"))
+	c.Assert(err, IsNil)
+	c.Assert(imur.ServerSideEncryption, Equals, "AES256")
+	c.Assert(imur.ServerSideEncryptionKeyID, Equals, "")
+
+	// not encrypted
+	imur, err = bucket.InitiateMultipartUpload("object")
+	c.Assert(err, IsNil)
+	c.Assert(imur.UploadID, Equals, "upload-id")
+	c.Assert(imur.ServerSideEncryption, Equals, "")
+}
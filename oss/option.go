@@ -1,8 +1,10 @@
 package oss
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +20,32 @@ const (
 
 const (
 	deleteObjectsQuiet = "delete-objects-quiet"
+	failOnPartialError = "x-fail-on-partial-error"
 	routineNum         = "x-routine-num"
+	partRetries        = "x-part-retries"
 	checkpointConfig   = "x-cp-config"
+	checkpointStore    = "x-cp-store"
+	cpDumpInterval     = "x-cp-dump-interval"
 	initCRC64          = "init-crc64"
 	progressListener   = "x-progress-listener"
+	progressInterval   = "x-progress-interval"
 	storageClass       = "storage-class"
+	readAhead          = "x-read-ahead"
+	verifyETag         = "x-verify-etag"
+	responseHeader     = "x-response-header"
+	contextArg         = "x-context"
+	sniffContentType   = "x-sniff-content-type"
+	withTags           = "x-with-tags"
+	deleteExtraneous   = "x-delete-extraneous"
+	refererTruncate    = "x-referer-truncate-path"
+	refererHTTPSOnly   = "x-referer-https-only"
+	maxObjectSize      = "x-max-object-size"
+	keepParts          = "x-keep-parts-on-complete-failure"
+	userAgentSuffix    = "x-user-agent-suffix"
+	verifyCRC          = "x-verify-crc"
+	stageDownloads     = "x-stage-downloads"
+	confirmDeleted     = "x-confirm-deleted"
+	maxPages           = "x-max-pages"
 )
 
 type (
@@ -75,6 +98,13 @@ func Expires(t time.Time) Option {
 	return setHeader(HTTPHeaderExpires, t.Format(http.TimeFormat))
 }
 
+// ExpiresIn is an option to set Expires header to the time after the duration from now
+func ExpiresIn(d time.Duration) Option {
+	return func(params map[string]optionValue) error {
+		return Expires(time.Now().UTC().Add(d))(params)
+	}
+}
+
 // Meta is an option to set Meta header
 func Meta(key, value string) Option {
 	return setHeader(HTTPHeaderOssMetaPrefix+key, value)
@@ -110,12 +140,34 @@ func IfMatch(value string) Option {
 	return setHeader(HTTPHeaderIfMatch, value)
 }
 
-// IfNoneMatch is an option to set IfNoneMatch header
+// IfNoneMatch is an option to set IfNoneMatch header. IfNoneMatch("*") for PutObject creates the object only if it does
+// not exist, ErrObjectAlreadyExists is returned otherwise.
 func IfNoneMatch(value string) Option {
 	return setHeader(HTTPHeaderIfNoneMatch, value)
 }
 
-// CopySource is an option to set X-Oss-Copy-Source header
+// ForbidOverwrite is an option to set X-Oss-Forbid-Overwrite header, PutObject with ForbidOverwrite(true) creates the
+// object only if it does not exist, ErrObjectAlreadyExists is returned otherwise.
+func ForbidOverwrite(forbid bool) Option {
+	return setHeader(HTTPHeaderOssForbidOverwrite, strconv.FormatBool(forbid))
+}
+
+// TrafficLimit is an option to set X-Oss-Traffic-Limit header, which limits the speed of the request's body in bits
+// per second, such as PutObject, GetObject and UploadPart. SignURL puts it in the url. The limit is from
+// MinTrafficLimit to MaxTrafficLimit, otherwise the option fails before the request is sent.
+func TrafficLimit(bitsPerSec int64) Option {
+	return func(params map[string]optionValue) error {
+		if bitsPerSec < MinTrafficLimit || bitsPerSec > MaxTrafficLimit {
+			return fmt.Errorf("oss: invalid traffic limit %d, it should be from %d to %d bits per second",
+				bitsPerSec, MinTrafficLimit, MaxTrafficLimit)
+		}
+		params[HTTPHeaderOssTrafficLimit] = optionValue{strconv.FormatInt(bitsPerSec, 10), optionHTTP}
+		return nil
+	}
+}
+
+// CopySource is an option to set X-Oss-Copy-Source header, the sourceObject is set as is so it must be URL encoded.
+// CopyObject and UploadPartCopy encode the source object key themselves.
 func CopySource(sourceBucket, sourceObject string) Option {
 	return setHeader(HTTPHeaderOssCopySource, "/"+sourceBucket+"/"+sourceObject)
 }
@@ -162,6 +214,28 @@ func ObjectACL(acl ACLType) Option {
 	return setHeader(HTTPHeaderOssObjectACL, string(acl))
 }
 
+// SetObjectTagging is an option to set X-Oss-Tagging header, the tag keys and values are URL-encoded
+func SetObjectTagging(tagging Tagging) Option {
+	tags := make([]string, len(tagging.Tags))
+	for i, tag := range tagging.Tags {
+		tags[i] = url.QueryEscape(tag.Key) + "=" + url.QueryEscape(tag.Value)
+	}
+	return setHeader(HTTPHeaderOssTagging, strings.Join(tags, "&"))
+}
+
+// ObjectStorageClass is an option to set X-Oss-Storage-Class header, which overrides the bucket's default storage class
+func ObjectStorageClass(value StorageClassType) Option {
+	return func(params map[string]optionValue) error {
+		switch value {
+		case StorageStandard, StorageIA, StorageArchive, StorageColdArchive:
+		default:
+			return fmt.Errorf("oss: invalid storage class %s", value)
+		}
+		params[HTTPHeaderOssStorageClass] = optionValue{string(value), optionHTTP}
+		return nil
+	}
+}
+
 // symlinkTarget is an option to set X-Oss-Symlink-Target
 func symlinkTarget(targetObjectKey string) Option {
 	return setHeader(HTTPHeaderOssSymlinkTarget, targetObjectKey)
@@ -217,6 +291,24 @@ func DeleteObjectsQuiet(isQuiet bool) Option {
 	return addArg(deleteObjectsQuiet, isQuiet)
 }
 
+// ConfirmDeleted true:DeleteObjects returns the deleted keys to confirm, it's in verbose mode even with
+// DeleteObjectsQuiet(true), and the keys failed to delete are returned in the result without the error, even with
+// FailOnPartialError(true). Default is false
+func ConfirmDeleted(isConfirm bool) Option {
+	return addArg(confirmDeleted, isConfirm)
+}
+
+// FailOnPartialError true:DeleteObjects returns DeleteObjectsError if any object failed to delete. Default is false
+func FailOnPartialError(isFail bool) Option {
+	return addArg(failOnPartialError, isFail)
+}
+
+// RequestPayer is an option to set X-Oss-Request-Payer header, the requests to the requester-pays bucket from the
+// others than the bucket owner are rejected without it. The payer is "requester", Requester is accepted too.
+func RequestPayer(payer string) Option {
+	return setHeader(HTTPHeaderOssRequester, strings.ToLower(payer))
+}
+
 // StorageClass bucket storage class
 func StorageClass(value StorageClassType) Option {
 	return addArg(storageClass, value)
@@ -228,26 +320,148 @@ type cpConfig struct {
 	FilePath string
 }
 
-// Checkpoint sets the isEnable flag and checkpoint file path for DownloadFile/UploadFile.
+// Checkpoint sets the isEnable flag and checkpoint file path for DownloadFile/UploadFile/CopyFile. When the filePath
+// is empty, UploadFile uses <file>.<md5>.cp next to the file and CopyFile uses <base of dest key>.<md5>.cp in the
+// working directory, the MD5 is of the source, the destination object and the part size. DownloadFile uses <file>.cp.
 func Checkpoint(isEnable bool, filePath string) Option {
 	return addArg(checkpointConfig, &cpConfig{isEnable, filePath})
 }
 
+// CheckpointStorage sets the store of the checkpoints for DownloadFile/UploadFile/CopyFile, the checkpoint still needs
+// to be enabled by Checkpoint. By default the checkpoints are saved in the local files.
+func CheckpointStorage(store CheckpointStore) Option {
+	return addArg(checkpointStore, store)
+}
+
+// CheckpointDumpInterval sets how many completed parts are batched before the checkpoint file is written for
+// DownloadFile/UploadFile/CopyFile. By default the checkpoint is written on every part completion.
+// The pending parts are always written when the transfer fails.
+func CheckpointDumpInterval(n int) Option {
+	return addArg(cpDumpInterval, n)
+}
+
+// MaxPages bounds the pages of ListObjects fetched by ListObjectsIterator, ForEachObject, ListObjectsSince, Sync and
+// DownloadDir, so that a server always reporting IsTruncated does not make them loop forever. The listing fails with
+// MaxPagesError when the last page is still truncated. By default it's 0 and there's no bound.
+func MaxPages(n int) Option {
+	return addArg(maxPages, n)
+}
+
+// getMaxPages gets the bound of MaxPages, it's 0 if there's none
+func getMaxPages(options []Option) int {
+	n, err := findOption(options, maxPages, 0)
+	if err != nil || n.(int) < 0 {
+		return 0
+	}
+	return n.(int)
+}
+
 // Routines DownloadFile/UploadFile thread count
 func Routines(n int) Option {
 	return addArg(routineNum, n)
 }
 
+// PartRetries UploadFile retry count of each failed part, with backoff between the retries. By default it's 0.
+func PartRetries(n int) Option {
+	return addArg(partRetries, n)
+}
+
 // InitCRC Init AppendObject CRC
 func InitCRC(initCRC uint64) Option {
 	return addArg(initCRC64, initCRC)
 }
 
+// ReadAhead sets the read-ahead buffer size for GetObject, small reads are served from the buffer
+func ReadAhead(bufSize int) Option {
+	return addArg(readAhead, bufSize)
+}
+
+// VerifyCRC is an option for GetObject to check the CRC64 of the streamed data against the object's when the body is read
+// to the end, the mismatch is returned by the last Read and Close. It's ignored for the Range download or with CRC disabled.
+func VerifyCRC(isVerify bool) Option {
+	return addArg(verifyCRC, isVerify)
+}
+
+// VerifyETag is an option to compute the content MD5 locally and compare it with the ETag returned by PutObject
+func VerifyETag(isVerify bool) Option {
+	return addArg(verifyETag, isVerify)
+}
+
+// CaptureResponseHeaders is an option to get the response headers, for the methods sending several requests it's the headers of the last response
+func CaptureResponseHeaders(respHeader *http.Header) Option {
+	return addArg(responseHeader, respHeader)
+}
+
+// WithContext is an option to bind the requests to the context, which could cancel them or set the deadline. The
+// in-flight request is aborted and ctx.Err() is returned when the context is cancelled or its deadline exceeds.
+func WithContext(ctx context.Context) Option {
+	return addArg(contextArg, ctx)
+}
+
+// SniffContentType is an option to detect Content-Type from the content when the object key has no known extension
+func SniffContentType(isSniff bool) Option {
+	return addArg(sniffContentType, isSniff)
+}
+
+// WithTags is an option to fetch the tags of each listed object in ListObjects, it costs one GetObjectTagging request per object
+func WithTags(isWithTags bool) Option {
+	return addArg(withTags, isWithTags)
+}
+
+// DeleteExtraneous is an option for Sync to delete the objects without the local files
+func DeleteExtraneous(isDelete bool) Option {
+	return addArg(deleteExtraneous, isDelete)
+}
+
+// StageDownloads is an option for DownloadDir to move the files into place only after all the downloads succeed
+func StageDownloads(isStage bool) Option {
+	return addArg(stageDownloads, isStage)
+}
+
+// RefererTruncatePath is an option for SetBucketReferer to match the referrer without its path
+func RefererTruncatePath(isTruncate bool) Option {
+	return addArg(refererTruncate, isTruncate)
+}
+
+// RefererAllowHTTPSOnly is an option for SetBucketReferer to allow the https referrers only
+func RefererAllowHTTPSOnly(isHTTPSOnly bool) Option {
+	return addArg(refererHTTPSOnly, isHTTPSOnly)
+}
+
+// MaxObjectSize is an option for GetObjects to fail the object larger than the size instead of reading it into memory
+func MaxObjectSize(size int64) Option {
+	return addArg(maxObjectSize, size)
+}
+
+// UserAgentSuffix is an option to append the suffix to the client's User-Agent for the requests of the call only, such as
+// the origin of the request. For UploadFile, DownloadFile, CopyFile and Sync it's appended for all their requests.
+func UserAgentSuffix(suffix string) Option {
+	return addArg(userAgentSuffix, suffix)
+}
+
+// KeepPartsOnCompleteFailure is an option for UploadFile and CopyFile to keep the uploaded parts when the completion fails,
+// so the completion could be retried later. By default the multipart upload is aborted without the checkpoint.
+func KeepPartsOnCompleteFailure(isKeep bool) Option {
+	return addArg(keepParts, isKeep)
+}
+
 // Progress set progress listener
 func Progress(listener ProgressListener) Option {
 	return addArg(progressListener, listener)
 }
 
+// ProgressInterval throttles the TransferDataEvent of Progress, the data event within the interval since the previous
+// event is not published, except the one of the last bytes. The last one held is published before the completed
+// or failed event, so the listener always sees the final bytes. By default all the data events are published.
+func ProgressInterval(interval time.Duration) Option {
+	return addArg(progressInterval, interval)
+}
+
+// VersionId is an option to set versionId param, it selects the object version to get, delete or copy from
+func VersionId(value string) Option {
+	return addParam(HTTPParamVersionID, value)
+}
+
 // ResponseContentType is an option to set response-content-type param
 func ResponseContentType(value string) Option {
 	return addParam("response-content-type", value)
@@ -330,6 +544,44 @@ func handleOptions(headers map[string]string, options []Option) error {
 	return nil
 }
 
+// setUserAgentSuffix sets the User-Agent header with the suffix of the UserAgentSuffix option
+func setUserAgentSuffix(headers map[string]string, options []Option, userAgent string) error {
+	suffix, err := findOption(options, userAgentSuffix, "")
+	if err != nil {
+		return err
+	}
+	if suffix.(string) != "" {
+		headers[HTTPHeaderUserAgent] = userAgent + " " + suffix.(string)
+	}
+	return nil
+}
+
+// isListArg returns true for the args of the requests and the listing, such as MaxPages
+func isListArg(key string) bool {
+	return isRequestArg(key) || key == maxPages
+}
+
+// isRequestArg returns true for the arguments and headers applied to every request of the call, they're kept for
+// the requests of the parts or the objects by the methods sending several requests
+func isRequestArg(key string) bool {
+	return key == contextArg || key == userAgentSuffix || key == HTTPHeaderOssRequester
+}
+
+func getContext(options []Option) context.Context {
+	ctx, _ := findOption(options, contextArg, nil)
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx.(context.Context)
+}
+
+func captureResponseHeaders(options []Option, resp *Response) {
+	respHeader, _ := findOption(options, responseHeader, nil)
+	if respHeader != nil && resp != nil {
+		*respHeader.(*http.Header) = resp.Headers
+	}
+}
+
 func getRawParams(options []Option) (map[string]interface{}, error) {
 	// option
 	params := map[string]optionValue{}
@@ -353,6 +605,30 @@ func getRawParams(options []Option) (map[string]interface{}, error) {
 	return paramsm, nil
 }
 
+// filterOptions returns the options whose keys are accepted by the filter function
+func filterOptions(options []Option, keep func(key string) bool) ([]Option, error) {
+	params := map[string]optionValue{}
+	for _, option := range options {
+		if option != nil {
+			if err := option(params); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	opts := []Option{}
+	for k, v := range params {
+		if keep(k) {
+			key, val := k, v
+			opts = append(opts, func(params map[string]optionValue) error {
+				params[key] = val
+				return nil
+			})
+		}
+	}
+	return opts, nil
+}
+
 func findOption(options []Option, param string, defaultVal interface{}) (interface{}, error) {
 	params := map[string]optionValue{}
 	for _, option := range options {
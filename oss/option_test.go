@@ -2,6 +2,7 @@ package oss
 
 import (
 	"net/http"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -257,3 +258,104 @@ func (s *OssOptionSuite) TestFindOption(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(str, Equals, "")
 }
+
+func (s *OssOptionSuite) TestFilterOptions(c *C) {
+	options := []Option{Meta("myprop", "myval"), MetadataDirective(MetaReplace),
+		CopySourceIfMatch("etag"), Routines(3)}
+
+	opts, err := getCopyInitOptions(options)
+	c.Assert(err, IsNil)
+	headers := map[string]string{}
+	c.Assert(handleOptions(headers, opts), IsNil)
+	c.Assert(headers, DeepEquals, map[string]string{"X-Oss-Meta-myprop": "myval"})
+	c.Assert(getRoutines(opts), Equals, 3)
+
+	opts, err = getCopyPartOptions(options)
+	c.Assert(err, IsNil)
+	headers = map[string]string{}
+	c.Assert(handleOptions(headers, opts), IsNil)
+	c.Assert(headers, DeepEquals, map[string]string{HTTPHeaderOssCopySourceIfMatch: "etag"})
+}
+
+func (s *OssOptionSuite) TestCheckpointDumpInterval(c *C) {
+	countDumps := func(b *cpDumpBatcher, parts int) int {
+		dumps := 0
+		for i := 0; i < parts; i++ {
+			if b.next() {
+				dumps++
+			}
+		}
+		if b.flush() {
+			dumps++
+		}
+		return dumps
+	}
+
+	// without batching every part is written
+	c.Assert(countDumps(getCpDumpBatcher(nil), 10000), Equals, 10000)
+	c.Assert(countDumps(getCpDumpBatcher([]Option{CheckpointDumpInterval(0)}), 10000), Equals, 10000)
+
+	// with batching
+	c.Assert(countDumps(getCpDumpBatcher([]Option{CheckpointDumpInterval(100)}), 10000), Equals, 100)
+	c.Assert(countDumps(getCpDumpBatcher([]Option{CheckpointDumpInterval(100)}), 10050), Equals, 101)
+
+	// pending parts are flushed
+	b := getCpDumpBatcher([]Option{CheckpointDumpInterval(10)})
+	c.Assert(b.next(), Equals, false)
+	c.Assert(b.flush(), Equals, true)
+	c.Assert(b.flush(), Equals, false)
+}
+
+func (s *OssOptionSuite) TestObjectStorageClass(c *C) {
+	headers := map[string]string{}
+	err := handleOptions(headers, []Option{ObjectStorageClass(StorageIA)})
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderOssStorageClass], Equals, "IA")
+
+	err = handleOptions(headers, []Option{ObjectStorageClass(StorageColdArchive)})
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderOssStorageClass], Equals, "ColdArchive")
+
+	err = handleOptions(map[string]string{}, []Option{ObjectStorageClass("Glacier")})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssOptionSuite) TestExpiresIn(c *C) {
+	d := 7 * 24 * time.Hour
+	before := time.Now().UTC().Add(d).Truncate(time.Second)
+
+	headers := map[string]string{}
+	err := handleOptions(headers, []Option{ExpiresIn(d), CacheControl("max-age=604800")})
+	c.Assert(err, IsNil)
+	after := time.Now().UTC().Add(d)
+
+	expires, err := http.ParseTime(headers[HTTPHeaderExpires])
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderExpires], Equals, expires.Format(http.TimeFormat))
+	c.Assert(expires.Before(before), Equals, false)
+	c.Assert(expires.After(after), Equals, false)
+	c.Assert(headers[HTTPHeaderCacheControl], Equals, "max-age=604800")
+}
+
+func (s *OssOptionSuite) TestSetObjectTagging(c *C) {
+	headers := map[string]string{}
+	tagging := Tagging{Tags: []Tag{{Key: "k1", Value: "v1"}, {Key: "a&b", Value: "c=d 中"}}}
+	err := handleOptions(headers, []Option{SetObjectTagging(tagging)})
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderOssTagging], Equals, "k1=v1&a%26b=c%3Dd+%E4%B8%AD")
+
+	headers = map[string]string{}
+	err = handleOptions(headers, []Option{SetObjectTagging(Tagging{})})
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderOssTagging], Equals, "")
+}
+
+func (s *OssOptionSuite) TestVersionId(c *C) {
+	params, err := getRawParams([]Option{VersionId("CAEQARiBgID"), Range(0, 9)})
+	c.Assert(err, IsNil)
+	c.Assert(params, DeepEquals, map[string]interface{}{"versionId": "CAEQARiBgID"})
+
+	// versionId is a sub-resource, it's signed
+	conn := Conn{}
+	c.Assert(conn.getSubResource(params), Equals, "versionId=CAEQARiBgID")
+}
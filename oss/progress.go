@@ -1,6 +1,10 @@
 package oss
 
-import "io"
+import (
+	"io"
+	"sync"
+	"time"
+)
 
 // ProgressEventType transfer progress event type
 type ProgressEventType int
@@ -21,6 +25,8 @@ type ProgressEvent struct {
 	ConsumedBytes int64
 	TotalBytes    int64
 	EventType     ProgressEventType
+	InstantRate   float64 // the bytes per second since the previous event of the transfer
+	AverageRate   float64 // the bytes per second since the transfer started
 }
 
 // ProgressListener listen progress change
@@ -37,6 +43,69 @@ func newProgressEvent(eventType ProgressEventType, consumed, total int64) *Progr
 		EventType:     eventType}
 }
 
+// rateListener fills the transfer rates of the events by their timing before passing them to the listener, and
+// throttles the data events by the interval. One is created for each transfer by getProgressListener.
+type rateListener struct {
+	listener    ProgressListener
+	interval    time.Duration // the min interval between the data events, 0 means no throttling
+	mu          sync.Mutex
+	start       time.Time      // the time the transfer started
+	last        time.Time      // the time of the previous event
+	lastBytes   int64          // the consumed bytes of the previous event
+	instantRate float64        // the instant rate of the previous event, it's kept for the events too close to measure
+	pending     *ProgressEvent // the last data event held by the throttling
+}
+
+func newRateListener(listener ProgressListener, interval time.Duration) *rateListener {
+	now := time.Now()
+	return &rateListener{listener: listener, interval: interval, start: now, last: now}
+}
+
+func (l *rateListener) ProgressChanged(event *ProgressEvent) {
+	l.mu.Lock()
+	now := time.Now()
+	// the data event of the last bytes is not held, so the transfer without the completed event ends at 100%
+	if event.EventType == TransferDataEvent && l.interval > 0 && now.Sub(l.last) < l.interval &&
+		event.ConsumedBytes != event.TotalBytes {
+		l.pending = event
+		l.mu.Unlock()
+		return
+	}
+
+	// the held data event is flushed before the completed or failed event, the later data event replaces it
+	events := []*ProgressEvent{event}
+	if l.pending != nil && event.EventType != TransferDataEvent {
+		events = []*ProgressEvent{l.pending, event}
+	}
+	l.pending = nil
+	for _, e := range events {
+		l.fillRates(e, now)
+	}
+	l.mu.Unlock()
+
+	for _, e := range events {
+		l.listener.ProgressChanged(e)
+	}
+}
+
+// fillRates fills the rates of the event published at now
+func (l *rateListener) fillRates(event *ProgressEvent, now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		// the consumed bytes restart from 0 when the request is retried
+		delta := event.ConsumedBytes - l.lastBytes
+		if delta < 0 {
+			delta = 0
+		}
+		l.instantRate = float64(delta) / elapsed.Seconds()
+	}
+	event.InstantRate = l.instantRate
+	if elapsed := now.Sub(l.start); elapsed > 0 {
+		event.AverageRate = float64(event.ConsumedBytes) / elapsed.Seconds()
+	}
+	l.last = now
+	l.lastBytes = event.ConsumedBytes
+}
+
 // publishProgress
 func publishProgress(listener ProgressListener, event *ProgressEvent) {
 	if listener != nil && event != nil {
@@ -6,8 +6,11 @@ import (
 	"bytes"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -458,3 +461,130 @@ func (s *OssProgressSuite) TestCopyFile(c *C) {
 
 	testLogger.Println("OssProgressSuite.TestCopyFile")
 }
+
+// rateRecorder records the data events with their rates
+type rateRecorder struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (r *rateRecorder) ProgressChanged(event *ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if event.EventType == TransferDataEvent {
+		r.events = append(r.events, *event)
+	}
+}
+
+func (s *OssBucketMockSuite) TestProgressRate(c *C) {
+	const chunkSize = 64 * 1024
+	const pause = 200 * time.Millisecond
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HTTPHeaderContentLength, strconv.Itoa(2*chunkSize))
+		w.WriteHeader(http.StatusOK)
+		w.Write(make([]byte, chunkSize))
+		w.(http.Flusher).Flush()
+		time.Sleep(pause)
+		w.Write(make([]byte, chunkSize))
+	}, c, EnableCRC(false))
+	defer server.Close()
+
+	recorder := &rateRecorder{}
+	start := time.Now()
+	body, err := bucket.GetObject("object", Progress(recorder))
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	body.Close()
+	elapsed := time.Since(start)
+
+	// the rates are bounded by the pause in the middle of the transfer
+	c.Assert(len(recorder.events) > 1, Equals, true)
+	last := recorder.events[len(recorder.events)-1]
+	c.Assert(last.ConsumedBytes, Equals, int64(2*chunkSize))
+	c.Assert(last.AverageRate > 0, Equals, true)
+	c.Assert(last.AverageRate <= float64(2*chunkSize)/pause.Seconds(), Equals, true)
+	c.Assert(last.AverageRate >= float64(2*chunkSize)/elapsed.Seconds(), Equals, true)
+	for _, event := range recorder.events {
+		c.Assert(event.InstantRate >= 0, Equals, true)
+	}
+
+	// the instant rate is of the bytes since the previous event
+	listener := newRateListener(recorder, 0)
+	listener.last = time.Now().Add(-time.Second)
+	listener.start = listener.last.Add(-time.Second)
+	listener.lastBytes = 1000
+	listener.ProgressChanged(newProgressEvent(TransferDataEvent, 3000, 10000))
+	event := recorder.events[len(recorder.events)-1]
+	c.Assert(event.InstantRate > 1900 && event.InstantRate <= 2000, Equals, true)
+	c.Assert(event.AverageRate > 1400 && event.AverageRate <= 1500, Equals, true)
+}
+
+// eventRecorder records all the events
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []ProgressEvent
+}
+
+func (r *eventRecorder) ProgressChanged(event *ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+}
+
+func (s *OssBucketMockSuite) TestProgressInterval(c *C) {
+	const size = 4 * 1024 * 1024
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Header().Set(HTTPHeaderContentLength, strconv.Itoa(size))
+			w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(make([]byte, size)))
+			w.Write(make([]byte, size))
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(data))
+	}, c)
+	defer server.Close()
+
+	// the data events of the fast upload are throttled, while the final bytes are still published
+	recorder := &eventRecorder{}
+	err := bucket.PutObject("object", bytes.NewReader(make([]byte, size)), Progress(recorder), ProgressInterval(time.Hour))
+	c.Assert(err, IsNil)
+	c.Assert(len(recorder.events), Equals, 3)
+	c.Assert(recorder.events[0].EventType, Equals, TransferStartedEvent)
+	c.Assert(recorder.events[1].EventType, Equals, TransferDataEvent)
+	c.Assert(recorder.events[1].ConsumedBytes, Equals, int64(size))
+	c.Assert(recorder.events[2].EventType, Equals, TransferCompletedEvent)
+	c.Assert(recorder.events[2].ConsumedBytes, Equals, int64(size))
+	c.Assert(recorder.events[2].TotalBytes, Equals, int64(size))
+
+	// the download without the completed event ends at 100% too
+	recorder = &eventRecorder{}
+	body, err := bucket.GetObject("object", Progress(recorder), ProgressInterval(time.Hour))
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(len(recorder.events), Equals, 1)
+	c.Assert(recorder.events[0].ConsumedBytes, Equals, int64(size))
+
+	// no throttling by default
+	recorder = &eventRecorder{}
+	err = bucket.PutObject("object", bytes.NewReader(make([]byte, size)), Progress(recorder))
+	c.Assert(err, IsNil)
+	c.Assert(len(recorder.events) > 3, Equals, true)
+
+	// the held data event is flushed before the completed or failed event
+	for _, eventType := range []ProgressEventType{TransferCompletedEvent, TransferFailedEvent} {
+		recorder = &eventRecorder{}
+		listener := newRateListener(recorder, time.Hour)
+		listener.ProgressChanged(newProgressEvent(TransferStartedEvent, 0, 300))
+		listener.ProgressChanged(newProgressEvent(TransferDataEvent, 100, 300))
+		listener.ProgressChanged(newProgressEvent(TransferDataEvent, 200, 300))
+		listener.ProgressChanged(newProgressEvent(eventType, 200, 300))
+		c.Assert(len(recorder.events), Equals, 3)
+		c.Assert(recorder.events[1].EventType, Equals, TransferDataEvent)
+		c.Assert(recorder.events[1].ConsumedBytes, Equals, int64(200))
+		c.Assert(recorder.events[2].EventType, Equals, eventType)
+	}
+}
@@ -0,0 +1,345 @@
+package oss
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+)
+
+// The frame types of the select response. The frame is made of
+// version (1 byte) | frame type (3 bytes) | payload length (4 bytes) | header checksum (4 bytes) | payload | payload checksum (4 bytes),
+// all the integers are big endian and the payload checksum is the CRC32 of the payload.
+const (
+	selectFrameData         = 0x800001 // payload: offset (8 bytes) | records
+	selectFrameContinuous   = 0x800004 // payload: offset (8 bytes), it keeps the connection alive during a long scan
+	selectFrameEnd          = 0x800005 // payload: offset (8 bytes) | scanned bytes (8 bytes) | status (4 bytes) | error message
+	selectFrameMetaEndCSV   = 0x800006 // payload: offset | scanned bytes | status | splits (4 bytes) | rows (8 bytes) | columns (4 bytes) | error message
+	selectFrameMetaEndJSON  = 0x800007 // payload: offset | scanned bytes | status | splits (4 bytes) | rows (8 bytes) | error message
+	selectFrameHeaderLength = 12
+)
+
+//
+// SelectObject Selects the records of a CSV or JSON object by SQL, only the matched records are returned.
+//
+// objectKey  the object key.
+// selectReq  the SQL, the object format and the output format. InputSerialization.CSV selects with csv/select,
+//            InputSerialization.JSON selects with json/select.
+// options    the options such as WithContext.
+//
+// io.ReadCloser  reader instance for reading the records. It must be called close() after the usage and only valid when error is nil.
+//                The frames are validated by CRC32 while reading, and the error of the select is returned by Read at the end.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) SelectObject(objectKey string, selectReq SelectRequest, options ...Option) (io.ReadCloser, error) {
+	return bucket.DoSelectObject(objectKey, selectReq, options)
+}
+
+//
+// DoSelectObject The actual API that selects the object, the result gives the statistics after it's drained.
+//
+// objectKey  the object key.
+// selectReq  the select request, checks out SelectObject for the details.
+// options    the options such as WithContext.
+//
+// SelectObjectResult  the reader of the records, it must be called close() after the usage and only valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) DoSelectObject(objectKey string, selectReq SelectRequest, options []Option) (*SelectObjectResult, error) {
+	process, err := selectProcess(selectReq.InputSerialization, "select")
+	if err != nil {
+		return nil, err
+	}
+
+	req := selectReq
+	req.Expression = base64.StdEncoding.EncodeToString([]byte(selectReq.Expression))
+	req.InputSerialization = encodeSelectInput(selectReq.InputSerialization)
+	req.OutputSerialization = encodeSelectOutput(selectReq.OutputSerialization)
+	req.OutputSerialization.EnablePayloadCrc = true
+
+	resp, err := bucket.doSelect(objectKey, process, req, options)
+	if err != nil {
+		return nil, err
+	}
+	return newSelectObjectResult(resp), nil
+}
+
+//
+// CreateSelectCsvObjectMeta Creates or gets the meta of the CSV object, which gives the rows, columns and splits count.
+//
+// objectKey  the object key.
+// metaReq    the CSV format of the object, its InputSerialization.CSV must be set.
+// options    the options such as WithContext.
+//
+// SelectObjectMetaResult  the result object, it's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) CreateSelectCsvObjectMeta(objectKey string, metaReq CsvMetaRequest, options ...Option) (SelectObjectMetaResult, error) {
+	if metaReq.InputSerialization.CSV == nil {
+		return SelectObjectMetaResult{}, errors.New("oss: the CSV input serialization is required")
+	}
+	req := metaReq
+	req.InputSerialization = encodeSelectInput(metaReq.InputSerialization)
+	return bucket.createSelectObjectMeta(objectKey, "csv/meta", req, options)
+}
+
+//
+// CreateSelectJsonObjectMeta Creates or gets the meta of the JSON LINES object, which gives the rows and splits count.
+//
+// objectKey  the object key.
+// metaReq    the JSON format of the object, its InputSerialization.JSON must be set.
+// options    the options such as WithContext.
+//
+// SelectObjectMetaResult  the result object, it's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) CreateSelectJsonObjectMeta(objectKey string, metaReq JsonMetaRequest, options ...Option) (SelectObjectMetaResult, error) {
+	if metaReq.InputSerialization.JSON == nil {
+		return SelectObjectMetaResult{}, errors.New("oss: the JSON input serialization is required")
+	}
+	req := metaReq
+	req.InputSerialization = encodeSelectInput(metaReq.InputSerialization)
+	return bucket.createSelectObjectMeta(objectKey, "json/meta", req, options)
+}
+
+func (bucket Bucket) createSelectObjectMeta(objectKey, process string, metaReq interface{}, options []Option) (SelectObjectMetaResult, error) {
+	resp, err := bucket.doSelect(objectKey, process, metaReq, options)
+	if err != nil {
+		return SelectObjectMetaResult{}, err
+	}
+
+	result := newSelectObjectResult(resp)
+	defer result.Close()
+	if _, err = io.Copy(ioutil.Discard, result); err != nil {
+		return SelectObjectMetaResult{}, err
+	}
+	if result.meta == nil {
+		return SelectObjectMetaResult{}, errors.New("oss: the select meta response has no meta end frame")
+	}
+	return *result.meta, nil
+}
+
+func (bucket Bucket) doSelect(objectKey, process string, body interface{}, options []Option) (*Response, error) {
+	bs, err := xml.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{}
+	params["x-oss-process"] = process
+	return bucket.do("POST", objectKey, params, options, bytes.NewReader(bs), nil)
+}
+
+// selectProcess gets the x-oss-process value such as csv/select by the object format
+func selectProcess(input SelectInputSerialization, action string) (string, error) {
+	if input.CSV != nil && input.JSON != nil {
+		return "", errors.New("oss: only one of the CSV and JSON input serialization could be set")
+	}
+	if input.CSV != nil {
+		return "csv/" + action, nil
+	}
+	if input.JSON != nil {
+		return "json/" + action, nil
+	}
+	return "", errors.New("oss: the CSV or JSON input serialization is required")
+}
+
+func encodeSelectInput(input SelectInputSerialization) SelectInputSerialization {
+	if input.CSV != nil {
+		csv := *input.CSV
+		csv.RecordDelimiter = base64Encode(csv.RecordDelimiter)
+		csv.FieldDelimiter = base64Encode(csv.FieldDelimiter)
+		csv.QuoteCharacter = base64Encode(csv.QuoteCharacter)
+		csv.CommentCharacter = base64Encode(csv.CommentCharacter)
+		input.CSV = &csv
+	}
+	return input
+}
+
+func encodeSelectOutput(output SelectOutputSerialization) SelectOutputSerialization {
+	if output.CSV != nil {
+		csv := *output.CSV
+		csv.RecordDelimiter = base64Encode(csv.RecordDelimiter)
+		csv.FieldDelimiter = base64Encode(csv.FieldDelimiter)
+		output.CSV = &csv
+	}
+	if output.JSON != nil {
+		json := *output.JSON
+		json.RecordDelimiter = base64Encode(json.RecordDelimiter)
+		output.JSON = &json
+	}
+	return output
+}
+
+// base64Encode encodes the value, the empty value is kept empty so that it's omitted
+func base64Encode(value string) string {
+	if value == "" {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+// SelectObjectResult The reader of the select response, it returns the records in the data frames
+type SelectObjectResult struct {
+	Response *Response
+	Stats    SelectObjectStats // the statistics, it's available after Read returns io.EOF
+
+	meta      *SelectObjectMetaResult
+	crc       hash.Hash32
+	remaining int64 // the records left in the current data frame
+	finished  bool
+	err       error
+}
+
+func newSelectObjectResult(resp *Response) *SelectObjectResult {
+	return &SelectObjectResult{Response: resp}
+}
+
+// Read reads the records, it returns the error if the frame is broken or the select fails
+func (r *SelectObjectResult) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	for r.remaining == 0 {
+		if r.finished {
+			return 0, io.EOF
+		}
+		if r.err = r.readFrame(); r.err != nil {
+			return 0, r.err
+		}
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.Response.Body.Read(p)
+	r.crc.Write(p[:n])
+	r.remaining -= int64(n)
+	r.Stats.ReturnedBytes += int64(n)
+
+	if r.remaining == 0 {
+		r.err = r.checkPayloadCRC()
+	} else if err == io.EOF {
+		r.err = io.ErrUnexpectedEOF
+	} else {
+		r.err = err
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+	return 0, r.err
+}
+
+// Close closes the response body
+func (r *SelectObjectResult) Close() error {
+	return r.Response.Body.Close()
+}
+
+// readFrame reads the frame header, the whole payload is read except the records of the data frame
+func (r *SelectObjectResult) readFrame() error {
+	var header [selectFrameHeaderLength]byte
+	if _, err := io.ReadFull(r.Response.Body, header[:]); err != nil {
+		return selectUnexpectedEOF(err)
+	}
+
+	frameType := binary.BigEndian.Uint32(header[0:4]) & 0x00FFFFFF // the first byte is the version
+	payloadLength := int64(binary.BigEndian.Uint32(header[4:8]))
+	if payloadLength < 8 {
+		return fmt.Errorf("oss: invalid select frame payload length %d", payloadLength)
+	}
+	r.crc = crc32.NewIEEE()
+
+	if frameType == selectFrameData {
+		offset := make([]byte, 8)
+		if _, err := io.ReadFull(r.Response.Body, offset); err != nil {
+			return selectUnexpectedEOF(err)
+		}
+		r.crc.Write(offset)
+		r.remaining = payloadLength - 8
+		if r.remaining == 0 {
+			return r.checkPayloadCRC()
+		}
+		return nil
+	}
+
+	payload := make([]byte, payloadLength)
+	if _, err := io.ReadFull(r.Response.Body, payload); err != nil {
+		return selectUnexpectedEOF(err)
+	}
+	r.crc.Write(payload)
+	if err := r.checkPayloadCRC(); err != nil {
+		return err
+	}
+
+	switch frameType {
+	case selectFrameContinuous:
+		return nil
+	case selectFrameEnd:
+		return r.parseEndFrame(payload, 0)
+	case selectFrameMetaEndCSV:
+		return r.parseEndFrame(payload, 16)
+	case selectFrameMetaEndJSON:
+		return r.parseEndFrame(payload, 12)
+	}
+	return fmt.Errorf("oss: unknown select frame type %#x", frameType)
+}
+
+// parseEndFrame parses the end frame, the meta end frame has metaLength more bytes before the error message
+func (r *SelectObjectResult) parseEndFrame(payload []byte, metaLength int) error {
+	if len(payload) < 20+metaLength {
+		return fmt.Errorf("oss: invalid select end frame payload length %d", len(payload))
+	}
+	r.finished = true
+	r.Stats.ScannedBytes = int64(binary.BigEndian.Uint64(payload[8:16]))
+	status := int(binary.BigEndian.Uint32(payload[16:20]))
+	message := string(payload[20+metaLength:])
+
+	if metaLength > 0 {
+		meta := payload[20 : 20+metaLength]
+		r.meta = &SelectObjectMetaResult{
+			ScannedBytes: r.Stats.ScannedBytes,
+			SplitsCount:  int(binary.BigEndian.Uint32(meta[0:4])),
+			RowsCount:    int64(binary.BigEndian.Uint64(meta[4:12])),
+		}
+		if metaLength == 16 {
+			r.meta.ColumnsCount = int(binary.BigEndian.Uint32(meta[12:16]))
+		}
+	}
+
+	if status/100 != 2 {
+		return ServiceError{
+			Message:    message,
+			RequestID:  r.Response.Headers.Get(HTTPHeaderOssRequestID),
+			StatusCode: status,
+		}
+	}
+	return nil
+}
+
+func (r *SelectObjectResult) checkPayloadCRC() error {
+	var checksum [4]byte
+	if _, err := io.ReadFull(r.Response.Body, checksum[:]); err != nil {
+		return selectUnexpectedEOF(err)
+	}
+	if binary.BigEndian.Uint32(checksum[:]) != r.crc.Sum32() {
+		return fmt.Errorf("oss: select frame checksum mismatch, client %d, server %d, RequestId: %s",
+			r.crc.Sum32(), binary.BigEndian.Uint32(checksum[:]), r.Response.Headers.Get(HTTPHeaderOssRequestID))
+	}
+	return nil
+}
+
+// selectUnexpectedEOF the response must end with the end frame, so the EOF before it is unexpected
+func selectUnexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,206 @@
+package oss
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssSelectObjectSuite struct{}
+
+var _ = Suite(&OssSelectObjectSuite{})
+
+// selectFrame builds a select response frame with the payload checksum
+func selectFrame(frameType uint32, payload []byte) []byte {
+	frame := make([]byte, selectFrameHeaderLength, selectFrameHeaderLength+len(payload)+4)
+	binary.BigEndian.PutUint32(frame[0:4], 1<<24|frameType)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(payload)))
+	frame = append(frame, payload...)
+	var checksum [4]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.ChecksumIEEE(payload))
+	return append(frame, checksum[:]...)
+}
+
+func selectDataFrame(offset uint64, data string) []byte {
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, offset)
+	return selectFrame(selectFrameData, append(payload, data...))
+}
+
+func selectEndFrame(frameType uint32, scanned uint64, status uint32, meta []byte, message string) []byte {
+	payload := make([]byte, 20)
+	binary.BigEndian.PutUint64(payload[0:8], scanned)
+	binary.BigEndian.PutUint64(payload[8:16], scanned)
+	binary.BigEndian.PutUint32(payload[16:20], status)
+	payload = append(payload, meta...)
+	return selectFrame(frameType, append(payload, message...))
+}
+
+func (s *OssSelectObjectSuite) TestSelectObject(c *C) {
+	var body []byte
+	keepAlive := make([]byte, 8)
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "POST")
+		c.Assert(r.URL.Query().Get("x-oss-process"), Equals, "csv/select")
+		body, _ = ioutil.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(selectDataFrame(10, "1,Tom\n"))
+		w.Write(selectFrame(selectFrameContinuous, keepAlive))
+		w.Write(selectDataFrame(20, ""))
+		w.Write(selectDataFrame(30, "3,Jerry\n"))
+		w.Write(selectEndFrame(selectFrameEnd, 1024, http.StatusOK, nil, ""))
+	}, c)
+	defer server.Close()
+
+	req := SelectRequest{
+		Expression: "select * from ossobject where _1 > 0",
+		InputSerialization: SelectInputSerialization{
+			CSV: &CSVInputSerialization{FileHeaderInfo: "IGNORE", FieldDelimiter: ","},
+		},
+		OutputSerialization: SelectOutputSerialization{
+			CSV: &CSVOutputSerialization{RecordDelimiter: "\n"},
+		},
+	}
+	result, err := bucket.DoSelectObject("data.csv", req, nil)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(result)
+	c.Assert(err, IsNil)
+	c.Assert(result.Close(), IsNil)
+	c.Assert(string(data), Equals, "1,Tom\n3,Jerry\n")
+	c.Assert(result.Stats.ScannedBytes, Equals, int64(1024))
+	c.Assert(result.Stats.ReturnedBytes, Equals, int64(len(data)))
+
+	// the request is base64 encoded, the caller's request is not modified
+	var sent SelectRequest
+	c.Assert(xml.Unmarshal(body, &sent), IsNil)
+	c.Assert(sent.Expression, Equals, base64.StdEncoding.EncodeToString([]byte(req.Expression)))
+	c.Assert(sent.InputSerialization.CSV.FileHeaderInfo, Equals, "IGNORE")
+	c.Assert(sent.InputSerialization.CSV.FieldDelimiter, Equals, "LA==")
+	c.Assert(sent.InputSerialization.CSV.RecordDelimiter, Equals, "")
+	c.Assert(sent.OutputSerialization.CSV.RecordDelimiter, Equals, "Cg==")
+	c.Assert(sent.OutputSerialization.EnablePayloadCrc, Equals, true)
+	c.Assert(req.InputSerialization.CSV.FieldDelimiter, Equals, ",")
+
+	// SelectObject reads the same records in small pieces
+	reader, err := bucket.SelectObject("data.csv", req)
+	c.Assert(err, IsNil)
+	data = nil
+	buf := make([]byte, 3)
+	for {
+		n, err := reader.Read(buf)
+		data = append(data, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+	}
+	reader.Close()
+	c.Assert(string(data), Equals, "1,Tom\n3,Jerry\n")
+
+	// no input format
+	_, err = bucket.SelectObject("data.csv", SelectRequest{Expression: "select * from ossobject"})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssSelectObjectSuite) TestSelectObjectBrokenFrames(c *C) {
+	var response []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Query().Get("x-oss-process"), Equals, "json/select")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(response)
+	}, c)
+	defer server.Close()
+
+	req := SelectRequest{
+		Expression:         "select * from ossobject s where s.age > 10",
+		InputSerialization: SelectInputSerialization{JSON: &JSONInputSerialization{Type: "LINES"}},
+	}
+
+	// the checksum mismatch
+	frame := selectDataFrame(0, `{"age":11}`)
+	frame[len(frame)-1] ^= 0xFF
+	response = frame
+	reader, err := bucket.SelectObject("data.json", req)
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, "oss: select frame checksum mismatch.*")
+
+	// no end frame
+	response = selectDataFrame(0, `{"age":11}`)
+	reader, err = bucket.SelectObject("data.json", req)
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+
+	// truncated in the records
+	response = selectDataFrame(0, `{"age":11}`)[:20]
+	reader, err = bucket.SelectObject("data.json", req)
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+
+	// the select fails after some records
+	response = append(selectDataFrame(0, `{"age":11}`),
+		selectEndFrame(selectFrameEnd, 100, http.StatusBadRequest, nil, "InvalidJsonData.Line 2")...)
+	reader, err = bucket.SelectObject("data.json", req)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(string(data), Equals, `{"age":11}`)
+	c.Assert(err, NotNil)
+	c.Assert(err.(ServiceError).StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(err.(ServiceError).Message, Equals, "InvalidJsonData.Line 2")
+}
+
+func (s *OssSelectObjectSuite) TestCreateSelectObjectMeta(c *C) {
+	var body []byte
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		meta := make([]byte, 16)
+		binary.BigEndian.PutUint32(meta[0:4], 2)
+		binary.BigEndian.PutUint64(meta[4:12], 100)
+		switch r.URL.Query().Get("x-oss-process") {
+		case "csv/meta":
+			binary.BigEndian.PutUint32(meta[12:16], 5)
+			w.Write(selectFrame(selectFrameContinuous, make([]byte, 8)))
+			w.Write(selectEndFrame(selectFrameMetaEndCSV, 4096, http.StatusOK, meta, ""))
+		case "json/meta":
+			w.Write(selectEndFrame(selectFrameMetaEndJSON, 2048, http.StatusOK, meta[:12], ""))
+		}
+	}, c)
+	defer server.Close()
+
+	res, err := bucket.CreateSelectCsvObjectMeta("data.csv", CsvMetaRequest{
+		InputSerialization: SelectInputSerialization{CSV: &CSVInputSerialization{RecordDelimiter: "\r\n"}},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, SelectObjectMetaResult{ScannedBytes: 4096, SplitsCount: 2, RowsCount: 100, ColumnsCount: 5})
+
+	var sent CsvMetaRequest
+	c.Assert(xml.Unmarshal(body, &sent), IsNil)
+	c.Assert(sent.InputSerialization.CSV.RecordDelimiter, Equals, "DQo=")
+
+	res, err = bucket.CreateSelectJsonObjectMeta("data.json", JsonMetaRequest{
+		InputSerialization: SelectInputSerialization{JSON: &JSONInputSerialization{Type: "LINES"}},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, SelectObjectMetaResult{ScannedBytes: 2048, SplitsCount: 2, RowsCount: 100})
+
+	// the input format is required
+	_, err = bucket.CreateSelectCsvObjectMeta("data.csv", CsvMetaRequest{})
+	c.Assert(err, NotNil)
+	_, err = bucket.CreateSelectJsonObjectMeta("data.json", JsonMetaRequest{})
+	c.Assert(err, NotNil)
+}
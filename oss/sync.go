@@ -0,0 +1,158 @@
+package oss
+
+import (
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Sync Uploads the files under the local directory to the objects under the key prefix.
+//
+// The object key of a file is keyPrefix plus its path relative to localDir with the slash separator, so keyPrefix
+// should end with "/" to sync into a folder. A file is uploaded when the object does not exist or its size or
+// CRC64 differs, otherwise it's skipped. Checking the CRC64 costs one GetObjectDetailedMeta request per file
+// whose object has the same size. The files are uploaded one by one by PutObjectFromFile.
+//
+// localDir   the local directory to sync.
+// keyPrefix  the key prefix of the objects.
+// options    the options for uploading the files, such as ObjectACL and Meta. With DeleteExtraneous(true)
+//            the objects under keyPrefix without the local file are deleted after the upload. MaxPages bounds the
+//            pages of listing the objects.
+//
+// SyncResult  the uploaded, skipped and deleted object keys. It's valid when error is nil, or the keys synced before the error.
+// error  it's nil if no error; otherwise it's the error object.
+//
+func (bucket Bucket) Sync(localDir, keyPrefix string, options ...Option) (SyncResult, error) {
+	var out SyncResult
+	ctxOptions, err := filterOptions(options, isListArg)
+	if err != nil {
+		return out, err
+	}
+
+	remote, err := bucket.listSyncObjects(keyPrefix, ctxOptions)
+	if err != nil {
+		return out, err
+	}
+
+	local := map[string]bool{}
+	err = filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		objectKey := keyPrefix + filepath.ToSlash(rel)
+		local[objectKey] = true
+
+		changed, err := bucket.isSyncFileChanged(path, info, objectKey, remote, ctxOptions)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			out.Skipped = append(out.Skipped, objectKey)
+			return nil
+		}
+
+		if err = bucket.PutObjectFromFile(objectKey, path, options...); err != nil {
+			return err
+		}
+		out.Uploaded = append(out.Uploaded, objectKey)
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	isDelete, err := findOption(options, deleteExtraneous, false)
+	if err != nil {
+		return out, err
+	}
+	if !isDelete.(bool) {
+		return out, nil
+	}
+
+	// the folder objects ending with "/" have no local file, they are kept
+	extraneous := []string{}
+	for key := range remote {
+		if !local[key] && !strings.HasSuffix(key, "/") {
+			extraneous = append(extraneous, key)
+		}
+	}
+	if len(extraneous) == 0 {
+		return out, nil
+	}
+	sort.Strings(extraneous)
+	res, err := bucket.DeleteObjects(extraneous, append(ctxOptions, FailOnPartialError(true))...)
+	out.Deleted = res.DeletedObjects
+	return out, err
+}
+
+// listSyncObjects lists all the objects under the key prefix
+func (bucket Bucket) listSyncObjects(keyPrefix string, options []Option) (map[string]ObjectProperties, error) {
+	objects := map[string]ObjectProperties{}
+	marker := ""
+	max := getMaxPages(options)
+	for pages := 0; ; pages++ {
+		if max > 0 && pages >= max {
+			return nil, MaxPagesError{BucketName: bucket.BucketName, Pages: pages, NextMarker: marker}
+		}
+		lor, err := bucket.ListObjects(append(options, Prefix(keyPrefix), Marker(marker), MaxKeys(1000))...)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range lor.Objects {
+			objects[object.Key] = object
+		}
+		if !lor.IsTruncated {
+			return objects, nil
+		}
+		marker = lor.NextMarker
+	}
+}
+
+// isSyncFileChanged compares the file with the object by the size first and then by the CRC64
+func (bucket Bucket) isSyncFileChanged(filePath string, info os.FileInfo, objectKey string,
+	remote map[string]ObjectProperties, options []Option) (bool, error) {
+	object, ok := remote[objectKey]
+	if !ok || object.Size != info.Size() {
+		return true, nil
+	}
+
+	meta, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return false, err
+	}
+	serverCRC, err := strconv.ParseUint(meta.Get(HTTPHeaderOssCRC64), 10, 64)
+	if err != nil {
+		// no CRC64 to compare, such as the object uploaded before OSS supports it
+		return true, nil
+	}
+
+	localCRC, err := calcFileCRC64(filePath)
+	if err != nil {
+		return false, err
+	}
+	return localCRC != serverCRC, nil
+}
+
+func calcFileCRC64(filePath string) (uint64, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer fd.Close()
+
+	crc := crc64.New(crcTable())
+	if _, err = io.Copy(crc, fd); err != nil {
+		return 0, err
+	}
+	return crc.Sum64(), nil
+}
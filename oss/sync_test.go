@@ -0,0 +1,215 @@
+package oss
+
+import (
+	"encoding/xml"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssSyncSuite struct{}
+
+var _ = Suite(&OssSyncSuite{})
+
+// mockObjectStore serves the objects in memory for the list, put, get, head and delete requests
+type mockObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+	heads   int
+}
+
+func (store *mockObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+	_, isDelete := r.URL.Query()["delete"]
+	switch {
+	case r.Method == "GET" && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		out := ListObjectsResult{}
+		for k, v := range store.objects {
+			if strings.HasPrefix(k, prefix) {
+				out.Objects = append(out.Objects, ObjectProperties{Key: k, Size: int64(len(v))})
+			}
+		}
+		bs, _ := xml.Marshal(out)
+		w.WriteHeader(http.StatusOK)
+		w.Write(bs)
+	case r.Method == "POST" && isDelete:
+		var dxml deleteXML
+		body, _ := ioutil.ReadAll(r.Body)
+		xml.Unmarshal(body, &dxml)
+		out := DeleteObjectsResult{}
+		for _, object := range dxml.Objects {
+			delete(store.objects, object.Key)
+			out.DeletedObjects = append(out.DeletedObjects, object.Key)
+		}
+		bs, _ := xml.Marshal(out)
+		w.WriteHeader(http.StatusOK)
+		w.Write(bs)
+	case r.Method == "PUT":
+		store.puts++
+		body, _ := ioutil.ReadAll(r.Body)
+		store.objects[key] = body
+		w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(body))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "GET":
+		body, ok := store.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(body))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	case r.Method == "HEAD":
+		store.heads++
+		body, ok := store.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(HTTPHeaderOssCRC64, mockCRC64(body))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func mockCRC64(data []byte) string {
+	crc := crc64.New(crcTable())
+	crc.Write(data)
+	return strconv.FormatUint(crc.Sum64(), 10)
+}
+
+func (s *OssSyncSuite) TestSync(c *C) {
+	store := &mockObjectStore{objects: map[string][]byte{
+		"backup/":          nil,
+		"backup/stale.txt": []byte("stale"),
+		"other/keep.txt":   []byte("keep"),
+	}}
+	bucket, server := newMockBucket(store.ServeHTTP, c)
+	defer server.Close()
+
+	localDir, err := ioutil.TempDir("", "oss-sync-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(localDir)
+	c.Assert(os.MkdirAll(filepath.Join(localDir, "sub"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(localDir, "a.txt"), []byte("aaa"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(localDir, "sub", "b.txt"), []byte("bbb"), 0644), IsNil)
+
+	// the first sync uploads all, the extraneous object is kept by default
+	res, err := bucket.Sync(localDir, "backup/")
+	c.Assert(err, IsNil)
+	c.Assert(res.Uploaded, DeepEquals, []string{"backup/a.txt", "backup/sub/b.txt"})
+	c.Assert(res.Skipped, IsNil)
+	c.Assert(res.Deleted, IsNil)
+	c.Assert(string(store.objects["backup/sub/b.txt"]), Equals, "bbb")
+	c.Assert(store.objects["backup/stale.txt"], NotNil)
+
+	// a.txt is unchanged, b.txt is changed in the same size, c.txt is new and b2.txt is removed
+	c.Assert(ioutil.WriteFile(filepath.Join(localDir, "sub", "b.txt"), []byte("BBB"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(localDir, "c.txt"), []byte("c"), 0644), IsNil)
+	store.objects["backup/sub/b2.txt"] = []byte("removed")
+	store.puts, store.heads = 0, 0
+
+	res, err = bucket.Sync(localDir, "backup/", DeleteExtraneous(true))
+	c.Assert(err, IsNil)
+	c.Assert(res.Uploaded, DeepEquals, []string{"backup/c.txt", "backup/sub/b.txt"})
+	c.Assert(res.Skipped, DeepEquals, []string{"backup/a.txt"})
+	c.Assert(res.Deleted, DeepEquals, []string{"backup/stale.txt", "backup/sub/b2.txt"})
+	c.Assert(store.puts, Equals, 2)
+	c.Assert(store.heads, Equals, 2)
+
+	keys := []string{}
+	for key := range store.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	c.Assert(keys, DeepEquals, []string{"backup/", "backup/a.txt", "backup/c.txt", "backup/sub/b.txt", "other/keep.txt"})
+	c.Assert(string(store.objects["backup/sub/b.txt"]), Equals, "BBB")
+
+	// nothing changed
+	res, err = bucket.Sync(localDir, "backup/", DeleteExtraneous(true))
+	c.Assert(err, IsNil)
+	c.Assert(res.Uploaded, IsNil)
+	c.Assert(len(res.Skipped), Equals, 3)
+	c.Assert(res.Deleted, IsNil)
+
+	// the local directory does not exist
+	_, err = bucket.Sync(filepath.Join(localDir, "none"), "backup/")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssSyncSuite) TestDownloadDir(c *C) {
+	store := &mockObjectStore{objects: map[string][]byte{
+		"backup/":          nil,
+		"backup/a.txt":     []byte("aaa"),
+		"backup/c.txt":     []byte("ccc"),
+		"backup/sub/b.txt": []byte("bbb"),
+		"other/keep.txt":   []byte("keep"),
+	}}
+	var failKey string
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && failKey != "" && r.URL.Path == "/mock-bucket/"+failKey {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		store.ServeHTTP(w, r)
+	}, c)
+	defer server.Close()
+
+	localDir, err := ioutil.TempDir("", "oss-download-dir-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(localDir)
+	listFiles := func() []string {
+		files := []string{}
+		filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+			if path != localDir {
+				rel, _ := filepath.Rel(localDir, path)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		return files
+	}
+
+	// c.txt fails, a.txt downloaded before is kept without staging
+	failKey = "backup/c.txt"
+	keys, err := bucket.DownloadDir("backup/", localDir)
+	c.Assert(err, NotNil)
+	c.Assert(keys, DeepEquals, []string{"backup/a.txt"})
+	c.Assert(listFiles(), DeepEquals, []string{"a.txt"})
+	c.Assert(os.RemoveAll(localDir), IsNil)
+
+	// nothing leaks into the directory with staging
+	keys, err = bucket.DownloadDir("backup/", localDir, StageDownloads(true))
+	c.Assert(err, NotNil)
+	c.Assert(len(keys), Equals, 0)
+	c.Assert(listFiles(), DeepEquals, []string{})
+
+	// all downloaded with staging, the staging directory is removed
+	failKey = ""
+	keys, err = bucket.DownloadDir("backup/", localDir, StageDownloads(true))
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"backup/a.txt", "backup/c.txt", "backup/sub/b.txt"})
+	c.Assert(listFiles(), DeepEquals, []string{"a.txt", "c.txt", "sub", "sub/b.txt"})
+	data, err := ioutil.ReadFile(filepath.Join(localDir, "sub", "b.txt"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "bbb")
+
+	// the key out of the directory is rejected
+	_, err = getDownloadDirPath(localDir, "backup/", "backup/../../etc/passwd")
+	c.Assert(err, NotNil)
+	path, err := getDownloadDirPath(localDir, "backup/", "backup/sub/b.txt")
+	c.Assert(err, IsNil)
+	c.Assert(path, Equals, filepath.Join(localDir, "sub", "b.txt"))
+}
@@ -2,7 +2,9 @@ package oss
 
 import (
 	"encoding/xml"
+	"fmt"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -47,6 +49,36 @@ type LifecycleRule struct {
 	Prefix     string              `xml:"Prefix"`     // object key prefix
 	Status     string              `xml:"Status"`     // the rule status (enabled or not)
 	Expiration LifecycleExpiration `xml:"Expiration"` // the expiration property
+
+	Tags                        []Tag                                 `xml:"Tag"`                         // the rule applies to the objects with all the tags besides the prefix
+	Filter                      *LifecycleFilter                      `xml:"Filter"`                      // the filter by the prefix and the tags, Prefix and Tags of the rule are left empty with it
+	Transitions                 []LifecycleTransition                 `xml:"Transition"`                  // transits the objects to the colder storage classes
+	AbortMultipartUpload        *LifecycleAbortMultipartUpload        `xml:"AbortMultipartUpload"`        // aborts the uncompleted multipart uploads
+	NoncurrentVersionExpiration *LifecycleNoncurrentVersionExpiration `xml:"NoncurrentVersionExpiration"` // deletes the noncurrent versions of the versioned bucket
+}
+
+// LifecycleFilter the rule's filter, the rule applies to the objects with the prefix and all the tags.
+// They're combined under the And element when there're more than one of them.
+type LifecycleFilter struct {
+	Prefix string // object key prefix
+	Tags   []Tag  // object tags
+}
+
+// UnmarshalXML decodes the filter with or without the And element
+func (f *LifecycleFilter) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var filter lifecycleFilter
+	if err := d.DecodeElement(&filter, &start); err != nil {
+		return err
+	}
+	f.Prefix = filter.Prefix
+	f.Tags = filter.Tags
+	if filter.And != nil {
+		if filter.And.Prefix != "" {
+			f.Prefix = filter.And.Prefix
+		}
+		f.Tags = append(f.Tags, filter.And.Tags...)
+	}
+	return nil
 }
 
 // LifecycleExpiration the rule's expiration property
@@ -56,17 +88,56 @@ type LifecycleExpiration struct {
 	Date    time.Time `xml:"Date,omitempty"` // Absolute expiration time: The expiration time in date.
 }
 
+// LifecycleTransition the rule's property of transiting the objects to the storage class, either Days or CreatedBeforeDate is set
+type LifecycleTransition struct {
+	XMLName           xml.Name         `xml:"Transition"`
+	Days              int              `xml:"Days,omitempty"`              // the objects are transited in days after the last modified time
+	CreatedBeforeDate time.Time        `xml:"CreatedBeforeDate,omitempty"` // the objects last modified before the date are transited
+	StorageClass      StorageClassType `xml:"StorageClass"`                // the storage class of IA, Archive or ColdArchive
+}
+
+// LifecycleAbortMultipartUpload the rule's property of aborting the uncompleted multipart uploads, either Days or CreatedBeforeDate is set
+type LifecycleAbortMultipartUpload struct {
+	XMLName           xml.Name  `xml:"AbortMultipartUpload"`
+	Days              int       `xml:"Days,omitempty"`              // the uploads are aborted in days after they're initiated
+	CreatedBeforeDate time.Time `xml:"CreatedBeforeDate,omitempty"` // the uploads initiated before the date are aborted
+}
+
+// LifecycleNoncurrentVersionExpiration the rule's expiration property of the noncurrent versions
+type LifecycleNoncurrentVersionExpiration struct {
+	XMLName        xml.Name `xml:"NoncurrentVersionExpiration"`
+	NoncurrentDays int      `xml:"NoncurrentDays"` // the noncurrent versions are deleted in days after they become noncurrent
+}
+
 type lifecycleXML struct {
 	XMLName xml.Name        `xml:"LifecycleConfiguration"`
 	Rules   []lifecycleRule `xml:"Rule"`
 }
 
 type lifecycleRule struct {
-	XMLName    xml.Name            `xml:"Rule"`
-	ID         string              `xml:"ID"`
-	Prefix     string              `xml:"Prefix"`
-	Status     string              `xml:"Status"`
-	Expiration lifecycleExpiration `xml:"Expiration"`
+	XMLName                     xml.Name                              `xml:"Rule"`
+	ID                          string                                `xml:"ID"`
+	Prefix                      *string                               `xml:"Prefix,omitempty"` // nil with the filter
+	Tags                        []Tag                                 `xml:"Tag,omitempty"`
+	Filter                      *lifecycleFilter                      `xml:"Filter,omitempty"`
+	Status                      string                                `xml:"Status"`
+	Expiration                  *lifecycleExpiration                  `xml:"Expiration,omitempty"`
+	Transitions                 []lifecycleTransition                 `xml:"Transition,omitempty"`
+	AbortMultipartUpload        *lifecycleAbortMultipartUpload        `xml:"AbortMultipartUpload,omitempty"`
+	NoncurrentVersionExpiration *LifecycleNoncurrentVersionExpiration `xml:"NoncurrentVersionExpiration,omitempty"`
+}
+
+type lifecycleFilter struct {
+	XMLName xml.Name            `xml:"Filter"`
+	Prefix  string              `xml:"Prefix,omitempty"`
+	Tags    []Tag               `xml:"Tag,omitempty"`
+	And     *lifecycleFilterAnd `xml:"And,omitempty"`
+}
+
+type lifecycleFilterAnd struct {
+	XMLName xml.Name `xml:"And"`
+	Prefix  string   `xml:"Prefix,omitempty"`
+	Tags    []Tag    `xml:"Tag,omitempty"`
 }
 
 type lifecycleExpiration struct {
@@ -75,6 +146,19 @@ type lifecycleExpiration struct {
 	Date    string   `xml:"Date,omitempty"`
 }
 
+type lifecycleTransition struct {
+	XMLName           xml.Name         `xml:"Transition"`
+	Days              int              `xml:"Days,omitempty"`
+	CreatedBeforeDate string           `xml:"CreatedBeforeDate,omitempty"`
+	StorageClass      StorageClassType `xml:"StorageClass"`
+}
+
+type lifecycleAbortMultipartUpload struct {
+	XMLName           xml.Name `xml:"AbortMultipartUpload"`
+	Days              int      `xml:"Days,omitempty"`
+	CreatedBeforeDate string   `xml:"CreatedBeforeDate,omitempty"`
+}
+
 const expirationDateFormat = "2006-01-02T15:04:05.000Z"
 
 func convLifecycleRule(rules []LifecycleRule) []lifecycleRule {
@@ -82,18 +166,228 @@ func convLifecycleRule(rules []LifecycleRule) []lifecycleRule {
 	for _, rule := range rules {
 		r := lifecycleRule{}
 		r.ID = rule.ID
-		r.Prefix = rule.Prefix
-		r.Status = rule.Status
-		if rule.Expiration.Date.IsZero() {
-			r.Expiration.Days = rule.Expiration.Days
+		// the filter replaces the prefix and the tags of the rule
+		if rule.Filter != nil {
+			r.Filter = convLifecycleFilter(rule.Filter)
 		} else {
-			r.Expiration.Date = rule.Expiration.Date.Format(expirationDateFormat)
+			prefix := rule.Prefix
+			r.Prefix = &prefix
+			r.Tags = rule.Tags
+		}
+		r.Status = rule.Status
+
+		// the empty expiration is omitted only for the rules with the other actions
+		noExpiration := rule.Expiration.Days == 0 && rule.Expiration.Date.IsZero()
+		if !noExpiration || (len(rule.Transitions) == 0 && rule.AbortMultipartUpload == nil &&
+			rule.NoncurrentVersionExpiration == nil) {
+			r.Expiration = &lifecycleExpiration{}
+			if rule.Expiration.Date.IsZero() {
+				r.Expiration.Days = rule.Expiration.Days
+			} else {
+				r.Expiration.Date = rule.Expiration.Date.Format(expirationDateFormat)
+			}
+		}
+
+		for _, transition := range rule.Transitions {
+			t := lifecycleTransition{StorageClass: transition.StorageClass}
+			if transition.CreatedBeforeDate.IsZero() {
+				t.Days = transition.Days
+			} else {
+				t.CreatedBeforeDate = transition.CreatedBeforeDate.Format(expirationDateFormat)
+			}
+			r.Transitions = append(r.Transitions, t)
+		}
+
+		if rule.AbortMultipartUpload != nil {
+			r.AbortMultipartUpload = &lifecycleAbortMultipartUpload{}
+			if rule.AbortMultipartUpload.CreatedBeforeDate.IsZero() {
+				r.AbortMultipartUpload.Days = rule.AbortMultipartUpload.Days
+			} else {
+				r.AbortMultipartUpload.CreatedBeforeDate = rule.AbortMultipartUpload.CreatedBeforeDate.Format(expirationDateFormat)
+			}
 		}
+		r.NoncurrentVersionExpiration = rule.NoncurrentVersionExpiration
 		rs = append(rs, r)
 	}
 	return rs
 }
 
+// the storage classes of the transitions from the warmest to the coldest
+var lifecycleTransitionClasses = []StorageClassType{StorageIA, StorageArchive, StorageColdArchive}
+
+// validateLifecycleRules checks the rules for the conflicts OSS rejects, so that the error tells the offending rule:
+// the duplicate rule ids, the same action of the rules without the tags on the same prefix, the prefix or the tags of
+// the rule set with the filter, both the days and the date set for an action, and the transitions not getting colder
+// along the time, such as Archive before IA. The rule is told by its id, or by its index such as #0 if the id is
+// empty. The empty ids are assigned by OSS, so they do not collide.
+func validateLifecycleRules(rules []LifecycleRule) error {
+	ids := map[string]bool{}
+	for i, rule := range rules {
+		name := fmt.Sprintf("#%d", i)
+		if rule.ID != "" {
+			name = rule.ID
+			if ids[rule.ID] {
+				return fmt.Errorf("oss: invalid lifecycle rule %s, the rule id is duplicate", name)
+			}
+			ids[rule.ID] = true
+		}
+
+		if rule.Filter != nil && len(rule.Tags) > 0 {
+			return fmt.Errorf("oss: invalid lifecycle rule %s, both Tags and Filter are set", name)
+		}
+		if rule.Filter != nil && rule.Prefix != "" {
+			return fmt.Errorf("oss: invalid lifecycle rule %s, both Prefix and Filter are set", name)
+		}
+		if rule.Expiration.Days != 0 && !rule.Expiration.Date.IsZero() {
+			return fmt.Errorf("oss: invalid lifecycle rule %s, both Days and Date are set for Expiration", name)
+		}
+		if abort := rule.AbortMultipartUpload; abort != nil && abort.Days != 0 && !abort.CreatedBeforeDate.IsZero() {
+			return fmt.Errorf("oss: invalid lifecycle rule %s, both Days and CreatedBeforeDate are set for AbortMultipartUpload", name)
+		}
+		if err := validateLifecycleTransitions(rule); err != nil {
+			return fmt.Errorf("oss: invalid lifecycle rule %s, %s", name, err.Error())
+		}
+	}
+	return validateLifecyclePrefixes(rules)
+}
+
+// validateLifecyclePrefixes checks that the rules without the tags on the same prefix do not set the same action.
+// The rules on the overlapping prefixes, such as AbortMultipartUpload on "" and Expiration on "logs/", are accepted by
+// OSS, so are the rules with the tags, they apply to the different objects.
+func validateLifecyclePrefixes(rules []LifecycleRule) error {
+	ruleName := func(i int) string {
+		if rules[i].ID != "" {
+			return rules[i].ID
+		}
+		return fmt.Sprintf("#%d", i)
+	}
+	for i := range rules {
+		prefix, tagged := getLifecycleRuleFilter(rules[i])
+		if tagged {
+			continue
+		}
+		actions := getLifecycleRuleActions(rules[i])
+		for j := 0; j < i; j++ {
+			other, tagged := getLifecycleRuleFilter(rules[j])
+			if tagged || other != prefix {
+				continue
+			}
+			for _, action := range getLifecycleRuleActions(rules[j]) {
+				for _, a := range actions {
+					if a == action {
+						return fmt.Errorf("oss: invalid lifecycle rule %s, %s on the prefix %q is set by the rule %s too",
+							ruleName(i), action, prefix, ruleName(j))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getLifecycleRuleFilter gets the prefix of the rule, and whether the rule filters the objects by the tags
+func getLifecycleRuleFilter(rule LifecycleRule) (string, bool) {
+	if rule.Filter != nil {
+		return rule.Filter.Prefix, len(rule.Filter.Tags) > 0
+	}
+	return rule.Prefix, len(rule.Tags) > 0
+}
+
+// getLifecycleRuleActions gets the names of the actions set by the rule
+func getLifecycleRuleActions(rule LifecycleRule) []string {
+	actions := []string{}
+	if rule.Expiration.Days != 0 || !rule.Expiration.Date.IsZero() {
+		actions = append(actions, "Expiration")
+	}
+	if len(rule.Transitions) > 0 {
+		actions = append(actions, "Transition")
+	}
+	if rule.AbortMultipartUpload != nil {
+		actions = append(actions, "AbortMultipartUpload")
+	}
+	if rule.NoncurrentVersionExpiration != nil {
+		actions = append(actions, "NoncurrentVersionExpiration")
+	}
+	return actions
+}
+
+// validateLifecycleTransitions checks that the colder storage class is transited later, and the objects expire after
+// the last transition
+func validateLifecycleTransitions(rule LifecycleRule) error {
+	transited := map[StorageClassType]LifecycleTransition{}
+	for _, transition := range rule.Transitions {
+		if transition.Days != 0 && !transition.CreatedBeforeDate.IsZero() {
+			return fmt.Errorf("both Days and CreatedBeforeDate are set for the transition to %s", transition.StorageClass)
+		}
+		if _, ok := transited[transition.StorageClass]; ok {
+			return fmt.Errorf("the transition to %s is duplicate", transition.StorageClass)
+		}
+		transited[transition.StorageClass] = transition
+	}
+	for class := range transited {
+		if !isLifecycleTransitionClass(class) {
+			return fmt.Errorf("the transition to %s is not supported", class)
+		}
+	}
+
+	previous := LifecycleTransition{}
+	for _, class := range lifecycleTransitionClasses {
+		transition, ok := transited[class]
+		if !ok {
+			continue
+		}
+		if previous.StorageClass != "" {
+			if (transition.Days == 0) != (previous.Days == 0) {
+				return fmt.Errorf("the transitions to %s and %s mix Days and CreatedBeforeDate", previous.StorageClass, class)
+			}
+			if !isLaterTransition(transition, previous) {
+				return fmt.Errorf("the transition to %s is not after the transition to %s", class, previous.StorageClass)
+			}
+		}
+		previous = transition
+	}
+
+	// the expiration in the other kind is not compared
+	expiration := LifecycleTransition{Days: rule.Expiration.Days, CreatedBeforeDate: rule.Expiration.Date}
+	if previous.StorageClass != "" && (expiration.Days == 0) == (previous.Days == 0) &&
+		(expiration.Days != 0 || !expiration.CreatedBeforeDate.IsZero()) && !isLaterTransition(expiration, previous) {
+		return fmt.Errorf("the expiration is not after the transition to %s", previous.StorageClass)
+	}
+	return nil
+}
+
+// isLaterTransition tells whether the objects are transited later by t than by previous, which is in the same kind.
+// By days it's later with the more days after the last modified time, by date it's later with the older date, which
+// the objects last modified before are older.
+func isLaterTransition(t, previous LifecycleTransition) bool {
+	if t.Days != 0 {
+		return t.Days > previous.Days
+	}
+	return t.CreatedBeforeDate.Before(previous.CreatedBeforeDate)
+}
+
+// isLifecycleTransitionClass tells whether the objects could be transited to the storage class
+func isLifecycleTransitionClass(class StorageClassType) bool {
+	for _, c := range lifecycleTransitionClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// convLifecycleFilter combines the prefix and the tags under And if there're more than one of them
+func convLifecycleFilter(filter *LifecycleFilter) *lifecycleFilter {
+	conditions := len(filter.Tags)
+	if filter.Prefix != "" {
+		conditions++
+	}
+	if conditions > 1 {
+		return &lifecycleFilter{And: &lifecycleFilterAnd{Prefix: filter.Prefix, Tags: filter.Tags}}
+	}
+	return &lifecycleFilter{Prefix: filter.Prefix, Tags: filter.Tags}
+}
+
 // BuildLifecycleRuleByDays Builds a lifecycle rule with specified expiration days
 func BuildLifecycleRuleByDays(id, prefix string, status bool, days int) LifecycleRule {
 	var statusStr = "Enabled"
@@ -121,8 +415,10 @@ type GetBucketLifecycleResult LifecycleConfiguration
 // RefererXML Referer config
 type RefererXML struct {
 	XMLName           xml.Name `xml:"RefererConfiguration"`
-	AllowEmptyReferer bool     `xml:"AllowEmptyReferer"`   // Allow empty referrer
-	RefererList       []string `xml:"RefererList>Referer"` // referer whitelist
+	AllowEmptyReferer bool     `xml:"AllowEmptyReferer"`        // Allow empty referrer
+	TruncatePath      bool     `xml:"TruncatePath,omitempty"`   // match the referrer without the path, such as http://www.aliyun.com/ for http://www.aliyun.com/index.html
+	AllowHTTPSOnly    bool     `xml:"AllowHttpsOnly,omitempty"` // only the https referrers could pass the whitelist
+	RefererList       []string `xml:"RefererList>Referer"`      // referer whitelist
 }
 
 // GetBucketRefererResult result object for GetBucketReferer request
@@ -150,9 +446,11 @@ type GetBucketLoggingResult LoggingXML
 
 // WebsiteXML Website configuration
 type WebsiteXML struct {
-	XMLName       xml.Name      `xml:"WebsiteConfiguration"`
-	IndexDocument IndexDocument `xml:"IndexDocument"` // the index page
-	ErrorDocument ErrorDocument `xml:"ErrorDocument"` // the error page
+	XMLName               xml.Name               `xml:"WebsiteConfiguration"`
+	IndexDocument         IndexDocument          `xml:"IndexDocument"`            // the index page
+	ErrorDocument         ErrorDocument          `xml:"ErrorDocument"`            // the error page
+	RoutingRules          []RoutingRule          `xml:"RoutingRules>RoutingRule"` // the rules redirecting the requests
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo"`    // redirects all the requests to the host
 }
 
 // IndexDocument The index page info
@@ -167,6 +465,52 @@ type ErrorDocument struct {
 	Key     string   `xml:"Key"` // 404 error file name
 }
 
+// RoutingRule the website rule redirecting the requests matching the condition
+type RoutingRule struct {
+	XMLName    xml.Name         `xml:"RoutingRule"`
+	RuleNumber int              `xml:"RuleNumber"` // the rules are matched by the number in order, SetBucketWebsiteDetail numbers them from 1 if it's 0
+	Condition  RoutingCondition `xml:"Condition"`  // the condition of the requests to redirect
+	Redirect   RoutingRedirect  `xml:"Redirect"`   // how the requests are redirected
+}
+
+// RoutingCondition the condition of the routing rule, the request matches it when all the conditions set are met
+type RoutingCondition struct {
+	XMLName                     xml.Name `xml:"Condition"`
+	KeyPrefixEquals             string   `xml:"KeyPrefixEquals,omitempty"`             // the object key prefix
+	HTTPErrorCodeReturnedEquals int      `xml:"HttpErrorCodeReturnedEquals,omitempty"` // the HTTP status code returned, such as 404
+}
+
+// RoutingRedirect the redirect of the routing rule
+type RoutingRedirect struct {
+	XMLName              xml.Name `xml:"Redirect"`
+	RedirectType         string   `xml:"RedirectType,omitempty"`         // Internal, External or Mirror. OSS takes External by default
+	Protocol             string   `xml:"Protocol,omitempty"`             // http or https
+	HostName             string   `xml:"HostName,omitempty"`             // the host to redirect to
+	ReplaceKeyWith       string   `xml:"ReplaceKeyWith,omitempty"`       // the object key to redirect to
+	ReplaceKeyPrefixWith string   `xml:"ReplaceKeyPrefixWith,omitempty"` // the prefix replacing KeyPrefixEquals of the condition
+	HTTPRedirectCode     int      `xml:"HttpRedirectCode,omitempty"`     // the HTTP status code of the redirect, such as 301 or 302
+}
+
+// RedirectAllRequestsTo the host all the requests are redirected to
+type RedirectAllRequestsTo struct {
+	XMLName  xml.Name `xml:"RedirectAllRequestsTo"`
+	HostName string   `xml:"HostName"`           // the host to redirect to
+	Protocol string   `xml:"Protocol,omitempty"` // http or https
+}
+
+// websiteXML the website configuration to set, the empty parts are omitted
+type websiteXML struct {
+	XMLName               xml.Name               `xml:"WebsiteConfiguration"`
+	IndexDocument         *IndexDocument         `xml:"IndexDocument,omitempty"`
+	ErrorDocument         *ErrorDocument         `xml:"ErrorDocument,omitempty"`
+	RoutingRules          *websiteRoutingRules   `xml:"RoutingRules,omitempty"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty"`
+}
+
+type websiteRoutingRules struct {
+	Rules []RoutingRule `xml:"RoutingRule"`
+}
+
 // GetBucketWebsiteResult The result from GetBucketWebsite request.
 type GetBucketWebsiteResult WebsiteXML
 
@@ -189,6 +533,97 @@ type CORSRule struct {
 // GetBucketCORSResult The result from GetBucketCORS request
 type GetBucketCORSResult CORSXML
 
+// Match returns the first rule allowing the CORS request, as OSS decides the preflight request.
+// The origin and the headers could be matched by the rules with one wildcard '*', the headers are case-insensitive.
+func (result GetBucketCORSResult) Match(origin, method string, reqHeaders []string) (*CORSRule, bool) {
+	for i := range result.CORSRules {
+		rule := &result.CORSRules[i]
+		if matchCORS(rule.AllowedOrigin, origin, false) && matchCORS(rule.AllowedMethod, method, false) {
+			allowed := true
+			for _, header := range reqHeaders {
+				if !matchCORS(rule.AllowedHeader, header, true) {
+					allowed = false
+					break
+				}
+			}
+			if allowed {
+				return rule, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// matchCORS checks if any pattern matches the value, the pattern could have one wildcard '*'
+func matchCORS(patterns []string, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		value = strings.ToLower(value)
+	}
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+		i := strings.Index(pattern, "*")
+		if i < 0 {
+			if pattern == value {
+				return true
+			}
+			continue
+		}
+		prefix, suffix := pattern[:i], pattern[i+1:]
+		if len(value) >= len(prefix)+len(suffix) && strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// VersioningConfig Bucket versioning configuration
+type VersioningConfig struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Status  string   `xml:"Status,omitempty"` // versioning status, Enabled or Suspended. It's empty if versioning was never enabled
+}
+
+// GetBucketVersioningResult The result from GetBucketVersioning request.
+type GetBucketVersioningResult VersioningConfig
+
+// ServerEncryptionRule The default server side encryption of the bucket's new objects
+type ServerEncryptionRule struct {
+	SSEAlgorithm   string // the encryption algorithm, SSEAES256 or SSEKMS
+	KMSMasterKeyID string // the KMS master key id, it's only valid for SSEKMS. The default KMS key is used when it's empty
+}
+
+// serverEncryptionXML the XML of the bucket encryption rule
+type serverEncryptionXML struct {
+	XMLName    xml.Name                `xml:"ServerSideEncryptionRule"`
+	SSEDefault serverEncryptionDefault `xml:"ApplyServerSideEncryptionByDefault"`
+}
+
+type serverEncryptionDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty"`
+}
+
+// GetBucketEncryptionResult The result from GetBucketEncryption request.
+type GetBucketEncryptionResult ServerEncryptionRule
+
+// RequestPaymentConfiguration the payer of the bucket's requests
+type RequestPaymentConfiguration struct {
+	XMLName xml.Name `xml:"RequestPaymentConfiguration"`
+	Payer   string   `xml:"Payer"` // the payer, Requester or BucketOwner
+}
+
+// GetBucketRequestPaymentResult The result from GetBucketRequestPayment request.
+type GetBucketRequestPaymentResult RequestPaymentConfiguration
+
+// GetBucketStatResult The result from GetBucketStat request.
+type GetBucketStatResult struct {
+	XMLName              xml.Name `xml:"BucketStat"`
+	Storage              int64    `xml:"Storage"`              // the storage size of the bucket in byte
+	ObjectCount          int64    `xml:"ObjectCount"`          // the count of the objects
+	MultipartUploadCount int64    `xml:"MultipartUploadCount"` // the count of the uncompleted multipart uploads
+}
+
 // GetBucketInfoResult The result from GetBucketInfo request.
 type GetBucketInfoResult struct {
 	XMLName    xml.Name   `xml:"BucketInfo"`
@@ -231,6 +666,7 @@ type ObjectProperties struct {
 	Owner        Owner     `xml:"Owner"`        // Object owner information
 	LastModified time.Time `xml:"LastModified"` // Object last modified time
 	StorageClass string    `xml:"StorageClass"` // Object storage class (Standard, IA, Archive)
+	Tags         []Tag     `xml:"-"`            // Object tags, it's only filled by ListObjects with WithTags(true)
 }
 
 // Owner Bucket/Object's owner
@@ -245,11 +681,63 @@ type CopyObjectResult struct {
 	XMLName      xml.Name  `xml:"CopyObjectResult"`
 	LastModified time.Time `xml:"LastModified"` // new Object's last modified time.
 	ETag         string    `xml:"ETag"`         // new Object's ETag
+	VersionID    string    `xml:"-"`            // new Object's version id, it's empty if the versioning is not enabled
+	CRC64        uint64    `xml:"-"`            // new Object's CRC64 ECMA returned by OSS, 0 if it's not returned
 }
 
 // GetObjectACLResult result of GetObjectACL request
 type GetObjectACLResult GetBucketACLResult
 
+// RestoreConfiguration the configuration of restoring the archive objects
+type RestoreConfiguration struct {
+	XMLName       xml.Name              `xml:"RestoreRequest"`
+	Days          int                   `xml:"Days,omitempty"`          // the days the restored object is available, the server default is used when it's 0
+	JobParameters *RestoreJobParameters `xml:"JobParameters,omitempty"` // the restore job, it's for the ColdArchive object only
+}
+
+// RestoreJobParameters the restore job of the ColdArchive object
+type RestoreJobParameters struct {
+	Tier RestoreTierType `xml:"Tier"` // the restore priority, by default it's RestoreStandard
+}
+
+// RestoreResult the result of restoring one object in RestoreObjects
+type RestoreResult struct {
+	Key        string // Object name
+	InProgress bool   // true if the object was already being restored
+	Error      error  // nil if the restore is accepted or already in progress
+}
+
+// Tag a tag of the object
+type Tag struct {
+	XMLName xml.Name `xml:"Tag"`
+	Key     string   `xml:"Key"`   // tag key
+	Value   string   `xml:"Value"` // tag value
+}
+
+// Tagging the tag set of the object
+type Tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Tags    []Tag    `xml:"TagSet>Tag,omitempty"` // the tags, it's empty if the object has no tag
+}
+
+// GetObjectTaggingResult result of GetObjectTagging request
+type GetObjectTaggingResult Tagging
+
+// LegalHold the legal hold of the object
+type LegalHold struct {
+	XMLName xml.Name        `xml:"LegalHold"`
+	Status  LegalHoldStatus `xml:"Status"` // LegalHoldOn or LegalHoldOff
+}
+
+// GetObjectLegalHoldResult result of GetObjectLegalHold request
+type GetObjectLegalHoldResult LegalHold
+
+// IsDeleteBlocked returns true if the object is under the legal hold, deleting or overwriting it fails until the
+// legal hold is off, regardless of the retention
+func (result GetObjectLegalHoldResult) IsDeleteBlocked() bool {
+	return strings.EqualFold(string(result.Status), string(LegalHoldOn))
+}
+
 type deleteXML struct {
 	XMLName xml.Name       `xml:"Delete"`
 	Objects []DeleteObject `xml:"Object"` // objects to delete
@@ -264,8 +752,24 @@ type DeleteObject struct {
 
 // DeleteObjectsResult result of DeleteObjects request
 type DeleteObjectsResult struct {
-	XMLName        xml.Name `xml:"DeleteResult"`
-	DeletedObjects []string `xml:"Deleted>Key"` // deleted object list
+	XMLName        xml.Name            `xml:"DeleteResult"`
+	DeletedObjects []string            `xml:"Deleted>Key"` // deleted object list
+	FailedObjects  []DeleteObjectError `xml:"Error"`       // the objects failed to delete
+	RequestID      string              `xml:"-"`           // the request id, it's of the last batch when there're more batches
+	RequestIDs     []string            `xml:"-"`           // the request ids of all the batches in order, to audit the deletion
+}
+
+// DeleteObjectError the object failed to delete in DeleteObjects
+type DeleteObjectError struct {
+	XMLName xml.Name `xml:"Error"`
+	Key     string   `xml:"Key"`     // Object name
+	Code    string   `xml:"Code"`    // the error code
+	Message string   `xml:"Message"` // the error message
+}
+
+// HasErrors returns true if any object failed to delete
+func (result DeleteObjectsResult) HasErrors() bool {
+	return len(result.FailedObjects) > 0
 }
 
 // InitiateMultipartUploadResult result of InitiateMultipartUpload request
@@ -274,6 +778,10 @@ type InitiateMultipartUploadResult struct {
 	Bucket   string   `xml:"Bucket"`   // Bucket name
 	Key      string   `xml:"Key"`      // Object name to upload
 	UploadID string   `xml:"UploadId"` // generated UploadId
+
+	// the server side encryption negotiated for the upload, they're from the response headers
+	ServerSideEncryption      string `xml:"-"` // the server side encryption applied by OSS, such as AES256 or KMS
+	ServerSideEncryptionKeyID string `xml:"-"` // the KMS master key id when the encryption is KMS
 }
 
 // UploadPart the upload/copy part
@@ -372,6 +880,12 @@ func decodeDeleteObjectsResult(result *DeleteObjectsResult) error {
 			return err
 		}
 	}
+	for i := 0; i < len(result.FailedObjects); i++ {
+		result.FailedObjects[i].Key, err = url.QueryUnescape(result.FailedObjects[i].Key)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -448,3 +962,76 @@ type createBucketConfiguration struct {
 	XMLName      xml.Name         `xml:"CreateBucketConfiguration"`
 	StorageClass StorageClassType `xml:"StorageClass,omitempty"`
 }
+
+// SelectRequest The request of SelectObject, the SQL and the delimiters are in plain text, SelectObject encodes them in base64
+type SelectRequest struct {
+	XMLName             xml.Name                  `xml:"SelectRequest"`
+	Expression          string                    `xml:"Expression"`          // the SQL, such as "select * from ossobject where _1 > 10"
+	InputSerialization  SelectInputSerialization  `xml:"InputSerialization"`  // the format of the object
+	OutputSerialization SelectOutputSerialization `xml:"OutputSerialization"` // the format of the returned records
+	Options             *SelectOptions            `xml:"Options,omitempty"`   // the options to handle the malformed records
+}
+
+// SelectInputSerialization The format of the object to select from, one of CSV and JSON must be set
+type SelectInputSerialization struct {
+	CompressionType string                  `xml:"CompressionType,omitempty"` // None or GZIP, by default it's None
+	CSV             *CSVInputSerialization  `xml:"CSV,omitempty"`
+	JSON            *JSONInputSerialization `xml:"JSON,omitempty"`
+}
+
+// CSVInputSerialization The CSV object format
+type CSVInputSerialization struct {
+	FileHeaderInfo   string `xml:"FileHeaderInfo,omitempty"`   // NONE, IGNORE or USE, with USE the columns could be referenced by the header names
+	RecordDelimiter  string `xml:"RecordDelimiter,omitempty"`  // by default it's "\n"
+	FieldDelimiter   string `xml:"FieldDelimiter,omitempty"`   // by default it's ","
+	QuoteCharacter   string `xml:"QuoteCharacter,omitempty"`   // by default it's "\""
+	CommentCharacter string `xml:"CommentCharacter,omitempty"` // the lines starting with it are skipped
+	Range            string `xml:"Range,omitempty"`            // the line range such as "line-range=0-99", or split range such as "split-range=0-9"
+}
+
+// JSONInputSerialization The JSON object format
+type JSONInputSerialization struct {
+	Type                    string `xml:"Type"`                              // DOCUMENT or LINES
+	Range                   string `xml:"Range,omitempty"`                   // the line or split range, it's for LINES only
+	ParseJSONNumberAsString bool   `xml:"ParseJsonNumberAsString,omitempty"` // parses the numbers as strings to keep the precision
+}
+
+// SelectOutputSerialization The format of the returned records
+type SelectOutputSerialization struct {
+	CSV              *CSVOutputSerialization  `xml:"CSV,omitempty"`
+	JSON             *JSONOutputSerialization `xml:"JSON,omitempty"`
+	KeepAllColumns   bool                     `xml:"KeepAllColumns,omitempty"` // keeps the unselected columns as empty in the records
+	OutputHeader     bool                     `xml:"OutputHeader,omitempty"`   // returns the CSV header line first
+	EnablePayloadCrc bool                     `xml:"EnablePayloadCrc"`         // it's always enabled by SelectObject to validate the frames
+}
+
+// CSVOutputSerialization The CSV format of the returned records
+type CSVOutputSerialization struct {
+	RecordDelimiter string `xml:"RecordDelimiter,omitempty"` // by default it's "\n"
+	FieldDelimiter  string `xml:"FieldDelimiter,omitempty"`  // by default it's ","
+}
+
+// JSONOutputSerialization The JSON format of the returned records
+type JSONOutputSerialization struct {
+	RecordDelimiter string `xml:"RecordDelimiter,omitempty"` // by default it's "\n"
+}
+
+// SelectOptions The options of SelectObject
+type SelectOptions struct {
+	SkipPartialDataRecord    bool `xml:"SkipPartialDataRecord,omitempty"`    // skips the records with fewer columns than selected
+	MaxSkippedRecordsAllowed int  `xml:"MaxSkippedRecordsAllowed,omitempty"` // the select fails when more records are skipped
+}
+
+// CsvMetaRequest The request of CreateSelectCsvObjectMeta
+type CsvMetaRequest struct {
+	XMLName            xml.Name                 `xml:"CsvMetaRequest"`
+	InputSerialization SelectInputSerialization `xml:"InputSerialization"` // its CSV must be set
+	OverwriteIfExists  bool                     `xml:"OverwriteIfExists"`  // rebuilds the meta even if it exists
+}
+
+// JsonMetaRequest The request of CreateSelectJsonObjectMeta
+type JsonMetaRequest struct {
+	XMLName            xml.Name                 `xml:"JsonMetaRequest"`
+	InputSerialization SelectInputSerialization `xml:"InputSerialization"` // its JSON must be set with Type LINES
+	OverwriteIfExists  bool                     `xml:"OverwriteIfExists"`  // rebuilds the meta even if it exists
+}
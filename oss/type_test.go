@@ -1,8 +1,11 @@
 package oss
 
 import (
+	"encoding/xml"
 	"net/url"
 	"sort"
+	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -24,19 +27,232 @@ func (s *OssTypeSuite) TestConvLifecycleRule(c *C) {
 
 	rs := convLifecycleRule([]LifecycleRule{r1})
 	c.Assert(rs[0].ID, Equals, "id1")
-	c.Assert(rs[0].Prefix, Equals, "one")
+	c.Assert(*rs[0].Prefix, Equals, "one")
 	c.Assert(rs[0].Status, Equals, "Enabled")
 	c.Assert(rs[0].Expiration.Date, Equals, "2015-11-11T00:00:00.000Z")
 	c.Assert(rs[0].Expiration.Days, Equals, 0)
 
 	rs = convLifecycleRule([]LifecycleRule{r2})
 	c.Assert(rs[0].ID, Equals, "id2")
-	c.Assert(rs[0].Prefix, Equals, "two")
+	c.Assert(*rs[0].Prefix, Equals, "two")
 	c.Assert(rs[0].Status, Equals, "Disabled")
 	c.Assert(rs[0].Expiration.Date, Equals, "")
 	c.Assert(rs[0].Expiration.Days, Equals, 3)
 }
 
+func (s *OssTypeSuite) TestConvLifecycleRuleActions(c *C) {
+	marshal := func(rule LifecycleRule) string {
+		bs, err := xml.Marshal(lifecycleXML{Rules: convLifecycleRule([]LifecycleRule{rule})})
+		c.Assert(err, IsNil)
+		return string(bs)
+	}
+
+	// the rules with the expiration only are not changed
+	c.Assert(marshal(BuildLifecycleRuleByDays("id", "logs/", true, 30)), Equals,
+		"<LifecycleConfiguration><Rule><ID>id</ID><Prefix>logs/</Prefix><Status>Enabled</Status>"+
+			"<Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>")
+
+	// AbortMultipartUpload by days without the expiration
+	rule := LifecycleRule{ID: "abort", Prefix: "tmp/", Status: "Enabled",
+		AbortMultipartUpload: &LifecycleAbortMultipartUpload{Days: 7}}
+	c.Assert(marshal(rule), Equals,
+		"<LifecycleConfiguration><Rule><ID>abort</ID><Prefix>tmp/</Prefix><Status>Enabled</Status>"+
+			"<AbortMultipartUpload><Days>7</Days></AbortMultipartUpload></Rule></LifecycleConfiguration>")
+
+	// AbortMultipartUpload by date
+	rule.AbortMultipartUpload = &LifecycleAbortMultipartUpload{CreatedBeforeDate: time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)}
+	c.Assert(marshal(rule), Equals,
+		"<LifecycleConfiguration><Rule><ID>abort</ID><Prefix>tmp/</Prefix><Status>Enabled</Status>"+
+			"<AbortMultipartUpload><CreatedBeforeDate>2017-05-01T00:00:00.000Z</CreatedBeforeDate></AbortMultipartUpload>"+
+			"</Rule></LifecycleConfiguration>")
+
+	// the tags with the expiration
+	rule = BuildLifecycleRuleByDays("tag", "", true, 10)
+	rule.Tags = []Tag{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}}
+	c.Assert(marshal(rule), Equals,
+		"<LifecycleConfiguration><Rule><ID>tag</ID><Prefix></Prefix>"+
+			"<Tag><Key>k1</Key><Value>v1</Value></Tag><Tag><Key>k2</Key><Value>v2</Value></Tag>"+
+			"<Status>Enabled</Status><Expiration><Days>10</Days></Expiration></Rule></LifecycleConfiguration>")
+
+	// NoncurrentVersionExpiration
+	rule = LifecycleRule{ID: "version", Prefix: "data/", Status: "Enabled",
+		NoncurrentVersionExpiration: &LifecycleNoncurrentVersionExpiration{NoncurrentDays: 60}}
+	c.Assert(marshal(rule), Equals,
+		"<LifecycleConfiguration><Rule><ID>version</ID><Prefix>data/</Prefix><Status>Enabled</Status>"+
+			"<NoncurrentVersionExpiration><NoncurrentDays>60</NoncurrentDays></NoncurrentVersionExpiration>"+
+			"</Rule></LifecycleConfiguration>")
+
+	// GetBucketLifecycle parses all of them
+	var res GetBucketLifecycleResult
+	err := xml.Unmarshal([]byte("<LifecycleConfiguration><Rule><ID>all</ID><Prefix>p/</Prefix>"+
+		"<Tag><Key>k</Key><Value>v</Value></Tag><Status>Enabled</Status><Expiration><Days>30</Days></Expiration>"+
+		"<AbortMultipartUpload><Days>3</Days></AbortMultipartUpload>"+
+		"<NoncurrentVersionExpiration><NoncurrentDays>5</NoncurrentDays></NoncurrentVersionExpiration>"+
+		"</Rule></LifecycleConfiguration>"), &res)
+	c.Assert(err, IsNil)
+	c.Assert(len(res.Rules), Equals, 1)
+	c.Assert(res.Rules[0].Expiration.Days, Equals, 30)
+	c.Assert(res.Rules[0].Tags, HasLen, 1)
+	c.Assert(res.Rules[0].Tags[0].Key, Equals, "k")
+	c.Assert(res.Rules[0].AbortMultipartUpload.Days, Equals, 3)
+	c.Assert(res.Rules[0].NoncurrentVersionExpiration.NoncurrentDays, Equals, 5)
+}
+
+func (s *OssTypeSuite) TestLifecycleTransitions(c *C) {
+	rule := LifecycleRule{ID: "cold", Prefix: "logs/", Status: "Enabled",
+		Transitions: []LifecycleTransition{{Days: 30, StorageClass: StorageIA}, {Days: 180, StorageClass: StorageArchive}}}
+	bs, err := xml.Marshal(lifecycleXML{Rules: convLifecycleRule([]LifecycleRule{rule})})
+	c.Assert(err, IsNil)
+	c.Assert(string(bs), Equals, "<LifecycleConfiguration><Rule><ID>cold</ID><Prefix>logs/</Prefix><Status>Enabled</Status>"+
+		"<Transition><Days>30</Days><StorageClass>IA</StorageClass></Transition>"+
+		"<Transition><Days>180</Days><StorageClass>Archive</StorageClass></Transition></Rule></LifecycleConfiguration>")
+
+	var res GetBucketLifecycleResult
+	c.Assert(xml.Unmarshal(bs, &res), IsNil)
+	c.Assert(res.Rules[0].Transitions, HasLen, 2)
+	c.Assert(res.Rules[0].Transitions[1].Days, Equals, 180)
+	c.Assert(res.Rules[0].Transitions[1].StorageClass, Equals, StorageArchive)
+
+	// by date
+	rule.Transitions = []LifecycleTransition{{CreatedBeforeDate: time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC), StorageClass: StorageIA}}
+	bs, err = xml.Marshal(lifecycleXML{Rules: convLifecycleRule([]LifecycleRule{rule})})
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(bs), "<Transition><CreatedBeforeDate>2017-05-01T00:00:00.000Z</CreatedBeforeDate>"+
+		"<StorageClass>IA</StorageClass></Transition>"), Equals, true)
+}
+
+func (s *OssTypeSuite) TestValidateLifecycleRules(c *C) {
+	date := func(month int) time.Time {
+		return time.Date(2017, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	}
+	invalid := func(rules []LifecycleRule, reason string) {
+		err := validateLifecycleRules(rules)
+		c.Assert(err, NotNil)
+		c.Assert(strings.Contains(err.Error(), reason), Equals, true, Commentf("%s", err.Error()))
+	}
+
+	valid := []LifecycleRule{
+		BuildLifecycleRuleByDays("one", "one/", true, 3),
+		BuildLifecycleRuleByDate("two", "two/", true, 2017, 1, 1),
+		{Prefix: "three/", Status: "Enabled"},
+		{ID: "four", Prefix: "four/", Status: "Enabled"},
+		{ID: "tagged", Prefix: "four/", Status: "Enabled", Tags: []Tag{{Key: "env", Value: "dev"}}},
+		{Prefix: "five/", Status: "Enabled"},
+		{ID: "abort", Status: "Enabled", AbortMultipartUpload: &LifecycleAbortMultipartUpload{Days: 7}},
+		BuildLifecycleRuleByDays("sub", "one/sub/", true, 30),
+		{ID: "one-abort", Prefix: "one/", Status: "Enabled", AbortMultipartUpload: &LifecycleAbortMultipartUpload{Days: 1}},
+		{ID: "cold", Prefix: "cold/", Status: "Enabled", Expiration: LifecycleExpiration{Days: 365},
+			Transitions: []LifecycleTransition{{Days: 180, StorageClass: StorageArchive}, {Days: 30, StorageClass: StorageIA}}},
+		{ID: "date", Prefix: "date/", Status: "Enabled", Expiration: LifecycleExpiration{Date: date(1)},
+			Transitions: []LifecycleTransition{{CreatedBeforeDate: date(6), StorageClass: StorageIA},
+				{CreatedBeforeDate: date(3), StorageClass: StorageColdArchive}}},
+		{ID: "mix", Prefix: "mix/", Status: "Enabled", Expiration: LifecycleExpiration{Date: date(12)},
+			Transitions: []LifecycleTransition{{Days: 30, StorageClass: StorageIA}}},
+	}
+	c.Assert(validateLifecycleRules(valid), IsNil)
+	c.Assert(validateLifecycleRules(nil), IsNil)
+
+	// the duplicate ids
+	invalid([]LifecycleRule{valid[0], BuildLifecycleRuleByDays("one", "other/", true, 3)},
+		"rule one, the rule id is duplicate")
+
+	// the same action on the same prefix
+	invalid([]LifecycleRule{valid[0], BuildLifecycleRuleByDays("", "one/", true, 30)},
+		`rule #1, Expiration on the prefix "one/" is set by the rule one too`)
+	rule := BuildLifecycleRuleByDays("filter", "", true, 3)
+	rule.Filter = &LifecycleFilter{Prefix: "one/"}
+	invalid([]LifecycleRule{valid[0], rule}, `rule filter, Expiration on the prefix "one/" is set by the rule one too`)
+
+	// both the days and the date
+	rule = BuildLifecycleRuleByDays("", "p/", true, 3)
+	rule.Expiration.Date = date(1)
+	invalid([]LifecycleRule{valid[0], rule}, "rule #1, both Days and Date are set for Expiration")
+
+	rule = LifecycleRule{ID: "abort", AbortMultipartUpload: &LifecycleAbortMultipartUpload{Days: 1, CreatedBeforeDate: date(1)}}
+	invalid([]LifecycleRule{rule}, "rule abort, both Days and CreatedBeforeDate are set for AbortMultipartUpload")
+
+	// the tags or the prefix of the rule with the filter
+	rule = LifecycleRule{ID: "filter", Tags: []Tag{{Key: "env", Value: "dev"}},
+		Filter: &LifecycleFilter{Tags: []Tag{{Key: "env", Value: "prod"}}}}
+	invalid([]LifecycleRule{rule}, "rule filter, both Tags and Filter are set")
+
+	rule = LifecycleRule{ID: "filter", Prefix: "logs/", Filter: &LifecycleFilter{Prefix: "logs/"}}
+	invalid([]LifecycleRule{rule}, "rule filter, both Prefix and Filter are set")
+
+	rule = LifecycleRule{ID: "t", Transitions: []LifecycleTransition{{Days: 1, CreatedBeforeDate: date(1), StorageClass: StorageIA}}}
+	invalid([]LifecycleRule{rule}, "both Days and CreatedBeforeDate are set for the transition to IA")
+
+	// the transitions not getting colder
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageArchive}, {Days: 60, StorageClass: StorageIA}}
+	invalid([]LifecycleRule{rule}, "rule t, the transition to Archive is not after the transition to IA")
+
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageIA}, {Days: 30, StorageClass: StorageColdArchive}}
+	invalid([]LifecycleRule{rule}, "the transition to ColdArchive is not after the transition to IA")
+
+	rule.Transitions = []LifecycleTransition{{CreatedBeforeDate: date(3), StorageClass: StorageIA},
+		{CreatedBeforeDate: date(6), StorageClass: StorageArchive}}
+	invalid([]LifecycleRule{rule}, "the transition to Archive is not after the transition to IA")
+
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageIA}, {CreatedBeforeDate: date(1), StorageClass: StorageArchive}}
+	invalid([]LifecycleRule{rule}, "the transitions to IA and Archive mix Days and CreatedBeforeDate")
+
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageIA}, {Days: 60, StorageClass: StorageIA}}
+	invalid([]LifecycleRule{rule}, "the transition to IA is duplicate")
+
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageStandard}}
+	invalid([]LifecycleRule{rule}, "the transition to Standard is not supported")
+
+	// the expiration before the last transition
+	rule.Transitions = []LifecycleTransition{{Days: 30, StorageClass: StorageIA}, {Days: 60, StorageClass: StorageArchive}}
+	rule.Expiration = LifecycleExpiration{Days: 60}
+	invalid([]LifecycleRule{rule}, "the expiration is not after the transition to Archive")
+}
+
+func (s *OssTypeSuite) TestLifecycleFilterRoundTrip(c *C) {
+	roundTrip := func(rule LifecycleRule) (string, LifecycleRule) {
+		bs, err := xml.Marshal(lifecycleXML{Rules: convLifecycleRule([]LifecycleRule{rule})})
+		c.Assert(err, IsNil)
+		var res GetBucketLifecycleResult
+		c.Assert(xml.Unmarshal(bs, &res), IsNil)
+		c.Assert(res.Rules, HasLen, 1)
+		return string(bs), res.Rules[0]
+	}
+
+	// the prefix AND the tag are combined under And
+	rule := BuildLifecycleRuleByDays("prod-logs", "", true, 30)
+	rule.Filter = &LifecycleFilter{Prefix: "logs/", Tags: []Tag{{Key: "env", Value: "prod"}}}
+	body, res := roundTrip(rule)
+	c.Assert(body, Equals, "<LifecycleConfiguration><Rule><ID>prod-logs</ID>"+
+		"<Filter><And><Prefix>logs/</Prefix><Tag><Key>env</Key><Value>prod</Value></Tag></And></Filter>"+
+		"<Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>")
+	c.Assert(res.Filter.Prefix, Equals, "logs/")
+	c.Assert(res.Filter.Tags, HasLen, 1)
+	c.Assert(res.Filter.Tags[0].Key, Equals, "env")
+	c.Assert(res.Filter.Tags[0].Value, Equals, "prod")
+	c.Assert(res.Expiration.Days, Equals, 30)
+
+	// multiple tags
+	rule.Filter = &LifecycleFilter{Tags: []Tag{{Key: "env", Value: "prod"}, {Key: "team", Value: "ops"}}}
+	body, res = roundTrip(rule)
+	c.Assert(strings.Contains(body, "<Filter><And><Tag><Key>env</Key>"), Equals, true)
+	c.Assert(res.Filter.Prefix, Equals, "")
+	c.Assert(res.Filter.Tags, HasLen, 2)
+	c.Assert(res.Filter.Tags[1].Value, Equals, "ops")
+
+	// a single condition is not under And
+	rule.Filter = &LifecycleFilter{Prefix: "logs/"}
+	body, res = roundTrip(rule)
+	c.Assert(strings.Contains(body, "<Filter><Prefix>logs/</Prefix></Filter>"), Equals, true)
+	c.Assert(res.Filter.Prefix, Equals, "logs/")
+	c.Assert(res.Filter.Tags, HasLen, 0)
+
+	// no filter
+	body, res = roundTrip(BuildLifecycleRuleByDays("id", "logs/", true, 30))
+	c.Assert(strings.Contains(body, "Filter"), Equals, false)
+	c.Assert(res.Filter, IsNil)
+	c.Assert(res.Prefix, Equals, "logs/")
+}
+
 func (s *OssTypeSuite) TestDecodeDeleteObjectsResult(c *C) {
 	var res DeleteObjectsResult
 	err := decodeDeleteObjectsResult(&res)
@@ -125,3 +341,83 @@ func (s *OssTypeSuite) TestSortUploadPart(c *C) {
 	c.Assert(parts[4].PartNumber, Equals, 5)
 	c.Assert(parts[4].ETag, Equals, "E5")
 }
+
+func (s *OssTypeSuite) TestTaggingXML(c *C) {
+	tagging := Tagging{Tags: []Tag{{Key: "k1", Value: "v1"}, {Key: goStr, Value: chnStr}}}
+	bs, err := xml.Marshal(tagging)
+	c.Assert(err, IsNil)
+	c.Assert(string(bs), Equals, "<Tagging><TagSet><Tag><Key>k1</Key><Value>v1</Value></Tag>"+
+		"<Tag><Key>go go + go &lt;&gt; go</Key><Value>"+chnStr+"</Value></Tag></TagSet></Tagging>")
+
+	var out GetObjectTaggingResult
+	err = xml.Unmarshal(bs, &out)
+	c.Assert(err, IsNil)
+	c.Assert(len(out.Tags), Equals, 2)
+	c.Assert(out.Tags[1].Key, Equals, goStr)
+	c.Assert(out.Tags[1].Value, Equals, chnStr)
+
+	// no tag
+	out = GetObjectTaggingResult{}
+	err = xml.Unmarshal([]byte("<Tagging><TagSet/></Tagging>"), &out)
+	c.Assert(err, IsNil)
+	c.Assert(len(out.Tags), Equals, 0)
+}
+
+func (s *OssTypeSuite) TestVersioningXML(c *C) {
+	bs, err := xml.Marshal(VersioningConfig{Status: string(VersionEnabled)})
+	c.Assert(err, IsNil)
+	c.Assert(string(bs), Equals, "<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>")
+
+	// never enabled
+	var out GetBucketVersioningResult
+	err = xml.Unmarshal([]byte("<VersioningConfiguration/>"), &out)
+	c.Assert(err, IsNil)
+	c.Assert(out.Status, Equals, "")
+}
+
+func (s *OssTypeSuite) TestCORSMatch(c *C) {
+	cors := GetBucketCORSResult{CORSRules: []CORSRule{
+		{
+			AllowedOrigin: []string{"http://www.example.com", "https://*.example.com"},
+			AllowedMethod: []string{"GET", "PUT"},
+			AllowedHeader: []string{"Content-Type", "x-oss-meta-*"},
+		},
+		{
+			AllowedOrigin: []string{"*"},
+			AllowedMethod: []string{"GET"},
+		},
+	}}
+
+	// the exact origin
+	rule, ok := cors.Match("http://www.example.com", "PUT", []string{"content-type"})
+	c.Assert(ok, Equals, true)
+	c.Assert(rule, Equals, &cors.CORSRules[0])
+
+	// the wildcard origin and header, the headers are case-insensitive
+	rule, ok = cors.Match("https://img.example.com", "PUT", []string{"X-Oss-Meta-Author", "CONTENT-TYPE"})
+	c.Assert(ok, Equals, true)
+	c.Assert(rule, Equals, &cors.CORSRules[0])
+
+	// the wildcard does not match the empty prefix or suffix partly
+	_, ok = cors.Match("https://example.com", "PUT", nil)
+	c.Assert(ok, Equals, false)
+
+	// the header is not allowed by the first rule, and the second rule has no header
+	_, ok = cors.Match("https://img.example.com", "PUT", []string{"Authorization"})
+	c.Assert(ok, Equals, false)
+
+	// falls back to the second rule
+	rule, ok = cors.Match("http://other.com", "GET", nil)
+	c.Assert(ok, Equals, true)
+	c.Assert(rule, Equals, &cors.CORSRules[1])
+
+	_, ok = cors.Match("http://other.com", "GET", []string{"Content-Type"})
+	c.Assert(ok, Equals, false)
+
+	// the method is not allowed
+	_, ok = cors.Match("http://www.example.com", "DELETE", nil)
+	c.Assert(ok, Equals, false)
+
+	_, ok = GetBucketCORSResult{}.Match("http://www.example.com", "GET", nil)
+	c.Assert(ok, Equals, false)
+}
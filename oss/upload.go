@@ -1,12 +1,16 @@
 package oss
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -15,17 +19,32 @@ import (
 //
 // objectKey  object name
 // filePath   local file path to upload
-// partSize   the part size in byte
-// options    the options for uploading object.
+// partSize   the part size in byte. The file is split into MaxPartNum parts at most, GetPartSizeForFile gets a valid part size.
+// options    the options for uploading object. The multipart upload is aborted when the completion fails unless
+//            KeepPartsOnCompleteFailure is set, while the checkpoint always keeps it for the resume to complete it.
 //
 // error it will be nil if the operation succeeds; otherwise it's the error object.
 //
 func (bucket Bucket) UploadFile(objectKey, filePath string, partSize int64, options ...Option) error {
-	if partSize < MinPartSize || partSize > MaxPartSize {
-		return errors.New("oss: part size invalid range (1024KB, 5GB]")
+	if err := bucket.checkPartSize(partSize); err != nil {
+		return err
+	}
+
+	// the part count is checked before the upload starts
+	st, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if err = checkPartCount(st.Size(), partSize); err != nil {
+		return err
 	}
 
-	cpConf, err := getCpConfig(options, filePath)
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+	cpConf, err := getCpConfig(options, getCpFilePath(filepath.Dir(filePath), filepath.Base(filePath),
+		absPath, bucket.BucketName, objectKey, partSize))
 	if err != nil {
 		return err
 	}
@@ -39,24 +58,114 @@ func (bucket Bucket) UploadFile(objectKey, filePath string, partSize int64, opti
 	return bucket.uploadFile(objectKey, filePath, partSize, options, routines)
 }
 
+//
+// UploadFileWithContext Uploads the file by multipart upload bound to the context, checks out UploadFile for the details.
+//
+// It's the same as passing WithContext(ctx) in the options.
+//
+func (bucket Bucket) UploadFileWithContext(ctx context.Context, objectKey, filePath string, partSize int64, options ...Option) error {
+	options = append(options[:len(options):len(options)], WithContext(ctx))
+	return bucket.UploadFile(objectKey, filePath, partSize, options...)
+}
+
+//
+// UploadStream Uploads the data read from the io.ReaderAt by multipart upload, such as the data in memory or in another store.
+//
+// The data is split into the parts by the part size as UploadFile does, and the parts are read from the reader and uploaded
+// concurrently with Routines, so the reader must support the concurrent ReadAt. Progress and PartRetries work as
+// UploadFile, while Checkpoint is ignored because the reader has no identity to resume from.
+//
+// objectKey  object name
+// reader     the reader of the data, it's read in the range [0, size).
+// size       the size of the data.
+// partSize   the part size in byte. The data is split into MaxPartNum parts at most, GetPartSizeForFile gets a valid part size.
+// options    the options for uploading object, checks out UploadFile.
+//
+// error it will be nil if the operation succeeds; otherwise it's the error object.
+//
+func (bucket Bucket) UploadStream(objectKey string, reader io.ReaderAt, size, partSize int64, options ...Option) error {
+	if err := bucket.checkPartSize(partSize); err != nil {
+		return err
+	}
+	if size < 0 {
+		return fmt.Errorf("oss: invalid size %d", size)
+	}
+	if err := checkPartCount(size, partSize); err != nil {
+		return err
+	}
+
+	return bucket.uploadChunks(objectKey, "", reader, splitSizeByPartSize(size, partSize), options, getRoutines(options))
+}
+
+// checkPartSize checks the part size is in the range accepted, the min part size could be overridden by MinPartSizeOverride
+func (bucket Bucket) checkPartSize(partSize int64) error {
+	minPartSize := bucket.getConfig().getMinPartSize()
+	if partSize < minPartSize || partSize > MaxPartSize {
+		return fmt.Errorf("oss: part size invalid range [%d, %d]", minPartSize, int64(MaxPartSize))
+	}
+	return nil
+}
+
 // ----- concurrent upload without checkpoint  -----
 
 // gets Checkpoint configuration
-func getCpConfig(options []Option, filePath string) (*cpConfig, error) {
+func getCpConfig(options []Option, defaultFilePath string) (*cpConfig, error) {
 	cpc := &cpConfig{}
 	cpcOpt, err := findOption(options, checkpointConfig, nil)
 	if err != nil || cpcOpt == nil {
 		return cpc, err
 	}
 
-	cpc = cpcOpt.(*cpConfig)
+	// the config in the option is copied, so that the option could be reused for the other transfers
+	*cpc = *cpcOpt.(*cpConfig)
 	if cpc.IsEnable && cpc.FilePath == "" {
-		cpc.FilePath = filePath + CheckpointFileSuffix
+		cpc.FilePath = defaultFilePath
 	}
 
 	return cpc, nil
 }
 
+// getCpFilePath gets the default checkpoint file path of the transfer, it's name.<md5>.cp under the dir.
+// The MD5 is of the source, the destination object and the part size, so that the distinct transfers,
+// such as the uploads of the same file to different objects, do not share the checkpoint.
+func getCpFilePath(dir, name, src, destBucketName, destObjectKey string, partSize int64) string {
+	h := md5.New()
+	io.WriteString(h, src+"\n"+destBucketName+"\n"+destObjectKey+"\n"+strconv.FormatInt(partSize, 10))
+	return filepath.Join(dir, name+"."+hex.EncodeToString(h.Sum(nil))+CheckpointFileSuffix)
+}
+
+// checkpoint dump batching, the checkpoint is written every interval completed parts
+type cpDumpBatcher struct {
+	interval int // the completed parts count between two dumps
+	pending  int // the completed parts not written yet
+}
+
+// gets the checkpoint dump batcher. by default every part is written.
+func getCpDumpBatcher(options []Option) *cpDumpBatcher {
+	interval, err := findOption(options, cpDumpInterval, 1)
+	if err != nil || interval.(int) < 1 {
+		return &cpDumpBatcher{interval: 1}
+	}
+	return &cpDumpBatcher{interval: interval.(int)}
+}
+
+// completes one part and returns true if the checkpoint should be written
+func (b *cpDumpBatcher) next() bool {
+	b.pending++
+	if b.pending >= b.interval {
+		b.pending = 0
+		return true
+	}
+	return false
+}
+
+// returns true if there're parts not written yet
+func (b *cpDumpBatcher) flush() bool {
+	dirty := b.pending > 0
+	b.pending = 0
+	return dirty
+}
+
 // gets the thread count. by default it's 1.
 func getRoutines(options []Option) int {
 	rtnOpt, err := findOption(options, routineNum, nil)
@@ -74,13 +183,39 @@ func getRoutines(options []Option) int {
 	return rs
 }
 
-// gets the progress callback
+// gets the retry count of each part. by default it's 0.
+func getPartRetries(options []Option) int {
+	retries, err := findOption(options, partRetries, 0)
+	if err != nil || retries.(int) < 0 {
+		return 0
+	}
+	return retries.(int)
+}
+
+// the backoff before the retry of a part, it's doubled on each retry and up to maxPartRetryBackoff
+var partRetryBackoff = 100 * time.Millisecond
+
+const maxPartRetryBackoff = 5 * time.Second
+
+func getPartRetryBackoff(retry int) time.Duration {
+	backoff := partRetryBackoff
+	for i := 0; i < retry && backoff < maxPartRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxPartRetryBackoff {
+		backoff = maxPartRetryBackoff
+	}
+	return backoff
+}
+
+// gets the progress callback, the rates of the events are filled for the transfer
 func getProgressListener(options []Option) ProgressListener {
 	isSet, listener, _ := isOptionSet(options, progressListener)
 	if !isSet {
 		return nil
 	}
-	return listener.(ProgressListener)
+	interval, _ := findOption(options, progressInterval, time.Duration(0))
+	return newRateListener(listener.(ProgressListener), interval.(time.Duration))
 }
 
 // test purpose hook
@@ -96,20 +231,29 @@ func defaultUploadPart(id int, chunk FileChunk) error {
 type workerArg struct {
 	bucket   *Bucket
 	filePath string
+	reader   io.ReaderAt // the parts are read from the reader instead of the file when it's set
 	imur     InitiateMultipartUploadResult
+	options  []Option
+	retries  int
 	hook     uploadPartHook
 }
 
+// gets the options for UploadPartFromFile, which is the context
+func getUploadPartOptions(options []Option) ([]Option, error) {
+	return filterOptions(options, func(key string) bool {
+		return isRequestArg(key)
+	})
+}
+
 // worker thread function
 func worker(id int, arg workerArg, jobs <-chan FileChunk, results chan<- UploadPart, failed chan<- error, die <-chan bool) {
 	for chunk := range jobs {
-		if err := arg.hook(id, chunk); err != nil {
-			failed <- err
-			break
-		}
-		part, err := arg.bucket.UploadPartFromFile(arg.imur, arg.filePath, chunk.Offset, chunk.Size, chunk.Number)
+		part, err := uploadPartWithRetry(id, arg, chunk, die)
 		if err != nil {
-			failed <- err
+			select {
+			case failed <- err:
+			case <-die:
+			}
 			break
 		}
 		select {
@@ -121,6 +265,40 @@ func worker(id int, arg workerArg, jobs <-chan FileChunk, results chan<- UploadP
 	}
 }
 
+// uploads the part, the failed part is retried with backoff up to arg.retries times
+func uploadPartWithRetry(id int, arg workerArg, chunk FileChunk, die <-chan bool) (UploadPart, error) {
+	ctx := getContext(arg.options)
+	for retry := 0; ; retry++ {
+		err := arg.hook(id, chunk)
+		if err == nil {
+			// the part is read from its offset of the reader or the file on every try
+			var part UploadPart
+			if arg.reader != nil {
+				part, err = arg.bucket.UploadPart(arg.imur, io.NewSectionReader(arg.reader, chunk.Offset, chunk.Size),
+					chunk.Size, chunk.Number, arg.options...)
+			} else {
+				part, err = arg.bucket.UploadPartFromFile(arg.imur, arg.filePath, chunk.Offset, chunk.Size, chunk.Number,
+					arg.options...)
+			}
+			if err == nil {
+				return part, nil
+			}
+		}
+
+		if retry >= arg.retries || ctx.Err() != nil {
+			return UploadPart{}, err
+		}
+
+		select {
+		case <-time.After(getPartRetryBackoff(retry)):
+		case <-ctx.Done():
+			return UploadPart{}, ctx.Err()
+		case <-die:
+			return UploadPart{}, err
+		}
+	}
+}
+
 // scheduler function
 func scheduler(jobs chan FileChunk, chunks []FileChunk) {
 	for _, chunk := range chunks {
@@ -139,9 +317,20 @@ func getTotalBytes(chunks []FileChunk) int64 {
 
 // concurrent upload, without checkpoint
 func (bucket Bucket) uploadFile(objectKey, filePath string, partSize int64, options []Option, routines int) error {
+	chunks, err := SplitFileByPartSize(filePath, partSize)
+	if err != nil {
+		return err
+	}
+
+	return bucket.uploadChunks(objectKey, filePath, nil, chunks, options, routines)
+}
+
+// concurrent upload of the chunks read from the file, or from the reader if it's not nil
+func (bucket Bucket) uploadChunks(objectKey, filePath string, reader io.ReaderAt, chunks []FileChunk,
+	options []Option, routines int) error {
 	listener := getProgressListener(options)
 
-	chunks, err := SplitFileByPartSize(filePath, partSize)
+	partOptions, err := getUploadPartOptions(options)
 	if err != nil {
 		return err
 	}
@@ -163,7 +352,7 @@ func (bucket Bucket) uploadFile(objectKey, filePath string, partSize int64, opti
 	publishProgress(listener, event)
 
 	// starts the worker thread
-	arg := workerArg{&bucket, filePath, imur, uploadPartHooker}
+	arg := workerArg{&bucket, filePath, reader, imur, partOptions, getPartRetries(options), uploadPartHooker}
 	for w := 1; w <= routines; w++ {
 		go worker(w, arg, jobs, results, failed, die)
 	}
@@ -195,18 +384,28 @@ func (bucket Bucket) uploadFile(objectKey, filePath string, partSize int64, opti
 		}
 	}
 
-	event = newProgressEvent(TransferStartedEvent, completedBytes, totalBytes)
+	event = newProgressEvent(TransferCompletedEvent, completedBytes, totalBytes)
 	publishProgress(listener, event)
 
 	// complete the multpart upload
 	_, err = bucket.CompleteMultipartUpload(imur, parts)
 	if err != nil {
-		bucket.AbortMultipartUpload(imur)
+		bucket.abortOnCompleteFailure(imur, options)
 		return err
 	}
 	return nil
 }
 
+// abortOnCompleteFailure aborts the multipart upload whose completion fails, unless KeepPartsOnCompleteFailure is set.
+// The parts kept could be found by ListMultipartUploads and ListUploadedParts to retry the completion.
+func (bucket Bucket) abortOnCompleteFailure(imur InitiateMultipartUploadResult, options []Option) {
+	// the options are checked by the upload before, the error is not expected here
+	isKeep, err := findOption(options, keepParts, false)
+	if err != nil || !isKeep.(bool) {
+		bucket.AbortMultipartUpload(imur)
+	}
+}
+
 // ----- concurrent upload with checkpoint  -----
 const uploadCpMagic = "FE8BB4EA-B593-4FAC-AD7A-2459A36E2E62"
 
@@ -217,6 +416,7 @@ type uploadCheckpoint struct {
 	FileStat  cpStat   // file state
 	ObjectKey string   // key
 	UploadID  string   // upload id
+	PartSize  int64    // part size the file is split by
 	Parts     []cpPart // all parts of the local file
 }
 
@@ -232,8 +432,9 @@ type cpPart struct {
 	IsCompleted bool       // upload complete flag
 }
 
-// check if the uploaded data is valid---it's valid when the file is not updated and the checkpoint data is valid.
-func (cp uploadCheckpoint) isValid(filePath string) (bool, error) {
+// check if the uploaded data is valid---it's valid when the file is not updated, the part size is not changed and the checkpoint data is valid.
+// The reason why it's invalid is returned, such as the part size changed. It's empty if it's valid.
+func (cp uploadCheckpoint) invalidReason(filePath string, partSize int64) (string, error) {
 	// compares the CP's magic number and MD5.
 	cpb := cp
 	cpb.MD5 = ""
@@ -242,39 +443,48 @@ func (cp uploadCheckpoint) isValid(filePath string) (bool, error) {
 	b64 := base64.StdEncoding.EncodeToString(sum[:])
 
 	if cp.Magic != uploadCpMagic || b64 != cp.MD5 {
-		return false, nil
+		return "the checkpoint is corrupted", nil
+	}
+
+	// the parts split by another part size do not match the chunks, so the upload restarts.
+	if cp.PartSize != partSize {
+		return fmt.Sprintf("the part size changed from %d to %d", cp.PartSize, partSize), nil
 	}
 
 	// makes sure if the local file is updated.
 	fd, err := os.Open(filePath)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 	defer fd.Close()
 
 	st, err := fd.Stat()
 	if err != nil {
-		return false, err
+		return "", err
+	}
+
+	// compares the file size, file's last modified time and file's MD5
+	if cp.FileStat.Size != st.Size() {
+		return fmt.Sprintf("the file size changed from %d to %d", cp.FileStat.Size, st.Size()), nil
+	}
+	if !cp.FileStat.LastModified.Equal(st.ModTime()) {
+		return "the file's last modified time changed", nil
 	}
 
 	md, err := calcFileMD5(filePath)
 	if err != nil {
-		return false, err
+		return "", err
 	}
-
-	// compares the file size, file's last modified time and file's MD5
-	if cp.FileStat.Size != st.Size() ||
-		cp.FileStat.LastModified != st.ModTime() ||
-		cp.FileStat.MD5 != md {
-		return false, nil
+	if cp.FileStat.MD5 != md {
+		return "the file's content changed", nil
 	}
 
-	return true, nil
+	return "", nil
 }
 
 // load from the file
-func (cp *uploadCheckpoint) load(filePath string) error {
-	contents, err := ioutil.ReadFile(filePath)
+func (cp *uploadCheckpoint) load(store CheckpointStore, key string) error {
+	contents, err := store.Load(key)
 	if err != nil {
 		return err
 	}
@@ -284,7 +494,7 @@ func (cp *uploadCheckpoint) load(filePath string) error {
 }
 
 // dump to the local file
-func (cp *uploadCheckpoint) dump(filePath string) error {
+func (cp *uploadCheckpoint) dump(store CheckpointStore, key string) error {
 	bcp := *cp
 
 	// calculates MD5
@@ -304,7 +514,7 @@ func (cp *uploadCheckpoint) dump(filePath string) error {
 	}
 
 	// dump
-	return ioutil.WriteFile(filePath, js, FilePermMode)
+	return store.Save(key, js)
 }
 
 // updates the part status
@@ -344,9 +554,19 @@ func (cp *uploadCheckpoint) getCompletedBytes() int64 {
 	return completedBytes
 }
 
-// calculates the MD5 for the specified local file
+// calculates the MD5 for the specified local file, it's base64 encoded
 func calcFileMD5(filePath string) (string, error) {
-	return "", nil
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	md := md5.New()
+	if _, err = io.Copy(md, fd); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(md.Sum(nil)), nil
 }
 
 // initialize the multipart upload
@@ -355,6 +575,7 @@ func prepare(cp *uploadCheckpoint, objectKey, filePath string, partSize int64, b
 	cp.Magic = uploadCpMagic
 	cp.FilePath = filePath
 	cp.ObjectKey = objectKey
+	cp.PartSize = partSize
 
 	// localfile
 	fd, err := os.Open(filePath)
@@ -397,36 +618,50 @@ func prepare(cp *uploadCheckpoint, objectKey, filePath string, partSize int64, b
 	return nil
 }
 
-// completes the multipart upload and deletes the local CP files
-func complete(cp *uploadCheckpoint, bucket *Bucket, parts []UploadPart, cpFilePath string) error {
+// completes the multipart upload and deletes the CP
+func complete(cp *uploadCheckpoint, bucket *Bucket, parts []UploadPart, cpStore CheckpointStore, cpFilePath string) error {
 	imur := InitiateMultipartUploadResult{Bucket: bucket.BucketName,
 		Key: cp.ObjectKey, UploadID: cp.UploadID}
 	_, err := bucket.CompleteMultipartUpload(imur, parts)
 	if err != nil {
 		return err
 	}
-	os.Remove(cpFilePath)
+	cpStore.Delete(cpFilePath)
 	return err
 }
 
 // concurrent upload with checkpoint
 func (bucket Bucket) uploadFileWithCp(objectKey, filePath string, partSize int64, options []Option, cpFilePath string, routines int) error {
 	listener := getProgressListener(options)
+	cpStore := getCpStore(options)
+
+	partOptions, err := getUploadPartOptions(options)
+	if err != nil {
+		return err
+	}
 
 	// LOAD CP data
 	ucp := uploadCheckpoint{}
-	err := ucp.load(cpFilePath)
+	err = ucp.load(cpStore, cpFilePath)
+	isLoaded := err == nil
 	if err != nil {
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	// LOAD error or the cp data is invalid.
-	valid, err := ucp.isValid(filePath)
-	if err != nil || !valid {
+	reason, err := ucp.invalidReason(filePath, partSize)
+	if err != nil || reason != "" {
+		if isLoaded && bucket.getConfig().isLogging() {
+			if err != nil {
+				reason = err.Error()
+			}
+			bucket.getConfig().Logger.Infof("oss: the checkpoint %s is not used and the upload of %s restarts, %s",
+				cpFilePath, filePath, reason)
+		}
 		if err = prepare(&ucp, objectKey, filePath, partSize, &bucket, options); err != nil {
 			return err
 		}
-		os.Remove(cpFilePath)
+		cpStore.Delete(cpFilePath)
 	}
 
 	chunks := ucp.todoParts()
@@ -445,7 +680,7 @@ func (bucket Bucket) uploadFileWithCp(objectKey, filePath string, partSize int64
 	publishProgress(listener, event)
 
 	// starts the workers
-	arg := workerArg{&bucket, filePath, imur, uploadPartHooker}
+	arg := workerArg{&bucket, filePath, nil, imur, partOptions, getPartRetries(options), uploadPartHooker}
 	for w := 1; w <= routines; w++ {
 		go worker(w, arg, jobs, results, failed, die)
 	}
@@ -454,18 +689,35 @@ func (bucket Bucket) uploadFileWithCp(objectKey, filePath string, partSize int64
 	go scheduler(jobs, chunks)
 
 	// waiting for the job finished
+	batcher := getCpDumpBatcher(options)
 	completed := 0
 	for completed < len(chunks) {
 		select {
 		case part := <-results:
 			completed++
 			ucp.updatePart(part)
-			ucp.dump(cpFilePath)
+			if batcher.next() {
+				ucp.dump(cpStore, cpFilePath)
+			}
 			completedBytes += ucp.Parts[part.PartNumber-1].Chunk.Size
 			event = newProgressEvent(TransferDataEvent, completedBytes, ucp.FileStat.Size)
 			publishProgress(listener, event)
 		case err := <-failed:
 			close(die)
+			// the parts uploaded before the failure are recorded too, so that they're not uploaded again
+			dirty := batcher.flush()
+			for drained := false; !drained; {
+				select {
+				case part := <-results:
+					ucp.updatePart(part)
+					dirty = true
+				default:
+					drained = true
+				}
+			}
+			if dirty {
+				ucp.dump(cpStore, cpFilePath)
+			}
 			event = newProgressEvent(TransferFailedEvent, completedBytes, ucp.FileStat.Size)
 			publishProgress(listener, event)
 			return err
@@ -476,10 +728,14 @@ func (bucket Bucket) uploadFileWithCp(objectKey, filePath string, partSize int64
 		}
 	}
 
+	if batcher.flush() {
+		ucp.dump(cpStore, cpFilePath)
+	}
+
 	event = newProgressEvent(TransferCompletedEvent, completedBytes, ucp.FileStat.Size)
 	publishProgress(listener, event)
 
 	// complete the multipart upload
-	err = complete(&ucp, &bucket, ucp.allParts(), cpFilePath)
+	err = complete(&ucp, &bucket, ucp.allParts(), cpStore, cpFilePath)
 	return err
 }
@@ -1,9 +1,16 @@
 package oss
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	. "gopkg.in/check.v1"
@@ -234,24 +241,28 @@ func (s *OssUploadSuite) TestUploadRoutineWithRecovery(c *C) {
 	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
 	newFile := "upload-new-file-2.jpg"
 
-	// use default Routines and default CP file path (fileName+.cp)
+	// use default Routines and default CP file path (fileName.<md5>.cp)
+	absPath, err := filepath.Abs(fileName)
+	c.Assert(err, IsNil)
+	cpFile := getCpFilePath(filepath.Dir(fileName), filepath.Base(fileName), absPath, bucketName, objectName, 100*1024)
+
 	// first upload for 4 parts
 	uploadPartHooker = ErrorHooker
-	err := s.bucket.UploadFile(objectName, fileName, 100*1024, Checkpoint(true, ""))
+	err = s.bucket.UploadFile(objectName, fileName, 100*1024, Checkpoint(true, ""))
 	c.Assert(err, NotNil)
 	c.Assert(err.Error(), Equals, "ErrorHooker")
 	uploadPartHooker = defaultUploadPart
 
 	// check cp
 	ucp := uploadCheckpoint{}
-	err = ucp.load(fileName + ".cp")
+	err = ucp.load(FileCheckpointStore{}, cpFile)
 	c.Assert(err, IsNil)
 	c.Assert(ucp.Magic, Equals, uploadCpMagic)
 	c.Assert(len(ucp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
 	c.Assert(ucp.FilePath, Equals, fileName)
 	c.Assert(ucp.FileStat.Size, Equals, int64(482048))
 	c.Assert(len(ucp.FileStat.LastModified.String()) > 0, Equals, true)
-	c.Assert(ucp.FileStat.MD5, Equals, "")
+	c.Assert(len(ucp.FileStat.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
 	c.Assert(ucp.ObjectKey, Equals, objectName)
 	c.Assert(len(ucp.UploadID), Equals, len("3F79722737D1469980DACEDCA325BB52"))
 	c.Assert(len(ucp.Parts), Equals, 5)
@@ -273,7 +284,7 @@ func (s *OssUploadSuite) TestUploadRoutineWithRecovery(c *C) {
 	err = s.bucket.DeleteObject(objectName)
 	c.Assert(err, IsNil)
 
-	err = ucp.load(fileName + ".cp")
+	err = ucp.load(FileCheckpointStore{}, cpFile)
 	c.Assert(err, NotNil)
 
 	// specifies Routines and CP
@@ -285,14 +296,14 @@ func (s *OssUploadSuite) TestUploadRoutineWithRecovery(c *C) {
 
 	// check cp
 	ucp = uploadCheckpoint{}
-	err = ucp.load(objectName + ".cp")
+	err = ucp.load(FileCheckpointStore{}, objectName+".cp")
 	c.Assert(err, IsNil)
 	c.Assert(ucp.Magic, Equals, uploadCpMagic)
 	c.Assert(len(ucp.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
 	c.Assert(ucp.FilePath, Equals, fileName)
 	c.Assert(ucp.FileStat.Size, Equals, int64(482048))
 	c.Assert(len(ucp.FileStat.LastModified.String()) > 0, Equals, true)
-	c.Assert(ucp.FileStat.MD5, Equals, "")
+	c.Assert(len(ucp.FileStat.MD5), Equals, len("LC34jZU5xK4hlxi3Qn3XGQ=="))
 	c.Assert(ucp.ObjectKey, Equals, objectName)
 	c.Assert(len(ucp.UploadID), Equals, len("3F79722737D1469980DACEDCA325BB52"))
 	c.Assert(len(ucp.Parts), Equals, 5)
@@ -313,7 +324,7 @@ func (s *OssUploadSuite) TestUploadRoutineWithRecovery(c *C) {
 	err = s.bucket.DeleteObject(objectName)
 	c.Assert(err, IsNil)
 
-	err = ucp.load(objectName + ".cp")
+	err = ucp.load(FileCheckpointStore{}, objectName+".cp")
 	c.Assert(err, NotNil)
 
 	// uploads all 5 parts without error
@@ -445,3 +456,537 @@ func copyFile(src, dst string) error {
 	_, err = io.Copy(dstFile, srcFile)
 	return err
 }
+
+// TestUploadFileMinPartSizeOverride uploads with a part size below MinPartSize to a gateway accepting it
+func (s *OssBucketMockSuite) TestUploadFileMinPartSizeOverride(c *C) {
+	var parts int32
+	handler := newMultipartMockHandler(multipartMock{
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			atomic.AddInt32(&parts, 1)
+			return true
+		},
+	})
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+	partSize := int64(64 * 1024)
+
+	// rejected by default
+	bucket, server := newMockBucket(handler, c)
+	err := bucket.UploadFile("obj", fileName, partSize)
+	c.Assert(err, NotNil)
+	server.Close()
+
+	// accepted with the override
+	bucket, server = newMockBucket(handler, c, MinPartSizeOverride(partSize))
+	defer server.Close()
+	err = bucket.UploadFile("obj", fileName, partSize)
+	c.Assert(err, IsNil)
+
+	fi, err := os.Stat(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(int64(atomic.LoadInt32(&parts)), Equals, (fi.Size()+partSize-1)/partSize)
+
+	// the max part size is not overridden
+	err = bucket.UploadFile("obj", fileName, MaxPartSize+1)
+	c.Assert(err, NotNil)
+}
+
+// TestUploadFileWithContext cancels the upload while the parts are in flight
+func (s *OssBucketMockSuite) TestUploadFileWithContext(c *C) {
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err := bucket.UploadFileWithContext(ctx, "obj", "../sample/BingWallpaper-2015-11-07.jpg", MinPartSize, Routines(3))
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+}
+
+// TestUploadFilePartRetries injects transient failures on one part
+func (s *OssBucketMockSuite) TestUploadFilePartRetries(c *C) {
+	var parts int32
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			atomic.AddInt32(&parts, 1)
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	var failures int32
+	uploadPartHooker = func(id int, chunk FileChunk) error {
+		if chunk.Number == 2 && atomic.AddInt32(&failures, 1) <= 2 {
+			return fmt.Errorf("transient failure of part %d", chunk.Number)
+		}
+		return nil
+	}
+	defer func() { uploadPartHooker = defaultUploadPart }()
+
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+	fi, err := os.Stat(fileName)
+	c.Assert(err, IsNil)
+	partCount := int32((fi.Size() + MinPartSize - 1) / MinPartSize)
+
+	// fails without enough retries
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Routines(2), PartRetries(1))
+	c.Assert(err, NotNil)
+
+	// completes after the part is retried
+	atomic.StoreInt32(&failures, 0)
+	atomic.StoreInt32(&parts, 0)
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Routines(2), PartRetries(2))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&failures), Equals, int32(3))
+	c.Assert(atomic.LoadInt32(&parts), Equals, partCount)
+}
+
+// TestUploadFileWithCpContentChanged modifies the file without changing its size and mtime before resuming
+func (s *OssBucketMockSuite) TestUploadFileWithCpContentChanged(c *C) {
+	var initiates, parts int32
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			atomic.AddInt32(&initiates, 1)
+			return ""
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			atomic.AddInt32(&parts, 1)
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	data, err := ioutil.ReadFile("../sample/BingWallpaper-2015-11-07.jpg")
+	c.Assert(err, IsNil)
+	fileName := "upload-cp-changed-" + randLowStr(5) + ".jpg"
+	cpFile := fileName + ".cp"
+	defer os.Remove(fileName)
+	defer os.Remove(cpFile)
+	partCount := int32((int64(len(data)) + MinPartSize - 1) / MinPartSize)
+
+	// the last part fails and the other parts are recorded in the checkpoint, then the upload is resumed after the change
+	defer func() { uploadPartHooker = defaultUploadPart }()
+	failAndResume := func(change func()) {
+		atomic.StoreInt32(&initiates, 0)
+		atomic.StoreInt32(&parts, 0)
+		c.Assert(ioutil.WriteFile(fileName, data, 0644), IsNil)
+		os.Remove(cpFile)
+
+		uploadPartHooker = func(id int, chunk FileChunk) error {
+			if chunk.Number == int(partCount) {
+				return fmt.Errorf("part %d failed", chunk.Number)
+			}
+			return nil
+		}
+		err := bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpFile))
+		c.Assert(err, NotNil)
+		c.Assert(atomic.LoadInt32(&parts), Equals, partCount-1)
+
+		change()
+		uploadPartHooker = defaultUploadPart
+		atomic.StoreInt32(&parts, 0)
+		err = bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpFile))
+		c.Assert(err, IsNil)
+	}
+
+	// unchanged file, only the failed part is uploaded
+	failAndResume(func() {})
+	c.Assert(atomic.LoadInt32(&initiates), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&parts), Equals, int32(1))
+
+	// changed content with the same size and mtime, all the parts are uploaded again
+	failAndResume(func() {
+		st, err := os.Stat(fileName)
+		c.Assert(err, IsNil)
+		fd, err := os.OpenFile(fileName, os.O_WRONLY, 0644)
+		c.Assert(err, IsNil)
+		_, err = fd.WriteAt([]byte("changed"), 0)
+		c.Assert(err, IsNil)
+		c.Assert(fd.Close(), IsNil)
+		c.Assert(os.Chtimes(fileName, st.ModTime(), st.ModTime()), IsNil)
+
+		st2, err := os.Stat(fileName)
+		c.Assert(err, IsNil)
+		c.Assert(st2.Size(), Equals, st.Size())
+		c.Assert(st2.ModTime().Equal(st.ModTime()), Equals, true)
+	})
+	c.Assert(atomic.LoadInt32(&initiates), Equals, int32(2))
+	c.Assert(atomic.LoadInt32(&parts), Equals, partCount)
+}
+
+// memCheckpointStore keeps the checkpoints in memory
+type memCheckpointStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (store *memCheckpointStore) Load(key string) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	data, ok := store.data[key]
+	if !ok {
+		return nil, fmt.Errorf("checkpoint %s not found", key)
+	}
+	return data, nil
+}
+
+func (store *memCheckpointStore) Save(key string, data []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.data[key] = data
+	return nil
+}
+
+func (store *memCheckpointStore) Delete(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.data, key)
+	return nil
+}
+
+// TestUploadFileWithCpStore resumes the upload from the checkpoint in a custom store
+func (s *OssBucketMockSuite) TestUploadFileWithCpStore(c *C) {
+	var initiates, parts int32
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			atomic.AddInt32(&initiates, 1)
+			return ""
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			atomic.AddInt32(&parts, 1)
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+	cpKey := "checkpoints/obj"
+	store := &memCheckpointStore{data: map[string][]byte{}}
+	fi, err := os.Stat(fileName)
+	c.Assert(err, IsNil)
+	partCount := int32((fi.Size() + MinPartSize - 1) / MinPartSize)
+
+	// the worker fails on the third part
+	uploadPartHooker = func(id int, chunk FileChunk) error {
+		if chunk.Number == 3 {
+			return fmt.Errorf("part %d failed", chunk.Number)
+		}
+		return nil
+	}
+	defer func() { uploadPartHooker = defaultUploadPart }()
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&parts), Equals, int32(2))
+
+	// the checkpoint is in the store only
+	ucp := uploadCheckpoint{}
+	c.Assert(ucp.load(store, cpKey), IsNil)
+	c.Assert(len(ucp.todoParts()), Equals, int(partCount-2))
+	_, err = os.Stat(cpKey)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// resumes the remaining parts
+	uploadPartHooker = defaultUploadPart
+	atomic.StoreInt32(&parts, 0)
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&initiates), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&parts), Equals, partCount-2)
+	c.Assert(len(store.data), Equals, 0)
+}
+
+// TestUploadFileWithCpSameFile uploads the same file to two objects at the same time with the default checkpoint files
+func (s *OssBucketMockSuite) TestUploadFileWithCpSameFile(c *C) {
+	var failing int32 = 1
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			return "upload-" + strings.TrimPrefix(r.URL.Path, "/mock-bucket/")
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			if r.URL.Query().Get("partNumber") == "2" && atomic.LoadInt32(&failing) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return false
+			}
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "oss-upload-cp-")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "data.bin")
+	c.Assert(ioutil.WriteFile(fileName, make([]byte, 3*MinPartSize), 0644), IsNil)
+
+	uploadBoth := func() []error {
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, key := range []string{"obj-a", "obj-b"} {
+			wg.Add(1)
+			go func(i int, key string) {
+				defer wg.Done()
+				errs[i] = bucket.UploadFile(key, fileName, MinPartSize, Checkpoint(true, ""))
+			}(i, key)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	// both fail on the second part after the first one is recorded, each has its own checkpoint
+	for _, err := range uploadBoth() {
+		c.Assert(err, NotNil)
+	}
+	cpFiles, err := filepath.Glob(filepath.Join(dir, "data.bin.*.cp"))
+	c.Assert(err, IsNil)
+	c.Assert(len(cpFiles), Equals, 2)
+	keys := map[string]string{}
+	for _, cpFile := range cpFiles {
+		ucp := uploadCheckpoint{}
+		c.Assert(ucp.load(FileCheckpointStore{}, cpFile), IsNil)
+		c.Assert(ucp.UploadID, Equals, "upload-"+ucp.ObjectKey)
+		keys[ucp.ObjectKey] = cpFile
+	}
+	c.Assert(len(keys), Equals, 2)
+
+	// the part size is in the name too
+	absPath, err := filepath.Abs(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(getCpFilePath(dir, "data.bin", absPath, "mock-bucket", "obj-a", MinPartSize), Equals, keys["obj-a"])
+	c.Assert(getCpFilePath(dir, "data.bin", absPath, "mock-bucket", "obj-a", 2*MinPartSize), Not(Equals), keys["obj-a"])
+
+	// both resume and remove their checkpoints
+	atomic.StoreInt32(&failing, 0)
+	for _, err := range uploadBoth() {
+		c.Assert(err, IsNil)
+	}
+	cpFiles, err = filepath.Glob(filepath.Join(dir, "*.cp"))
+	c.Assert(err, IsNil)
+	c.Assert(len(cpFiles), Equals, 0)
+}
+
+// TestUploadFileWithCpPartSizeChanged restarts the upload when it's resumed with another part size
+func (s *OssBucketMockSuite) TestUploadFileWithCpPartSizeChanged(c *C) {
+	var initiates, parts int32
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			atomic.AddInt32(&initiates, 1)
+			return ""
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			atomic.AddInt32(&parts, 1)
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+	cpKey := "checkpoints/obj"
+	store := &memCheckpointStore{data: map[string][]byte{}}
+	fi, err := os.Stat(fileName)
+	c.Assert(err, IsNil)
+
+	// the worker fails on the third part
+	uploadPartHooker = func(id int, chunk FileChunk) error {
+		if chunk.Number == 3 {
+			return fmt.Errorf("part %d failed", chunk.Number)
+		}
+		return nil
+	}
+	defer func() { uploadPartHooker = defaultUploadPart }()
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Routines(1), Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, NotNil)
+
+	ucp := uploadCheckpoint{}
+	c.Assert(ucp.load(store, cpKey), IsNil)
+	c.Assert(ucp.PartSize, Equals, int64(MinPartSize))
+	c.Assert(len(ucp.todoParts()) < len(ucp.Parts), Equals, true)
+
+	// the parts uploaded are not reused, all the parts are uploaded in a new multipart upload
+	uploadPartHooker = defaultUploadPart
+	atomic.StoreInt32(&initiates, 0)
+	atomic.StoreInt32(&parts, 0)
+	partSize := int64(2 * MinPartSize)
+	logger := &recordingLogger{}
+	bucket.Client.Config.Logger = logger
+	err = bucket.UploadFile("obj", fileName, partSize, Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, IsNil)
+	c.Assert(logger.messages[0], Equals, "INFO oss: the checkpoint checkpoints/obj is not used and the upload of "+fileName+
+		" restarts, the part size changed from 102400 to 204800")
+	c.Assert(atomic.LoadInt32(&initiates), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&parts), Equals, int32((fi.Size()+partSize-1)/partSize))
+	c.Assert(len(store.data), Equals, 0)
+}
+
+// TestUploadFileTooManyParts fails before any request when the file is split into too many parts
+func (s *OssBucketMockSuite) TestUploadFileTooManyParts(c *C) {
+	var requests int32
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}, c)
+	defer server.Close()
+
+	// a 50GB sparse file with 1MB parts
+	fd, err := ioutil.TempFile("", "oss-sparse-")
+	c.Assert(err, IsNil)
+	defer os.Remove(fd.Name())
+	c.Assert(fd.Truncate(50*1024*1024*1024), IsNil)
+	fd.Close()
+
+	err = bucket.UploadFile("obj", fd.Name(), 1024*1024)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "too many parts 51200"), Equals, true)
+
+	err = bucket.UploadFile("obj", fd.Name(), 1024*1024, Checkpoint(true, fd.Name()+".cp"))
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(0))
+}
+
+// TestUploadFileKeepPartsOnCompleteFailure keeps the parts when the completion fails
+func (s *OssBucketMockSuite) TestUploadFileKeepPartsOnCompleteFailure(c *C) {
+	var mu sync.Mutex
+	uploads := map[string]map[string]bool{}
+	failComplete := true
+	initiates, puts, aborts := 0, 0, 0
+	handler := newMultipartMockHandler(multipartMock{
+		initiate: func(w http.ResponseWriter, r *http.Request) string {
+			initiates++
+			uploadID := fmt.Sprintf("upload-%d", initiates)
+			uploads[uploadID] = map[string]bool{}
+			return uploadID
+		},
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			puts++
+			uploads[r.URL.Query().Get("uploadId")][r.URL.Query().Get("partNumber")] = true
+			return true
+		},
+		complete: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			if failComplete {
+				w.WriteHeader(http.StatusInternalServerError)
+				io.WriteString(w, `<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>`)
+				return false
+			}
+			delete(uploads, r.URL.Query().Get("uploadId"))
+			return true
+		},
+		abort: func(r *http.Request) {
+			aborts++
+			delete(uploads, r.URL.Query().Get("uploadId"))
+		},
+	})
+	bucket, server := newMockBucket(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		handler(w, r)
+	}, c)
+	defer server.Close()
+
+	fileName := "../sample/BingWallpaper-2015-11-07.jpg"
+
+	// by default the multipart upload is aborted
+	err := bucket.UploadFile("obj", fileName, MinPartSize)
+	c.Assert(err, NotNil)
+	c.Assert(aborts, Equals, 1)
+	c.Assert(len(uploads), Equals, 0)
+
+	// the parts are kept
+	puts = 0
+	err = bucket.UploadFile("obj", fileName, MinPartSize, KeepPartsOnCompleteFailure(true))
+	c.Assert(err, NotNil)
+	c.Assert(aborts, Equals, 1)
+	c.Assert(len(uploads), Equals, 1)
+	c.Assert(len(uploads["upload-2"]), Equals, puts)
+
+	// the checkpoint is kept as well, the resume completes the upload without uploading the parts again
+	cpKey := "checkpoints/obj"
+	store := &memCheckpointStore{data: map[string][]byte{}}
+	puts = 0
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, NotNil)
+	c.Assert(aborts, Equals, 1)
+	c.Assert(len(uploads["upload-3"]), Equals, puts)
+	c.Assert(len(store.data), Equals, 1)
+
+	failComplete, puts = false, 0
+	err = bucket.UploadFile("obj", fileName, MinPartSize, Checkpoint(true, cpKey), CheckpointStorage(store))
+	c.Assert(err, IsNil)
+	c.Assert(puts, Equals, 0)
+	c.Assert(len(store.data), Equals, 0)
+	_, ok := uploads["upload-3"]
+	c.Assert(ok, Equals, false)
+}
+
+// TestUploadFileProgressEvents checks the started and completed events of the upload without checkpoint
+func (s *OssBucketMockSuite) TestUploadFileProgressEvents(c *C) {
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{}), c)
+	defer server.Close()
+
+	listener := &mockProgressListener{}
+	err := bucket.UploadFile("obj", "../sample/BingWallpaper-2015-11-07.jpg", MinPartSize, Routines(3), Progress(listener))
+	c.Assert(err, IsNil)
+
+	counts := map[ProgressEventType]int{}
+	for _, event := range listener.events {
+		counts[event]++
+	}
+	c.Assert(counts[TransferStartedEvent], Equals, 1)
+	c.Assert(counts[TransferCompletedEvent], Equals, 1)
+	c.Assert(counts[TransferFailedEvent], Equals, 0)
+	c.Assert(counts[TransferDataEvent] > 1, Equals, true)
+	c.Assert(listener.events[0], Equals, TransferStartedEvent)
+	c.Assert(listener.events[len(listener.events)-1], Equals, TransferCompletedEvent)
+}
+
+func (s *OssBucketMockSuite) TestUploadStream(c *C) {
+	var mu sync.Mutex
+	parts := map[string][]byte{}
+	var completeBody []byte
+	bucket, server := newMockBucket(newMultipartMockHandler(multipartMock{
+		uploadPart: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			mu.Lock()
+			parts[r.URL.Query().Get("partNumber")] = body
+			mu.Unlock()
+			return true
+		},
+		complete: func(w http.ResponseWriter, r *http.Request, body []byte) bool {
+			completeBody = body
+			return true
+		},
+	}), c)
+	defer server.Close()
+
+	// 250KB in memory is uploaded in 3 parts concurrently
+	data := []byte(strings.Repeat("0123456789", 25*1024))
+	listener := &mockProgressListener{}
+	err := bucket.UploadStream("obj", strings.NewReader(string(data)), int64(len(data)), MinPartSize,
+		Routines(3), Progress(listener))
+	c.Assert(err, IsNil)
+	c.Assert(len(parts), Equals, 3)
+	c.Assert(len(parts["3"]), Equals, len(data)-2*MinPartSize)
+	c.Assert(string(parts["1"])+string(parts["2"])+string(parts["3"]), Equals, string(data))
+	c.Assert(strings.Contains(string(completeBody), "<PartNumber>3</PartNumber>"), Equals, true)
+	c.Assert(listener.events[0], Equals, TransferStartedEvent)
+	c.Assert(listener.events[len(listener.events)-1], Equals, TransferCompletedEvent)
+
+	// only the range [0, size) is read
+	parts = map[string][]byte{}
+	err = bucket.UploadStream("obj", strings.NewReader(string(data)), MinPartSize+1, MinPartSize)
+	c.Assert(err, IsNil)
+	c.Assert(len(parts), Equals, 2)
+	c.Assert(string(parts["2"]), Equals, string(data[MinPartSize:MinPartSize+1]))
+
+	// the invalid part size or size
+	err = bucket.UploadStream("obj", strings.NewReader(string(data)), int64(len(data)), 1)
+	c.Assert(err, NotNil)
+	err = bucket.UploadStream("obj", strings.NewReader(string(data)), -1, MinPartSize)
+	c.Assert(err, NotNil)
+}
+
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"hash/crc64"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -227,11 +228,17 @@ func SplitFileByPartSize(fileName string, chunkSize int64) ([]FileChunk, error)
 	if err != nil {
 		return nil, err
 	}
-	var chunkN = stat.Size() / chunkSize
-	if chunkN >= 10000 {
-		return nil, errors.New("Too many parts, please increase part size.")
+	if err = checkPartCount(stat.Size(), chunkSize); err != nil {
+		return nil, err
 	}
 
+	return splitSizeByPartSize(stat.Size(), chunkSize), nil
+}
+
+// splits the size by the part size, the last chunk is smaller if the size is not a multiple of the part size
+func splitSizeByPartSize(size, chunkSize int64) []FileChunk {
+	var chunkN = size / chunkSize
+
 	var chunks []FileChunk
 	var chunk = FileChunk{}
 	for i := int64(0); i < chunkN; i++ {
@@ -241,14 +248,81 @@ func SplitFileByPartSize(fileName string, chunkSize int64) ([]FileChunk, error)
 		chunks = append(chunks, chunk)
 	}
 
-	if stat.Size()%chunkSize > 0 {
+	if size%chunkSize > 0 {
 		chunk.Number = len(chunks) + 1
 		chunk.Offset = int64(len(chunks)) * chunkSize
-		chunk.Size = stat.Size() % chunkSize
+		chunk.Size = size % chunkSize
 		chunks = append(chunks, chunk)
 	}
 
-	return chunks, nil
+	return chunks
+}
+
+// GetRemainingChunks gets the chunks of the file not uploaded yet, so that the multipart upload initiated elsewhere
+// could be resumed with the parts listed by ListUploadedParts. The file is split by the part size as SplitFileByPartSize
+// does, the uploaded part's number is its chunk's. It's an error if the uploaded part's size is not its chunk's,
+// which means the parts are not uploaded by the uniform part size.
+//
+// fileSize  the size of the file.
+// partSize  the part size of the upload. When it's 0, it's the largest size of the uploaded parts.
+// parts     the uploaded parts, such as ListUploadedPartsResult.UploadedParts.
+//
+// []FileChunk  the chunks to upload in the order of the number. It's valid when error is nil.
+// error  it's nil if no error; otherwise it's the error object.
+func GetRemainingChunks(fileSize, partSize int64, parts []UploadedPart) ([]FileChunk, error) {
+	if partSize <= 0 {
+		for _, part := range parts {
+			if int64(part.Size) > partSize {
+				partSize = int64(part.Size)
+			}
+		}
+		if partSize <= 0 {
+			return nil, errors.New("oss: the part size is unknown without the uploaded parts")
+		}
+	}
+	if err := checkPartCount(fileSize, partSize); err != nil {
+		return nil, err
+	}
+
+	chunks := splitSizeByPartSize(fileSize, partSize)
+	uploaded := make([]bool, len(chunks))
+	for _, part := range parts {
+		if part.PartNumber < 1 || part.PartNumber > len(chunks) {
+			return nil, fmt.Errorf("oss: the part %d is out of the %d parts of the file", part.PartNumber, len(chunks))
+		}
+		if chunk := chunks[part.PartNumber-1]; int64(part.Size) != chunk.Size {
+			return nil, fmt.Errorf("oss: the size %d of the part %d is not the chunk size %d by the part size %d",
+				part.Size, part.PartNumber, chunk.Size, partSize)
+		}
+		uploaded[part.PartNumber-1] = true
+	}
+
+	remaining := []FileChunk{}
+	for i, chunk := range chunks {
+		if !uploaded[i] {
+			remaining = append(remaining, chunk)
+		}
+	}
+	return remaining, nil
+}
+
+// GetPartSizeForFile gets the part size to split the file into MaxPartNum parts at most, it's MinPartSize at least.
+func GetPartSizeForFile(fileSize int64) int64 {
+	partSize := (fileSize + MaxPartNum - 1) / MaxPartNum
+	if partSize < MinPartSize {
+		return MinPartSize
+	}
+	return partSize
+}
+
+// checkPartCount checks the file is split into MaxPartNum parts at most, OSS rejects more parts on completing the upload.
+func checkPartCount(fileSize, partSize int64) error {
+	partCount := (fileSize + partSize - 1) / partSize
+	if partCount > MaxPartNum {
+		return fmt.Errorf("oss: too many parts %d by the part size %d, the max is %d, please increase the part size to %d at least",
+			partCount, partSize, MaxPartNum, GetPartSizeForFile(fileSize))
+	}
+	return nil
 }
 
 // GetPartEnd calculates the end position
@@ -259,7 +333,33 @@ func GetPartEnd(begin int64, total int64, per int64) int64 {
 	return begin + per - 1
 }
 
+// ParseMultipartETag parses the ETag of the object, the ETag of a multipart object is "<md5hex>-<part count>".
+// The quotes around the ETag are allowed. For a non-multipart object the md5hex is the whole ETag and the part count is 0.
+// With the part size of the upload, the part boundaries could be got, e.g. the part i starts from (i-1)*partSize.
+func ParseMultipartETag(etag string) (md5hex string, partCount int, isMultipart bool) {
+	etag = strings.Trim(etag, "\"")
+	pos := strings.LastIndex(etag, "-")
+	if pos < 0 {
+		return etag, 0, false
+	}
+
+	count, err := strconv.Atoi(etag[pos+1:])
+	if err != nil || count < 1 {
+		return etag, 0, false
+	}
+	return etag[:pos], count, true
+}
+
 // crcTable returns the Table constructed from the specified polynomial
 var crcTable = func() *crc64.Table {
 	return crc64.MakeTable(crc64.ECMA)
 }
+
+// copyBuffer copies src to dst with the buffer of the size, io.Copy is used when the size is not positive.
+// The reader and the writer are wrapped so that ReadFrom and WriteTo, such as os.File's, do not bypass the buffer.
+func copyBuffer(dst io.Writer, src io.Reader, size int) (int64, error) {
+	if size <= 0 {
+		return io.Copy(dst, src)
+	}
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
+}
@@ -1,6 +1,16 @@
 package oss
 
-import . "gopkg.in/check.v1"
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
 
 type OssUtilsSuite struct{}
 
@@ -80,6 +90,70 @@ func (s *OssUtilsSuite) TestUtilsSplitFile(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *OssUtilsSuite) TestGetPartSizeForFile(c *C) {
+	c.Assert(GetPartSizeForFile(0), Equals, int64(MinPartSize))
+	c.Assert(GetPartSizeForFile(MinPartSize*MaxPartNum), Equals, int64(MinPartSize))
+	c.Assert(GetPartSizeForFile(MinPartSize*MaxPartNum+1), Equals, int64(MinPartSize+1))
+
+	// a 50GB sparse file is split by 1MB into 51200 parts, which are too many
+	fileSize := int64(50 * 1024 * 1024 * 1024)
+	fd, err := ioutil.TempFile("", "oss-sparse-")
+	c.Assert(err, IsNil)
+	defer os.Remove(fd.Name())
+	c.Assert(fd.Truncate(fileSize), IsNil)
+	fd.Close()
+
+	_, err = SplitFileByPartSize(fd.Name(), 1024*1024)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "too many parts 51200"), Equals, true)
+
+	partSize := GetPartSizeForFile(fileSize)
+	c.Assert(partSize, Equals, int64(5368710))
+	parts, err := SplitFileByPartSize(fd.Name(), partSize)
+	c.Assert(err, IsNil)
+	c.Assert(len(parts), Equals, MaxPartNum)
+	c.Assert(parts[MaxPartNum-1].Offset+parts[MaxPartNum-1].Size, Equals, fileSize)
+}
+
+func (s *OssUtilsSuite) TestGetRemainingChunks(c *C) {
+	// 25 bytes split by 10 are the parts 1, 2 and 3, the part 2 is uploaded
+	parts := []UploadedPart{{PartNumber: 2, Size: 10}}
+	chunks, err := GetRemainingChunks(25, 10, parts)
+	c.Assert(err, IsNil)
+	c.Assert(chunks, DeepEquals, []FileChunk{{Number: 1, Offset: 0, Size: 10}, {Number: 3, Offset: 20, Size: 5}})
+
+	// the part size is inferred from the uploaded parts
+	chunks, err = GetRemainingChunks(25, 0, []UploadedPart{{PartNumber: 3, Size: 5}, {PartNumber: 1, Size: 10}})
+	c.Assert(err, IsNil)
+	c.Assert(chunks, DeepEquals, []FileChunk{{Number: 2, Offset: 10, Size: 10}})
+
+	// all uploaded
+	chunks, err = GetRemainingChunks(20, 10, []UploadedPart{{PartNumber: 1, Size: 10}, {PartNumber: 2, Size: 10}})
+	c.Assert(err, IsNil)
+	c.Assert(len(chunks), Equals, 0)
+
+	// nothing uploaded
+	chunks, err = GetRemainingChunks(20, 10, nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(chunks), Equals, 2)
+
+	// the part size is unknown
+	_, err = GetRemainingChunks(20, 0, nil)
+	c.Assert(err, NotNil)
+
+	// the part is not in the uniform part size
+	_, err = GetRemainingChunks(25, 10, []UploadedPart{{PartNumber: 1, Size: 8}})
+	c.Assert(err, NotNil)
+	_, err = GetRemainingChunks(25, 10, []UploadedPart{{PartNumber: 3, Size: 10}})
+	c.Assert(err, NotNil)
+
+	// the part is out of the file
+	_, err = GetRemainingChunks(25, 10, []UploadedPart{{PartNumber: 4, Size: 10}})
+	c.Assert(err, NotNil)
+	_, err = GetRemainingChunks(25, 10, []UploadedPart{{PartNumber: 0, Size: 10}})
+	c.Assert(err, NotNil)
+}
+
 func (s *OssUtilsSuite) TestUtilsFileExt(c *C) {
 	c.Assert(TypeByExtension("test.txt"), Equals, "text/plain; charset=utf-8")
 	c.Assert(TypeByExtension("test.jpg"), Equals, "image/jpeg")
@@ -218,3 +292,115 @@ func (s *OssUtilsSuite) TestAdjustRange(c *C) {
 	c.Assert(start, Equals, (int64)(0))
 	c.Assert(end, Equals, (int64)(8192))
 }
+
+func (s *OssUtilsSuite) TestParseMultipartETag(c *C) {
+	// multipart
+	md5hex, count, isMultipart := ParseMultipartETag("\"D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3-3\"")
+	c.Assert(isMultipart, Equals, true)
+	c.Assert(md5hex, Equals, "D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3")
+	c.Assert(count, Equals, 3)
+
+	md5hex, count, isMultipart = ParseMultipartETag("D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3-10000")
+	c.Assert(isMultipart, Equals, true)
+	c.Assert(md5hex, Equals, "D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3")
+	c.Assert(count, Equals, 10000)
+
+	// non-multipart
+	md5hex, count, isMultipart = ParseMultipartETag("\"D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3\"")
+	c.Assert(isMultipart, Equals, false)
+	c.Assert(md5hex, Equals, "D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3")
+	c.Assert(count, Equals, 0)
+
+	// invalid part count
+	_, count, isMultipart = ParseMultipartETag("\"D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3-x\"")
+	c.Assert(isMultipart, Equals, false)
+	c.Assert(count, Equals, 0)
+
+	_, _, isMultipart = ParseMultipartETag("D6E1F7E8A2F3D4B5C6A7B8C9D0E1F2A3-0")
+	c.Assert(isMultipart, Equals, false)
+
+	_, _, isMultipart = ParseMultipartETag("")
+	c.Assert(isMultipart, Equals, false)
+}
+
+// stubBodyReader reads the zero bytes of the size, it counts the reads
+type stubBodyReader struct {
+	remaining int64
+	reads     int
+}
+
+func (r *stubBodyReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	r.reads++
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	r.remaining -= int64(len(p))
+	return len(p), nil
+}
+
+// stubFileWriter discards the data, it counts the writes
+type stubFileWriter struct {
+	writes int
+}
+
+func (w *stubFileWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), nil
+}
+
+// ReadFrom is like os.File's, copyBuffer should not use it to bypass the buffer
+func (w *stubFileWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(ioutil.Discard, r)
+}
+
+func (s *OssUtilsSuite) TestCopyBuffer(c *C) {
+	client := &Client{Config: getDefaultOssConfig()}
+	c.Assert(client.Config.IOBufferSize, Equals, 0)
+	IOBufferSize(1024 * 1024)(client)
+	c.Assert(client.Config.IOBufferSize, Equals, 1024*1024)
+
+	for _, size := range []int{0, 32 * 1024, 1024 * 1024} {
+		r := &stubBodyReader{remaining: 8 * 1024 * 1024}
+		w := &stubFileWriter{}
+		written, err := copyBuffer(w, r, size)
+		c.Assert(err, IsNil)
+		c.Assert(written, Equals, int64(8*1024*1024))
+		if size == 0 {
+			// io.Copy uses ReadFrom of the writer
+			c.Assert(w.writes, Equals, 0)
+			continue
+		}
+		c.Assert(r.reads, Equals, 8*1024*1024/size)
+		c.Assert(w.writes, Equals, 8*1024*1024/size)
+	}
+
+	var buf bytes.Buffer
+	written, err := copyBuffer(&buf, strings.NewReader("123456789"), 4)
+	c.Assert(err, IsNil)
+	c.Assert(written, Equals, int64(9))
+	c.Assert(buf.String(), Equals, "123456789")
+}
+
+// BenchmarkCopyBuffer compares copying a 64MB body with the buffer sizes of IOBufferSize, the reads and writes
+// of each copy are logged, run it by "go test -run NONE -bench CopyBuffer".
+func BenchmarkCopyBuffer(b *testing.B) {
+	const bodySize = 64 * 1024 * 1024
+	for _, size := range []int{32 * 1024, 256 * 1024, 1024 * 1024, 4 * 1024 * 1024} {
+		b.Run(fmt.Sprintf("%dKB", size/1024), func(b *testing.B) {
+			b.SetBytes(bodySize)
+			var r *stubBodyReader
+			var w *stubFileWriter
+			for i := 0; i < b.N; i++ {
+				r = &stubBodyReader{remaining: bodySize}
+				w = &stubFileWriter{}
+				if _, err := copyBuffer(w, r, size); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.Logf("%d reads and %d writes per copy", r.reads, w.writes)
+		})
+	}
+}
@@ -17,6 +17,7 @@ func main() {
 	sample.BucketRefererSample()
 	sample.BucketLoggingSample()
 	sample.BucketCORSSample()
+	sample.BucketEncryptionSample()
 
 	sample.ObjectACLSample()
 	sample.ObjectMetaSample()
@@ -29,8 +30,11 @@ func main() {
 
 	sample.CnameSample()
 	sample.SignURLSample()
+	sample.PostPolicySample()
 
 	sample.ArchiveSample()
 
+	sample.InterceptorSample()
+
 	fmt.Println("All samples completed")
 }
@@ -0,0 +1,56 @@
+package sample
+
+import (
+	"fmt"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// BucketEncryptionSample demos how to set, get and delete the bucket's default server side encryption.
+func BucketEncryptionSample() {
+	// New Client
+	client, err := oss.New(endpoint, accessID, accessKey)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// creates the bucket with default parameters
+	err = client.CreateBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// case 1: encrypts the new objects by AES256 with the key managed by OSS
+	err = client.SetBucketEncryption(bucketName, oss.ServerEncryptionRule{SSEAlgorithm: oss.SSEAES256})
+	if err != nil {
+		HandleError(err)
+	}
+
+	// case 2: encrypts the new objects by KMS, the default KMS key is used without the KMSMasterKeyID.
+	// the existing rule is overwritten.
+	err = client.SetBucketEncryption(bucketName, oss.ServerEncryptionRule{SSEAlgorithm: oss.SSEKMS})
+	if err != nil {
+		HandleError(err)
+	}
+
+	// gets the bucket's encryption
+	rule, err := client.GetBucketEncryption(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+	fmt.Println("Bucket Encryption:", rule.SSEAlgorithm, rule.KMSMasterKeyID)
+
+	// deletes the bucket's encryption
+	err = client.DeleteBucketEncryption(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// deletes bucket
+	err = client.DeleteBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("BucketEncryptionSample completed")
+}
@@ -0,0 +1,92 @@
+package sample
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// requestSpan the span of an HTTP request, it stands for the span of the tracer such as OpenTelemetry's trace.Span
+type requestSpan struct {
+	name  string
+	start time.Time
+}
+
+// requestTracer starts the span of each request and ends it with the response
+type requestTracer struct {
+	mu    sync.Mutex
+	spans map[*http.Request]*requestSpan
+}
+
+// startSpan is the RequestInterceptor, with OpenTelemetry it's like
+// "ctx, span := tracer.Start(req.Context(), name)" and "otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))",
+// the injected headers are not signed so they do not break the signature.
+func (tracer *requestTracer) startSpan(req *http.Request) {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	tracer.spans[req] = &requestSpan{name: "oss " + req.Method + " " + req.URL.Path, start: time.Now()}
+}
+
+// endSpan is the ResponseInterceptor, with OpenTelemetry it's like "span.SetAttributes(...)", "span.RecordError(err)"
+// and "span.End()".
+func (tracer *requestTracer) endSpan(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	tracer.mu.Lock()
+	span := tracer.spans[req]
+	delete(tracer.spans, req)
+	tracer.mu.Unlock()
+	if span == nil {
+		return
+	}
+
+	// the bucket is in the host, the object key is the path
+	if err != nil {
+		fmt.Printf("span %s: host %s, error %v, duration %v\n", span.name, req.URL.Host, err, elapsed)
+		return
+	}
+	fmt.Printf("span %s: host %s, status %d, request id %s, duration %v\n", span.name, req.URL.Host,
+		resp.StatusCode, resp.Header.Get(oss.HTTPHeaderOssRequestID), elapsed)
+}
+
+// InterceptorSample shows how to trace each HTTP request by the interceptors
+func InterceptorSample() {
+	tracer := &requestTracer{spans: map[*http.Request]*requestSpan{}}
+	client, err := oss.New(endpoint, accessID, accessKey,
+		oss.AddRequestInterceptor(tracer.startSpan),
+		oss.AddResponseInterceptor(tracer.endSpan))
+	if err != nil {
+		HandleError(err)
+	}
+
+	err = client.CreateBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// each request prints its span
+	err = bucket.PutObject(objectKey, strings.NewReader("traced"))
+	if err != nil {
+		HandleError(err)
+	}
+
+	_, err = bucket.GetObjectDetailedMeta(objectKey)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// deletes the object and bucket
+	err = DeleteTestBucketAndObject(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("InterceptorSample completed")
+}
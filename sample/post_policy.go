@@ -0,0 +1,90 @@
+package sample
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// PostPolicySample the browser upload form sample
+func PostPolicySample() {
+	// creates Bucket
+	bucket, err := GetTestBucket(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// signs the policy on the server side, the form expires in 10 minutes and accepts the file up to 1MB
+	policy := oss.PostPolicy{
+		Expiration:          time.Now().Add(10 * time.Minute),
+		KeyStartsWith:       "upload/",
+		MaxContentLength:    1024 * 1024,
+		SuccessActionStatus: http.StatusCreated,
+	}
+	signature, err := bucket.SignPostPolicy(policy)
+	if err != nil {
+		HandleError(err)
+	}
+
+	// the form fields sent to the browser
+	fields := map[string]string{
+		"key":                   "upload/" + objectKey,
+		"policy":                signature.Policy,
+		"Signature":             signature.Signature,
+		"OSSAccessKeyId":        signature.AccessKeyID,
+		"success_action_status": strconv.Itoa(policy.SuccessActionStatus),
+	}
+	if signature.SecurityToken != "" {
+		fields["x-oss-security-token"] = signature.SecurityToken
+	}
+
+	// posts the form as the browser does, the file field must be the last one
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range fields {
+		writer.WriteField(k, v)
+	}
+	part, err := writer.CreateFormFile("file", "BingWallpaper-2015-11-07.jpg")
+	if err != nil {
+		HandleError(err)
+	}
+	fd, err := os.Open(localFile)
+	if err != nil {
+		HandleError(err)
+	}
+	io.Copy(part, fd)
+	fd.Close()
+	writer.Close()
+
+	bucketURL := "http://" + bucketName + "." + strings.TrimPrefix(endpoint, "http://") + "/"
+	resp, err := http.Post(bucketURL, writer.FormDataContentType(), &body)
+	if err != nil {
+		HandleError(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		HandleError(fmt.Errorf("post object failed: %s %s", resp.Status, data))
+	}
+
+	// deletes the object and bucket
+	err = bucket.DeleteObject("upload/" + objectKey)
+	if err != nil {
+		HandleError(err)
+	}
+	err = DeleteTestBucketAndObject(bucketName)
+	if err != nil {
+		HandleError(err)
+	}
+
+	fmt.Println("PostPolicySample completed")
+}
@@ -64,6 +64,17 @@ func SignURLSample() {
 		HandleError(err)
 	}
 
+	// get object with the speed limited to 800KB/s, the limit is in bits per second
+	signedURL, err = bucket.SignURL(objectKey, oss.HTTPGet, 60, oss.TrafficLimit(800*1024*8))
+	if err != nil {
+		HandleError(err)
+	}
+
+	err = bucket.GetObjectToFileWithURL(signedURL, "mynewfile-2.jpg")
+	if err != nil {
+		HandleError(err)
+	}
+
 	// deletes the object and bucket
 	err = DeleteTestBucketAndObject(bucketName)
 	if err != nil {